@@ -0,0 +1,186 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// cri-resmgr-replay feeds CRI traffic recorded by cri-resmgr (see the
+// --dump-record option) into a resource manager instance which is connected
+// to a fake runtime. The fake runtime answers each request with the reply
+// recorded for it, so that allocation decisions can be reproduced offline.
+//
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager"
+	"github.com/intel/cri-resource-manager/pkg/dump"
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+)
+
+// Our logger instance.
+var log = logger.NewLogger("replay")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [options] <record-file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if len(flag.Args()) != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	mismatches, err := replay(flag.Arg(0))
+	if err != nil {
+		log.Fatal("replay failed: %v", err)
+	}
+	if mismatches > 0 {
+		log.Error("%d replayed requests had a different outcome than recorded", mismatches)
+		os.Exit(1)
+	}
+}
+
+// replay replays the given record file, returning the number of outcome mismatches.
+func replay(path string) (int, error) {
+	records, err := dump.ReadRecords(path)
+	if err != nil {
+		return 0, err
+	}
+
+	dir, err := ioutil.TempDir("", "cri-resmgr-replay-")
+	if err != nil {
+		return 0, replayError("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	runtimeSocket := filepath.Join(dir, "runtime.sock")
+	flag.Set("runtime-socket", runtimeSocket)
+	flag.Set("image-socket", runtimeSocket)
+	setDefault("relay-socket", filepath.Join(dir, "relay.sock"))
+	setDefault("relay-dir", filepath.Join(dir, "relay"))
+	setDefault("agent-socket", filepath.Join(dir, "agent.sock"))
+	setDefault("config-socket", filepath.Join(dir, "config.sock"))
+
+	runtime := newFakeRuntime(runtimeSocket)
+	if err := runtime.Start(); err != nil {
+		return 0, err
+	}
+	defer runtime.Stop()
+
+	m, err := resmgr.NewResourceManager()
+	if err != nil {
+		return 0, replayError("failed to create resource manager: %v", err)
+	}
+	if err := m.Start(); err != nil {
+		return 0, replayError("failed to start resource manager: %v", err)
+	}
+	defer m.Stop()
+
+	relaySocket := flag.Lookup("relay-socket").Value.String()
+	conn, err := grpc.Dial(relaySocket,
+		grpc.WithInsecure(),
+		grpc.WithDialer(func(socket string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", socket, timeout)
+		}),
+	)
+	if err != nil {
+		return 0, replayError("failed to connect to relay socket %s: %v", relaySocket, err)
+	}
+	defer conn.Close()
+
+	mismatches := 0
+	for idx, rec := range records {
+		req, recorded, err := rec.Decode()
+		if err != nil {
+			return mismatches, err
+		}
+		_, rpl, err := dump.NewMessages(rec.Method)
+		if err != nil {
+			return mismatches, err
+		}
+
+		runtime.Expect(rec, req)
+		err = conn.Invoke(context.Background(), rec.Method, req, rpl)
+		runtime.Expect(nil, nil)
+
+		switch {
+		case err != nil && rec.Error == "":
+			log.Warn("#%d %s: failed (recorded success): %v", idx, methodName(rec.Method), err)
+			mismatches++
+		case err == nil && rec.Error != "":
+			log.Warn("#%d %s: succeeded (recorded failure: %s)", idx, methodName(rec.Method), rec.Error)
+			mismatches++
+		case err != nil:
+			log.Info("#%d %s: failed as recorded: %v", idx, methodName(rec.Method), err)
+		default:
+			if diff := compareReplies(recorded, rpl); diff != "" {
+				log.Warn("#%d %s: reply differs from recorded one: %s", idx, methodName(rec.Method), diff)
+				mismatches++
+			} else {
+				log.Info("#%d %s: ok", idx, methodName(rec.Method))
+			}
+		}
+	}
+
+	return mismatches, nil
+}
+
+// compareReplies compares a replayed reply to the recorded one, describing any difference.
+func compareReplies(recorded, replayed interface{}) string {
+	// compare the JSON encodings, which leave out internal protobuf bookkeeping
+	rec, err := json.Marshal(recorded)
+	if err != nil {
+		return fmt.Sprintf("failed to marshal recorded reply: %v", err)
+	}
+	rpl, err := json.Marshal(replayed)
+	if err != nil {
+		return fmt.Sprintf("failed to marshal replayed reply: %v", err)
+	}
+	if !bytes.Equal(rec, rpl) {
+		return fmt.Sprintf("expected %s, got %s", string(rec), string(rpl))
+	}
+	return ""
+}
+
+// setDefault sets a command line option unless it was given explicitly.
+func setDefault(name, value string) {
+	given := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			given = true
+		}
+	})
+	if !given {
+		flag.Set(name, value)
+	}
+}
+
+// replayError returns a formatted replay-specific error.
+func replayError(format string, args ...interface{}) error {
+	return fmt.Errorf("replay: "+format, args...)
+}
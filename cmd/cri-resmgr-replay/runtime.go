@@ -0,0 +1,149 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/intel/cri-resource-manager/pkg/dump"
+	"github.com/intel/cri-resource-manager/pkg/utils"
+)
+
+// fakeRuntime is a CRI runtime which answers requests with recorded replies.
+type fakeRuntime struct {
+	sync.Mutex
+	socket   string       // socket we serve on
+	server   *grpc.Server // gRPC server
+	expected *dump.Record // record being replayed, if its request should reach us
+	id       string       // container or pod sandbox ID of the expected request
+}
+
+// newFakeRuntime creates a fake runtime serving on the given socket.
+func newFakeRuntime(socket string) *fakeRuntime {
+	r := &fakeRuntime{
+		socket: socket,
+	}
+	r.server = grpc.NewServer(grpc.UnknownServiceHandler(r.handle))
+	return r
+}
+
+// Start starts serving requests.
+func (r *fakeRuntime) Start() error {
+	os.Remove(r.socket)
+	l, err := net.Listen("unix", r.socket)
+	if err != nil {
+		return replayError("failed to create fake runtime socket %s: %v", r.socket, err)
+	}
+
+	go r.server.Serve(l)
+
+	if err := utils.WaitForServer(r.socket, time.Second); err != nil {
+		return replayError("failed to start fake runtime: %v", err)
+	}
+
+	return nil
+}
+
+// Stop stops serving requests.
+func (r *fakeRuntime) Stop() {
+	r.server.Stop()
+}
+
+// Expect sets the record being replayed, with its decoded request. The recorded
+// reply answers the first request reaching us with the same method and container
+// or pod sandbox ID. Any other request, for instance one sent by the resource
+// manager on its own, gets an empty reply. Records of requests rejected before
+// reaching the runtime, and a nil record, expect nothing.
+func (r *fakeRuntime) Expect(rec *dump.Record, req interface{}) {
+	r.Lock()
+	defer r.Unlock()
+
+	if rec == nil || rec.Rejected {
+		r.expected, r.id = nil, ""
+		return
+	}
+	r.expected, r.id = rec, requestID(req)
+}
+
+// handle answers a request with the expected recorded reply, or an empty one.
+func (r *fakeRuntime) handle(srv interface{}, stream grpc.ServerStream) error {
+	name, ok := grpc.MethodFromServerStream(stream)
+	if !ok {
+		return status.Errorf(codes.Internal, "failed to determine method of request")
+	}
+
+	req, rpl, err := dump.NewMessages(name)
+	if err != nil {
+		return status.Errorf(codes.Unimplemented, "%v", err)
+	}
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	if rec := r.takeReply(methodName(name), req); rec != nil {
+		if rec.Error != "" {
+			return status.Error(codes.Unknown, rec.Error)
+		}
+		if _, rpl, err = rec.Decode(); err != nil {
+			return status.Errorf(codes.Internal, "%v", err)
+		}
+	} else {
+		log.Debug("fake runtime: no recorded reply for %s", name)
+	}
+
+	return stream.SendMsg(rpl)
+}
+
+// takeReply returns the expected record if it matches the request, at most once.
+func (r *fakeRuntime) takeReply(method string, req interface{}) *dump.Record {
+	r.Lock()
+	defer r.Unlock()
+
+	rec := r.expected
+	if rec == nil || methodName(rec.Method) != method || requestID(req) != r.id {
+		return nil
+	}
+	r.expected, r.id = nil, ""
+
+	return rec
+}
+
+// requestID returns the container or pod sandbox ID a request refers to, if any.
+func requestID(req interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(req))
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+	for _, name := range []string{"ContainerId", "PodSandboxId"} {
+		if f := v.FieldByName(name); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+			return f.String()
+		}
+	}
+	return ""
+}
+
+// methodName strips the service from a full gRPC method name.
+func methodName(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
@@ -16,11 +16,17 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 	"time"
 
+	"github.com/ghodss/yaml"
+
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/simulator"
 	"github.com/intel/cri-resource-manager/pkg/instrumentation"
 
 	"github.com/intel/cri-resource-manager/pkg/config"
@@ -30,10 +36,19 @@ import (
 
 func main() {
 	var printConfig bool
+	var configSchema bool
+	var configSchemaFile string
+	var checkCache string
 
 	log := logger.Default()
 
 	flag.BoolVar(&printConfig, "print-config", false, "Print configuration and exit.")
+	flag.BoolVar(&configSchema, "config-schema", false,
+		"Print JSON Schema of configuration and exit.")
+	flag.StringVar(&configSchemaFile, "config-schema-file", "",
+		"Write the JSON Schema of --config-schema to the given file instead of stdout.")
+	flag.StringVar(&checkCache, "check-cache", "",
+		"Check the given cache file, report its contents and any problems found, and exit.")
 	flag.Parse()
 
 	if len(flag.Args()) != 0 {
@@ -43,6 +58,14 @@ func main() {
 		case "config-help", "help":
 			config.Describe(args[1:]...)
 			os.Exit(0)
+		case "simulate":
+			if len(args) < 2 || len(args) > 3 {
+				log.Fatal("usage: %s simulate <script> [<output-file>]", os.Args[0])
+			}
+			if err := simulate(args[1], args[2:]...); err != nil {
+				log.Fatal("simulation failed: %v", err)
+			}
+			os.Exit(0)
 		default:
 			log.Error("unknown command line arguments: %s", strings.Join(flag.Args(), ","))
 			flag.Usage()
@@ -55,6 +78,20 @@ func main() {
 		os.Exit(0)
 	}
 
+	if configSchema {
+		if err := writeConfigSchema(configSchemaFile); err != nil {
+			log.Fatal("%v", err)
+		}
+		os.Exit(0)
+	}
+
+	if checkCache != "" {
+		if err := checkCacheFile(checkCache); err != nil {
+			log.Fatal("%v", err)
+		}
+		os.Exit(0)
+	}
+
 	log.Info("cri-resmgr (version %s, build %s) starting...", version.Version, version.Build)
 
 	if err := instrumentation.Start(); err != nil {
@@ -75,3 +112,53 @@ func main() {
 		time.Sleep(15 * time.Second)
 	}
 }
+
+// simulate runs the given simulation script, printing or saving the resulting decisions.
+func simulate(path string, output ...string) error {
+	script, err := simulator.LoadScript(path)
+	if err != nil {
+		return err
+	}
+	steps, err := simulator.Run(script)
+	if err != nil {
+		return err
+	}
+	out, err := yaml.Marshal(steps)
+	if err != nil {
+		return err
+	}
+	if len(output) > 0 {
+		return ioutil.WriteFile(output[0], out, 0644)
+	}
+	fmt.Print(string(out))
+	return nil
+}
+
+// writeConfigSchema writes the JSON Schema of our configuration to the given file, or stdout.
+func writeConfigSchema(path string) error {
+	schema, err := config.GetSchema()
+	if err != nil {
+		return fmt.Errorf("failed to generate configuration schema: %v", err)
+	}
+	if path == "" {
+		fmt.Println(schema.String())
+		return nil
+	}
+	if err := ioutil.WriteFile(path, []byte(schema.String()+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write configuration schema: %v", err)
+	}
+	return nil
+}
+
+// checkCacheFile checks the given cache file offline, printing a report about it.
+func checkCacheFile(path string) error {
+	report, err := cache.CheckFile(path)
+	if err != nil {
+		return err
+	}
+	fmt.Println(report.String())
+	if len(report.Problems) > 0 {
+		return fmt.Errorf("%d problem(s) found in cache file %s", len(report.Problems), path)
+	}
+	return nil
+}
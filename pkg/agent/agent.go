@@ -63,7 +63,7 @@ func NewResourceManagerAgent() (ResourceManagerAgent, error) {
 		return nil, agentError("failed to initialize gRPC server")
 	}
 
-	if a.updater, err = newConfigUpdater(opts.resmgrSocket); err != nil {
+	if a.updater, err = newConfigUpdater(a.cli, opts.resmgrSocket); err != nil {
 		return nil, agentError("failed to initialize config updater instance: %v", err)
 	}
 
@@ -18,13 +18,16 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net"
 	"time"
 
 	"google.golang.org/grpc"
+	k8sclient "k8s.io/client-go/kubernetes"
 
 	resmgr_v1 "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/config/api/v1"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
 	"github.com/intel/cri-resource-manager/pkg/log"
 )
 
@@ -37,6 +40,9 @@ const (
 	retryTimeout = 5 * time.Second
 )
 
+// configErrorsAnnotation is the node annotation we report configuration errors in.
+var configErrorsAnnotation = kubernetes.ResmgrKey("config-errors")
+
 // configUpdater handles sending configuration to cri-resmgr
 type configUpdater interface {
 	Start() error
@@ -47,12 +53,13 @@ type configUpdater interface {
 // updater implements configUpdater
 type updater struct {
 	log.Logger
+	cli       *k8sclient.Clientset
 	resmgrCli resmgr_v1.ConfigClient
 	newConfig chan *resmgrConfig
 }
 
-func newConfigUpdater(socket string) (configUpdater, error) {
-	u := &updater{Logger: log.NewLogger("config-updater")}
+func newConfigUpdater(cli *k8sclient.Clientset, socket string) (configUpdater, error) {
+	u := &updater{Logger: log.NewLogger("config-updater"), cli: cli}
 
 	c, err := newResmgrCli(opts.resmgrSocket)
 	if err != nil {
@@ -80,7 +87,7 @@ func (u *updater) Start() error {
 				ratelimit = time.After(rateLimitTimeout)
 
 			case _ = <-ratelimit:
-				mgrErr, err := u.setConfig(pending)
+				mgrErr, err := u.checkAndSetConfig(pending)
 				if err != nil {
 					u.Error("failed to send configuration update: %v", err)
 					ratelimit = time.After(retryTimeout)
@@ -105,14 +112,26 @@ func (u *updater) Update(c *resmgrConfig) {
 	u.newConfig <- c
 }
 
-func (u *updater) setConfig(cfg *resmgrConfig) (error, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), setConfigTimeout)
-	defer cancel()
+// checkAndSetConfig validates configuration and sends it to cri-resmgr if it is valid.
+// The outcome of validation is reported in an annotation of our node.
+func (u *updater) checkAndSetConfig(cfg *resmgrConfig) (error, error) {
+	reply, err := u.sendConfig(cfg, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.Error != "" {
+		u.annotateConfigErrors(reply.Errors)
+		return fmt.Errorf("configuration not pushed: %s", reply.Error), nil
+	}
 
-	req := &resmgr_v1.SetConfigRequest{NodeName: nodeName, Config: *cfg}
-	u.Debug("sending SetConfig request to cri-resmgr")
+	u.annotateConfigErrors(nil)
 
-	reply, err := u.resmgrCli.SetConfig(ctx, req, []grpc.CallOption{grpc.FailFast(false)}...)
+	return u.setConfig(cfg)
+}
+
+func (u *updater) setConfig(cfg *resmgrConfig) (error, error) {
+	reply, err := u.sendConfig(cfg, false)
 
 	switch {
 	case err != nil:
@@ -124,6 +143,34 @@ func (u *updater) setConfig(cfg *resmgrConfig) (error, error) {
 	}
 }
 
+// sendConfig sends a SetConfig request, optionally for validation only, to cri-resmgr.
+func (u *updater) sendConfig(cfg *resmgrConfig, validateOnly bool) (*resmgr_v1.SetConfigReply, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), setConfigTimeout)
+	defer cancel()
+
+	req := &resmgr_v1.SetConfigRequest{NodeName: nodeName, Config: *cfg, ValidateOnly: validateOnly}
+	u.Debug("sending SetConfig request (validate only: %v) to cri-resmgr", validateOnly)
+
+	return u.resmgrCli.SetConfig(ctx, req, []grpc.CallOption{grpc.FailFast(false)}...)
+}
+
+// annotateConfigErrors reports configuration errors in our node annotations, or clears them.
+func (u *updater) annotateConfigErrors(cfgErrors []*resmgr_v1.ConfigError) {
+	value := ""
+	if len(cfgErrors) > 0 {
+		data, err := json.Marshal(cfgErrors)
+		if err != nil {
+			u.Error("failed to marshal configuration errors: %v", err)
+			return
+		}
+		value = string(data)
+	}
+
+	if err := annotateNode(u.cli, configErrorsAnnotation, value); err != nil {
+		u.Error("failed to annotate node with configuration errors: %v", err)
+	}
+}
+
 func newResmgrCli(socket string) (resmgr_v1.ConfigClient, error) {
 	dialOpts := []grpc.DialOption{
 		grpc.WithInsecure(),
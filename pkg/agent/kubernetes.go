@@ -82,6 +82,26 @@ func patchNode(cli *k8sclient.Clientset, patchList []*agent_v1.JsonPatch) error
 	return nil
 }
 
+// annotateNode is a helper for setting or, if value is empty, removing a k8s Node annotation
+func annotateNode(cli *k8sclient.Clientset, key, value string) error {
+	var val interface{}
+	if value != "" {
+		val = value
+	}
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{key: val},
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return agentError("failed to marshal Node annotation patch: %v", err)
+	}
+
+	_, err = cli.CoreV1().Nodes().Patch(nodeName, types.MergePatchType, data)
+	return err
+}
+
 // patchNodeStatus is a helper for patching the status of a k8s Node object
 func patchNodeStatus(cli *k8sclient.Clientset, fields map[string]string) error {
 	patch, sep := fmt.Sprintf(`{"status": {`), ""
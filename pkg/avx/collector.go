@@ -125,6 +125,10 @@ type collector struct {
 // NewCollector creates new Prometheus collector for AVX metrics
 func NewCollector() (prometheus.Collector, error) {
 
+	if cgroups.UnifiedDir() == "" {
+		return nil, errors.New("AVX collector needs a cgroup v2 hierarchy")
+	}
+
 	elfFilepath := path.Join(bpfInstallpath, bpfBinaryName)
 
 	if err := checkElfKernelVersion(elfFilepath); err != nil {
@@ -167,7 +171,7 @@ func NewCollector() (prometheus.Collector, error) {
 	}
 
 	return &collector{
-		root:                     cgroups.V2path,
+		root:                     cgroups.UnifiedDir(),
 		bpfModule:                bpfModule,
 		avxContextSwitchCounters: avxSwitchCounters,
 		allContextSwitchCounters: allSwitchCounters,
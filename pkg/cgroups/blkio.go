@@ -0,0 +1,196 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroups
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultBlkioWeight is the default blkio.weight of a cgroup v1 block I/O cgroup.
+	DefaultBlkioWeight = 500
+	// DefaultIOWeight is the default io.weight of a cgroup v2 cgroup.
+	DefaultIOWeight = 100
+)
+
+// DeviceWeight is the proportional block I/O weight of a device.
+type DeviceWeight struct {
+	Major  int64
+	Minor  int64
+	Weight int64
+}
+
+// DeviceRate is a block I/O throttling rate (bytes or operations per second) of a device.
+type DeviceRate struct {
+	Major int64
+	Minor int64
+	Rate  int64
+}
+
+// BlockIOParameters contains the block I/O cgroup parameters of a cgroup.
+type BlockIOParameters struct {
+	Weight                  int64
+	WeightDevice            []DeviceWeight
+	ThrottleReadBpsDevice   []DeviceRate
+	ThrottleWriteBpsDevice  []DeviceRate
+	ThrottleReadIOPSDevice  []DeviceRate
+	ThrottleWriteIOPSDevice []DeviceRate
+}
+
+// NewBlockIOParameters creates a new BlockIOParameters instance with no weight set.
+func NewBlockIOParameters() BlockIOParameters {
+	return BlockIOParameters{Weight: -1}
+}
+
+// IsEmpty returns true if the parameters contain no weight or throttling settings.
+func (p BlockIOParameters) IsEmpty() bool {
+	return p.Weight < 0 && len(p.WeightDevice) == 0 &&
+		len(p.ThrottleReadBpsDevice) == 0 && len(p.ThrottleWriteBpsDevice) == 0 &&
+		len(p.ThrottleReadIOPSDevice) == 0 && len(p.ThrottleWriteIOPSDevice) == 0
+}
+
+// Reset returns the parameters necessary to undo the settings in p.
+func (p BlockIOParameters) Reset(v2 bool) BlockIOParameters {
+	r := NewBlockIOParameters()
+	if p.Weight >= 0 {
+		r.Weight = DefaultBlkioWeight
+		if v2 {
+			r.Weight = DefaultIOWeight
+		}
+	}
+	for _, w := range p.WeightDevice {
+		r.WeightDevice = append(r.WeightDevice, DeviceWeight{Major: w.Major, Minor: w.Minor})
+	}
+	resetRates := func(rates []DeviceRate) []DeviceRate {
+		var reset []DeviceRate
+		for _, rate := range rates {
+			reset = append(reset, DeviceRate{Major: rate.Major, Minor: rate.Minor})
+		}
+		return reset
+	}
+	r.ThrottleReadBpsDevice = resetRates(p.ThrottleReadBpsDevice)
+	r.ThrottleWriteBpsDevice = resetRates(p.ThrottleWriteBpsDevice)
+	r.ThrottleReadIOPSDevice = resetRates(p.ThrottleReadIOPSDevice)
+	r.ThrottleWriteIOPSDevice = resetRates(p.ThrottleWriteIOPSDevice)
+	return r
+}
+
+// SetBlkioParameters writes block I/O parameters to a cgroup v1 blkio cgroup directory.
+// A zero device weight or rate removes the corresponding per-device setting.
+func SetBlkioParameters(cgroupDir string, p BlockIOParameters) error {
+	if p.Weight >= 0 {
+		value := strconv.FormatInt(p.Weight, 10)
+		if err := writeFirstExisting(cgroupDir, value, "blkio.weight", "blkio.bfq.weight"); err != nil {
+			return err
+		}
+	}
+	for _, w := range p.WeightDevice {
+		value := fmt.Sprintf("%d:%d %d", w.Major, w.Minor, w.Weight)
+		if err := writeFirstExisting(cgroupDir, value, "blkio.weight_device", "blkio.bfq.weight_device"); err != nil {
+			return err
+		}
+	}
+	for entry, rates := range map[string][]DeviceRate{
+		"blkio.throttle.read_bps_device":   p.ThrottleReadBpsDevice,
+		"blkio.throttle.write_bps_device":  p.ThrottleWriteBpsDevice,
+		"blkio.throttle.read_iops_device":  p.ThrottleReadIOPSDevice,
+		"blkio.throttle.write_iops_device": p.ThrottleWriteIOPSDevice,
+	} {
+		for _, r := range rates {
+			value := fmt.Sprintf("%d:%d %d", r.Major, r.Minor, r.Rate)
+			if err := writeCgroupFile(filepath.Join(cgroupDir, entry), value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetIOParameters writes block I/O parameters to a cgroup v2 directory.
+// A zero device weight or rate removes the corresponding per-device setting.
+func SetIOParameters(cgroupDir string, p BlockIOParameters) error {
+	if p.Weight >= 0 {
+		value := "default " + strconv.FormatInt(p.Weight, 10)
+		if err := writeFirstExisting(cgroupDir, value, "io.weight", "io.bfq.weight"); err != nil {
+			return err
+		}
+	}
+	for _, w := range p.WeightDevice {
+		value := fmt.Sprintf("%d:%d default", w.Major, w.Minor)
+		if w.Weight > 0 {
+			value = fmt.Sprintf("%d:%d %d", w.Major, w.Minor, w.Weight)
+		}
+		if err := writeFirstExisting(cgroupDir, value, "io.weight", "io.bfq.weight"); err != nil {
+			return err
+		}
+	}
+
+	type majmin struct{ major, minor int64 }
+	limits := map[majmin][]string{}
+	devices := []majmin{}
+	for _, kr := range []struct {
+		key   string
+		rates []DeviceRate
+	}{
+		{"rbps", p.ThrottleReadBpsDevice},
+		{"wbps", p.ThrottleWriteBpsDevice},
+		{"riops", p.ThrottleReadIOPSDevice},
+		{"wiops", p.ThrottleWriteIOPSDevice},
+	} {
+		key := kr.key
+		for _, r := range kr.rates {
+			dev := majmin{r.Major, r.Minor}
+			if _, ok := limits[dev]; !ok {
+				devices = append(devices, dev)
+			}
+			value := "max"
+			if r.Rate > 0 {
+				value = strconv.FormatInt(r.Rate, 10)
+			}
+			limits[dev] = append(limits[dev], key+"="+value)
+		}
+	}
+	for _, dev := range devices {
+		value := fmt.Sprintf("%d:%d %s", dev.major, dev.minor, strings.Join(limits[dev], " "))
+		if err := writeCgroupFile(filepath.Join(cgroupDir, "io.max"), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFirstExisting writes a value to the first of the given entries present in a directory.
+func writeFirstExisting(dir, value string, entries ...string) error {
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry)
+		if _, err := os.Stat(path); err == nil {
+			return writeCgroupFile(path, value)
+		}
+	}
+	return fmt.Errorf("none of %s found in %s", strings.Join(entries, ", "), dir)
+}
+
+// writeCgroupFile writes a single value to a cgroup control file.
+func writeCgroupFile(path, value string) error {
+	if err := ioutil.WriteFile(path, []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write %q to %s: %v", value, path, err)
+	}
+	return nil
+}
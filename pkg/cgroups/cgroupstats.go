@@ -45,7 +45,15 @@ type CPUAcctUsage struct {
 	System int64
 }
 
+// CPUUsage has the cumulative CPU time consumed by a cgroup, in nanoseconds.
+type CPUUsage struct {
+	Total  int64
+	User   int64
+	System int64
+}
+
 // HugetlbUsage has parsed contents of huge pages usage in bytes.
+// MaxBytes is -1 if the peak usage is not available.
 type HugetlbUsage struct {
 	Size     string
 	Bytes    int64
@@ -53,6 +61,7 @@ type HugetlbUsage struct {
 }
 
 // MemoryUsage has parsed contents of memory usage in bytes.
+// MaxBytes is -1 if the peak usage is not available.
 type MemoryUsage struct {
 	Bytes    int64
 	MaxBytes int64
@@ -157,6 +166,10 @@ func GetBlkioThrottleBytes(cgroupPath string) (BlkioThrottleBytes, error) {
 	// 8:0 Total 7608309248
 	// Total 15039162880
 
+	if IsUnifiedDir(cgroupPath) {
+		return getIOStat(cgroupPath)
+	}
+
 	entry := path.Join(cgroupPath, cgroupEntry)
 	lines, err := readCgroupFileLines(entry)
 	if err != nil {
@@ -235,6 +248,10 @@ func GetCPUAcctStats(cgroupPath string) ([]CPUAcctUsage, error) {
 	// 0 3723082232186 2456599218
 	// 1 3748398003001 1149546796
 
+	if IsUnifiedDir(cgroupPath) {
+		return nil, fmt.Errorf("per-CPU accounting not available in cgroup v2")
+	}
+
 	lines, err := readCgroupFileLines(path.Join(cgroupPath, "cpuacct.usage_all"))
 
 	if err != nil {
@@ -272,6 +289,10 @@ func GetCPUSetMemoryMigrate(cgroupPath string) (bool, error) {
 	//
 	// 0
 
+	if IsUnifiedDir(cgroupPath) {
+		return false, fmt.Errorf("memory migration not available in cgroup v2")
+	}
+
 	number, err := readCgroupSingleNumber(path.Join(cgroupPath, "cpuset.memory_migrate"))
 
 	if err != nil {
@@ -290,8 +311,7 @@ func GetCPUSetMemoryMigrate(cgroupPath string) (bool, error) {
 // GetHugetlbUsage retrieves huge pages statistics for a given cgroup.
 func GetHugetlbUsage(cgroupPath string) ([]HugetlbUsage, error) {
 	const (
-		prefix         = "/hugetlb."
-		usageSuffix    = ".usage_in_bytes"
+		prefix         = "hugetlb."
 		maxUsageSuffix = ".max_usage_in_bytes"
 	)
 
@@ -299,6 +319,11 @@ func GetHugetlbUsage(cgroupPath string) ([]HugetlbUsage, error) {
 	//
 	// 124
 
+	usageSuffix := ".usage_in_bytes"
+	if IsUnifiedDir(cgroupPath) {
+		usageSuffix = ".current"
+	}
+
 	usageFiles, err := filepath.Glob(path.Join(cgroupPath, prefix+"*"+usageSuffix))
 	if err != nil {
 		return nil, err
@@ -307,14 +332,21 @@ func GetHugetlbUsage(cgroupPath string) ([]HugetlbUsage, error) {
 	result := make([]HugetlbUsage, 0, len(usageFiles))
 
 	for _, file := range usageFiles {
-		size := strings.TrimSuffix(strings.TrimPrefix(file, prefix), usageSuffix)
+		size := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), prefix), usageSuffix)
+		if strings.Contains(size, ".") {
+			// skip reservation accounting (hugetlb.<size>.rsvd.*)
+			continue
+		}
 		bytes, err := readCgroupSingleNumber(file)
 		if err != nil {
 			return nil, err
 		}
-		max, err := readCgroupSingleNumber(strings.TrimSuffix(file, usageSuffix) + maxUsageSuffix)
-		if err != nil {
-			return nil, err
+		max := int64(-1)
+		if usageSuffix != ".current" {
+			max, err = readCgroupSingleNumber(strings.TrimSuffix(file, usageSuffix) + maxUsageSuffix)
+			if err != nil {
+				return nil, err
+			}
 		}
 		result = append(result, HugetlbUsage{
 			Size:     size,
@@ -333,6 +365,10 @@ func GetMemoryUsage(cgroupPath string) (MemoryUsage, error) {
 	//
 	// 142
 
+	if IsUnifiedDir(cgroupPath) {
+		return getMemoryCurrent(cgroupPath)
+	}
+
 	usage, err := readCgroupSingleNumber(path.Join(cgroupPath, "memory.usage_in_bytes"))
 	if err != nil {
 		return MemoryUsage{}, err
@@ -368,6 +404,10 @@ func GetNumaStats(cgroupPath string) (NumaStat, error) {
 	// hierarchical_anon=46096 N0=12597 N1=18890 N2=283 N3=14326
 	// hierarchical_unevictable=20 N0=0 N1=0 N2=0 N3=20
 
+	if IsUnifiedDir(cgroupPath) {
+		return getV2NumaStats(cgroupPath)
+	}
+
 	entry := path.Join(cgroupPath, cgroupEntry)
 	lines, err := readCgroupFileLines(entry)
 	if err != nil {
@@ -439,6 +479,68 @@ func GetNumaStats(cgroupPath string) (NumaStat, error) {
 	return result, nil
 }
 
+// GetCPUUsage returns the cumulative CPU time consumed by a cgroup.
+func GetCPUUsage(cgroupPath string) (CPUUsage, error) {
+	if IsUnifiedDir(cgroupPath) {
+		return getV2CPUUsage(cgroupPath)
+	}
+
+	// Files look like this:
+	//
+	// 2456599218
+
+	result := CPUUsage{}
+	for file, ptr := range map[string]*int64{
+		"cpuacct.usage":      &result.Total,
+		"cpuacct.usage_user": &result.User,
+		"cpuacct.usage_sys":  &result.System,
+	} {
+		value, err := readCgroupSingleNumber(path.Join(cgroupPath, file))
+		if err != nil {
+			return CPUUsage{}, err
+		}
+		*ptr = value
+	}
+
+	return result, nil
+}
+
+// GetMemoryStat returns the parsed memory.stat entries of a cgroup.
+func GetMemoryStat(cgroupPath string) (map[string]int64, error) {
+
+	// File looks like this, with different keys in cgroup v1 and v2:
+	//
+	// cache 1236992
+	// rss 540672
+	// ...
+
+	entry := path.Join(cgroupPath, "memory.stat")
+	return readCgroupKeyValues(entry)
+}
+
+// readCgroupKeyValues reads a flat-keyed cgroup file of "key value" lines.
+func readCgroupKeyValues(filePath string) (map[string]int64, error) {
+	lines, err := readCgroupFileLines(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(lines))
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("error parsing file %s", filePath)
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing file %s: %v", filePath, err)
+		}
+		result[fields[0]] = value
+	}
+
+	return result, nil
+}
+
 // GetGlobalNumaStats returns the global (non-cgroup) NUMA statistics per node.
 func GetGlobalNumaStats() (map[int]GlobalNumaStats, error) {
 	const (
@@ -0,0 +1,166 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroups
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/intel/cri-resource-manager/pkg/utils"
+)
+
+// Mode describes the layout of the cgroup hierarchies of the system.
+type Mode int
+
+const (
+	// Legacy is a pure cgroup v1 setup, with one hierarchy per controller.
+	Legacy Mode = iota
+	// Hybrid is a cgroup v1 setup with an additional, controller-less v2 hierarchy.
+	Hybrid
+	// Unified is a pure cgroup v2 setup, with a single hierarchy for all controllers.
+	Unified
+)
+
+const (
+	// controllersFile is present in the directories of a cgroup v2 hierarchy.
+	controllersFile = "cgroup.controllers"
+	// v1TasksFile lists the threads of a cgroup v1 cgroup.
+	v1TasksFile = "tasks"
+	// v2ThreadsFile lists the threads of a cgroup v2 cgroup.
+	v2ThreadsFile = "cgroup.threads"
+	// v2ProcsFile lists the processes of a cgroup v2 cgroup.
+	v2ProcsFile = "cgroup.procs"
+)
+
+var (
+	// mode is the detected layout of the cgroup hierarchies.
+	mode Mode
+	// detectOnce takes care of detecting mode only once.
+	detectOnce sync.Once
+)
+
+// String returns the layout as a string.
+func (m Mode) String() string {
+	switch m {
+	case Legacy:
+		return "legacy (v1)"
+	case Hybrid:
+		return "hybrid (v1+v2)"
+	case Unified:
+		return "unified (v2)"
+	}
+	return fmt.Sprintf("<unknown cgroup mode %d>", int(m))
+}
+
+// GetMode returns the detected layout of the cgroup hierarchies.
+func GetMode() Mode {
+	detectOnce.Do(func() {
+		mode = detectMode()
+	})
+	return mode
+}
+
+// detectMode detects the layout of the cgroup hierarchies.
+func detectMode() Mode {
+	switch {
+	case IsUnifiedDir(Root):
+		return Unified
+	case IsUnifiedDir(V2path):
+		return Hybrid
+	default:
+		return Legacy
+	}
+}
+
+// IsUnifiedDir returns true if the given directory is in a cgroup v2 hierarchy.
+func IsUnifiedDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, controllersFile))
+	return err == nil
+}
+
+// UnifiedDir returns the root of the cgroup v2 hierarchy, or "" if there is none.
+func UnifiedDir() string {
+	switch GetMode() {
+	case Unified:
+		return Root
+	case Hybrid:
+		return V2path
+	}
+	return ""
+}
+
+// ControllerDir returns the root of the hierarchy of the given (v1) controller.
+func ControllerDir(controller string) string {
+	if GetMode() == Unified {
+		return Root
+	}
+	return filepath.Join(Root, controller)
+}
+
+// UnifiedControllers returns the controllers available in the cgroup v2 hierarchy.
+func UnifiedControllers() []string {
+	dir := UnifiedDir()
+	if dir == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, controllersFile))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
+// FindContainerDir finds the cgroup directory of a container for the given controller.
+func FindContainerDir(controller, cgroupParentDir, containerID string) string {
+	return utils.FindContainerCgroupDir(ControllerDir(controller), cgroupParentDir, containerID)
+}
+
+// GetTasks returns the IDs of all threads in the given cgroup directory.
+func GetTasks(dir string) ([]string, error) {
+	files := []string{v1TasksFile}
+	if IsUnifiedDir(dir) {
+		files = []string{v2ThreadsFile, v2ProcsFile}
+	}
+
+	for _, file := range files {
+		lines, err := readCgroupFileLines(filepath.Join(dir, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		tasks := make([]string, 0, len(lines))
+		for _, line := range lines {
+			tasks = append(tasks, strings.TrimSpace(line))
+		}
+		return tasks, nil
+	}
+
+	return nil, fmt.Errorf("no tasks file found in cgroup %s", dir)
+}
+
+// GetContainerTasks returns the IDs of all threads in the given container.
+func GetContainerTasks(cgroupParentDir, containerID string) ([]string, error) {
+	dir := FindContainerDir("cpuset", cgroupParentDir, containerID)
+	if dir == "" {
+		return nil, fmt.Errorf("failed to find cgroup directory for container %s", containerID)
+	}
+	return GetTasks(dir)
+}
@@ -0,0 +1,205 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroups
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/testutils"
+)
+
+// setupHierarchy sets up a fake cgroup filesystem and redetects the mode.
+func setupHierarchy(t *testing.T, files map[string]string) (string, func()) {
+	tmp, err := ioutil.TempDir("", "cgroups-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	testutils.CreateFiles(t, tmp, files)
+
+	oldRoot, oldV2path, oldMode := Root, V2path, mode
+	Root, V2path = tmp, filepath.Join(tmp, "unified")
+	mode = detectMode()
+
+	return tmp, func() {
+		Root, V2path, mode = oldRoot, oldV2path, oldMode
+		os.RemoveAll(tmp)
+	}
+}
+
+const ctrDir = "kubepods.slice/pod0/cri-containerd-ctr0.scope"
+
+func TestMode(t *testing.T) {
+	tcases := []struct {
+		name     string
+		files    map[string]string
+		mode     Mode
+		unified  string
+		cpusetOf string
+	}{
+		{
+			name:     "legacy",
+			files:    map[string]string{"cpuset/tasks": "1\n"},
+			mode:     Legacy,
+			cpusetOf: "cpuset",
+		},
+		{
+			name: "hybrid",
+			files: map[string]string{
+				"cpuset/tasks":               "1\n",
+				"unified/cgroup.controllers": "\n",
+			},
+			mode:     Hybrid,
+			unified:  "unified",
+			cpusetOf: "cpuset",
+		},
+		{
+			name:     "unified",
+			files:    map[string]string{"cgroup.controllers": "cpuset cpu io memory hugetlb pids\n"},
+			mode:     Unified,
+			unified:  ".",
+			cpusetOf: ".",
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			root, cleanup := setupHierarchy(t, tc.files)
+			defer cleanup()
+
+			if GetMode() != tc.mode {
+				t.Errorf("expected mode %s, got %s", tc.mode, GetMode())
+			}
+			unified := ""
+			if tc.unified != "" {
+				unified = filepath.Join(root, tc.unified)
+			}
+			if UnifiedDir() != unified {
+				t.Errorf("expected unified dir %q, got %q", unified, UnifiedDir())
+			}
+			if dir := ControllerDir("cpuset"); dir != filepath.Join(root, tc.cpusetOf) {
+				t.Errorf("expected cpuset dir %q, got %q", filepath.Join(root, tc.cpusetOf), dir)
+			}
+		})
+	}
+}
+
+func TestLegacyStats(t *testing.T) {
+	root, cleanup := setupHierarchy(t, map[string]string{
+		"cpuset/" + ctrDir + "/tasks":                                "10\n11\n",
+		"cpuacct/" + ctrDir + "/cpuacct.usage":                       "3000\n",
+		"cpuacct/" + ctrDir + "/cpuacct.usage_user":                  "2000\n",
+		"cpuacct/" + ctrDir + "/cpuacct.usage_sys":                   "1000\n",
+		"hugetlb/" + ctrDir + "/hugetlb.2MB.usage_in_bytes":          "4194304\n",
+		"hugetlb/" + ctrDir + "/hugetlb.2MB.max_usage_in_bytes":      "8388608\n",
+		"hugetlb/" + ctrDir + "/hugetlb.2MB.rsvd.usage_in_bytes":     "0\n",
+		"hugetlb/" + ctrDir + "/hugetlb.2MB.rsvd.max_usage_in_bytes": "0\n",
+	})
+	defer cleanup()
+
+	tasks, err := GetContainerTasks("kubepods.slice/pod0", "ctr0")
+	if err != nil || !reflect.DeepEqual(tasks, []string{"10", "11"}) {
+		t.Errorf("unexpected container tasks %v (error: %v)", tasks, err)
+	}
+
+	usage, err := GetCPUUsage(filepath.Join(root, "cpuacct", ctrDir))
+	if err != nil || usage != (CPUUsage{Total: 3000, User: 2000, System: 1000}) {
+		t.Errorf("unexpected CPU usage %v (error: %v)", usage, err)
+	}
+
+	hugetlb, err := GetHugetlbUsage(filepath.Join(root, "hugetlb", ctrDir))
+	expected := []HugetlbUsage{{Size: "2MB", Bytes: 4194304, MaxBytes: 8388608}}
+	if err != nil || !reflect.DeepEqual(hugetlb, expected) {
+		t.Errorf("unexpected hugetlb usage %v (error: %v)", hugetlb, err)
+	}
+}
+
+func TestUnifiedStats(t *testing.T) {
+	page := int64(os.Getpagesize())
+	root, cleanup := setupHierarchy(t, map[string]string{
+		"cgroup.controllers":                 "cpuset cpu io memory hugetlb pids\n",
+		ctrDir + "/cgroup.controllers":       "cpuset cpu io memory hugetlb pids\n",
+		ctrDir + "/cgroup.procs":             "10\n",
+		ctrDir + "/cgroup.threads":           "10\n11\n",
+		ctrDir + "/cpu.stat":                 "usage_usec 3\nuser_usec 2\nsystem_usec 1\n",
+		ctrDir + "/memory.current":           "4096\n",
+		ctrDir + "/hugetlb.1GB.current":      "0\n",
+		ctrDir + "/hugetlb.1GB.rsvd.current": "0\n",
+		ctrDir + "/io.stat": "8:16 rbytes=100 wbytes=200 rios=1 wios=2 dbytes=5 dios=1\n" +
+			"8:0 rbytes=1 wbytes=2 rios=1 wios=1 dbytes=0 dios=0\n",
+		ctrDir + "/memory.numa_stat": "anon 0 N0=0 N1=0\n" +
+			"file " + itoa(3*page) + " N0=" + itoa(page) + " N1=" + itoa(2*page) + "\n" +
+			"kernel_stack 0 N0=0 N1=0\n" +
+			"unevictable " + itoa(page) + " N0=" + itoa(page) + " N1=0\n",
+	})
+	defer cleanup()
+
+	dir := FindContainerDir("memory", "kubepods.slice/pod0", "ctr0")
+	if dir != filepath.Join(root, ctrDir) {
+		t.Fatalf("unexpected container cgroup directory %q", dir)
+	}
+
+	tasks, err := GetTasks(dir)
+	if err != nil || !reflect.DeepEqual(tasks, []string{"10", "11"}) {
+		t.Errorf("unexpected container tasks %v (error: %v)", tasks, err)
+	}
+
+	usage, err := GetCPUUsage(dir)
+	if err != nil || usage != (CPUUsage{Total: 3000, User: 2000, System: 1000}) {
+		t.Errorf("unexpected CPU usage %v (error: %v)", usage, err)
+	}
+
+	memory, err := GetMemoryUsage(dir)
+	if err != nil || memory != (MemoryUsage{Bytes: 4096, MaxBytes: -1}) {
+		t.Errorf("unexpected memory usage %v (error: %v)", memory, err)
+	}
+
+	hugetlb, err := GetHugetlbUsage(dir)
+	expected := []HugetlbUsage{{Size: "1GB", Bytes: 0, MaxBytes: -1}}
+	if err != nil || !reflect.DeepEqual(hugetlb, expected) {
+		t.Errorf("unexpected hugetlb usage %v (error: %v)", hugetlb, err)
+	}
+
+	io, err := GetBlkioThrottleBytes(dir)
+	if err != nil || io.TotalBytes != 303 || len(io.DeviceBytes) != 2 {
+		t.Fatalf("unexpected I/O stats %v (error: %v)", io, err)
+	}
+	ops := map[string]int64{"Read": 100, "Write": 200, "Discard": 5, "Total": 300}
+	if dev := io.DeviceBytes[0]; dev.Major != 8 || dev.Minor != 16 || !reflect.DeepEqual(dev.Operations, ops) {
+		t.Errorf("unexpected I/O stats for device 8:16: %v", *dev)
+	}
+
+	numa, err := GetNumaStats(dir)
+	if err != nil {
+		t.Fatalf("failed to get NUMA stats: %v", err)
+	}
+	if !reflect.DeepEqual(numa.File.Nodes, map[string]int64{"N0": 1, "N1": 2}) {
+		t.Errorf("unexpected file NUMA stats %v", numa.File)
+	}
+	if numa.Total.Total != 4 || !reflect.DeepEqual(numa.HierarchicalTotal, numa.Total) {
+		t.Errorf("unexpected total NUMA stats %v, %v", numa.Total, numa.HierarchicalTotal)
+	}
+
+	if _, err := GetCPUSetMemoryMigrate(dir); err == nil {
+		t.Errorf("expected memory migration stats to be unavailable in cgroup v2")
+	}
+}
+
+func itoa(i int64) string {
+	return strconv.FormatInt(i, 10)
+}
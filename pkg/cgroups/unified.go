@@ -0,0 +1,201 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//
+// Readers for cgroup v2 statistics. These provide the same data as their
+// cgroup v1 counterparts, converted to the same units where necessary.
+//
+
+// getIOStat returns amount of bytes transferred to/from the disk, from io.stat.
+func getIOStat(cgroupPath string) (BlkioThrottleBytes, error) {
+
+	// File looks like this:
+	//
+	// 8:16 rbytes=1459200 wbytes=314773504 rios=192 wios=353 dbytes=0 dios=0
+	// 8:0 rbytes=90430464 wbytes=299008000 rios=8950 wios=1252 dbytes=50331648 dios=3021
+
+	operations := map[string]string{
+		"rbytes": "Read",
+		"wbytes": "Write",
+		"dbytes": "Discard",
+	}
+
+	entry := path.Join(cgroupPath, "io.stat")
+	lines, err := readCgroupFileLines(entry)
+	if err != nil {
+		return BlkioThrottleBytes{}, err
+	}
+
+	result := BlkioThrottleBytes{DeviceBytes: make([]*BlkioDeviceBytes, 0, len(lines))}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 1 {
+			continue
+		}
+		var major, minor int
+		if _, err := fmt.Sscanf(fields[0], "%d:%d", &major, &minor); err != nil {
+			return BlkioThrottleBytes{}, fmt.Errorf("error parsing file %s: %v", entry, err)
+		}
+		dev := &BlkioDeviceBytes{
+			Major:      major,
+			Minor:      minor,
+			Operations: make(map[string]int64),
+		}
+		for _, field := range fields[1:] {
+			keyval := strings.SplitN(field, "=", 2)
+			if len(keyval) != 2 {
+				return BlkioThrottleBytes{}, fmt.Errorf("error parsing file %s", entry)
+			}
+			op, ok := operations[keyval[0]]
+			if !ok {
+				continue
+			}
+			bytes, err := strconv.ParseInt(keyval[1], 10, 64)
+			if err != nil {
+				return BlkioThrottleBytes{}, fmt.Errorf("error parsing file %s: %v", entry, err)
+			}
+			dev.Operations[op] = bytes
+		}
+		// like cgroup v1, count only reads and writes in the total
+		dev.Operations["Total"] = dev.Operations["Read"] + dev.Operations["Write"]
+		result.TotalBytes += dev.Operations["Total"]
+		result.DeviceBytes = append(result.DeviceBytes, dev)
+	}
+
+	return result, nil
+}
+
+// getV2CPUUsage returns the cumulative CPU time consumed by a cgroup, from cpu.stat.
+func getV2CPUUsage(cgroupPath string) (CPUUsage, error) {
+
+	// File looks like this:
+	//
+	// usage_usec 2313637
+	// user_usec 1521564
+	// system_usec 792073
+
+	entry := path.Join(cgroupPath, "cpu.stat")
+	stat, err := readCgroupKeyValues(entry)
+	if err != nil {
+		return CPUUsage{}, err
+	}
+
+	return CPUUsage{
+		Total:  stat["usage_usec"] * 1000,
+		User:   stat["user_usec"] * 1000,
+		System: stat["system_usec"] * 1000,
+	}, nil
+}
+
+// getMemoryCurrent retrieves cgroup memory usage, from memory.current and memory.peak.
+func getMemoryCurrent(cgroupPath string) (MemoryUsage, error) {
+	usage, err := readCgroupSingleNumber(path.Join(cgroupPath, "memory.current"))
+	if err != nil {
+		return MemoryUsage{}, err
+	}
+
+	// memory.peak is only available in recent kernels
+	maxUsage, err := readCgroupSingleNumber(path.Join(cgroupPath, "memory.peak"))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return MemoryUsage{}, err
+		}
+		maxUsage = -1
+	}
+
+	return MemoryUsage{
+		Bytes:    usage,
+		MaxBytes: maxUsage,
+	}, nil
+}
+
+// getV2NumaStats returns parsed cgroup NUMA statistics, from memory.numa_stat.
+func getV2NumaStats(cgroupPath string) (NumaStat, error) {
+
+	// File looks like this, with amounts in bytes:
+	//
+	// anon 749568 N0=749568 N1=0
+	// file 1658880 N0=1658880 N1=0
+	// kernel_stack 0 N0=0 N1=0
+	// ...
+	// unevictable 0 N0=0 N1=0
+	//
+	// We convert amounts to pages, like in cgroup v1. Since cgroup v2
+	// statistics are always hierarchical, we report the same values for
+	// the local and hierarchical statistics.
+
+	pageSize := int64(os.Getpagesize())
+
+	entry := path.Join(cgroupPath, "memory.numa_stat")
+	lines, err := readCgroupFileLines(entry)
+	if err != nil {
+		return NumaStat{}, err
+	}
+
+	result := NumaStat{}
+	total := NumaLine{Nodes: make(map[string]int64)}
+	for _, line := range lines {
+		split := strings.Fields(line)
+		if len(split) < 2 {
+			return NumaStat{}, fmt.Errorf("error parsing file %s", entry)
+		}
+
+		var stat *NumaLine
+		switch split[0] {
+		case "anon":
+			stat = &result.Anon
+		case "file":
+			stat = &result.File
+		case "unevictable":
+			stat = &result.Unevictable
+		default:
+			continue
+		}
+
+		stat.Nodes = make(map[string]int64)
+		for _, nodeEntry := range split[1:] {
+			nodeamount := strings.Split(nodeEntry, "=")
+			if len(nodeamount) != 2 {
+				continue
+			}
+			node, amount := nodeamount[0], nodeamount[1]
+			number, err := strconv.ParseInt(amount, 10, 64)
+			if err != nil {
+				return NumaStat{}, fmt.Errorf("error parsing file %s: %v", entry, err)
+			}
+			stat.Nodes[node] = number / pageSize
+			stat.Total += number / pageSize
+			total.Nodes[node] += number / pageSize
+			total.Total += number / pageSize
+		}
+	}
+
+	result.Total = total
+	result.HierarchicalTotal = result.Total
+	result.HierarchicalFile = result.File
+	result.HierarchicalAnon = result.Anon
+	result.HierarchicalUnevictable = result.Unevictable
+
+	return result, nil
+}
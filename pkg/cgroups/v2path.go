@@ -19,11 +19,15 @@ import (
 )
 
 var (
-	// V2path is the mount point for the cgroup V2 pseudofilesystem.
+	// Root is the mount point of the cgroup (v1 or v2) filesystem(s).
+	Root = "/sys/fs/cgroup"
+	// V2path is the mount point for the cgroup V2 pseudofilesystem in hybrid mode.
 	V2path string
 )
 
 func init() {
+	flag.StringVar(&Root, "cgroup-path", Root,
+		"Path to cgroup filesystem mountpoint")
 	flag.StringVar(&V2path, "cgroupv2-path", "/sys/fs/cgroup/unified",
 		"Path to cgroup-v2 mountpoint")
 }
@@ -15,7 +15,6 @@
 package cgroupstats
 
 import (
-	"flag"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -51,6 +50,15 @@ var (
 		}, nil,
 	)
 
+	memoryStatDesc = prometheus.NewDesc(
+		"cgroup_memory_stat",
+		"Memory statistics (memory.stat) for a given container and pod.",
+		[]string{
+			"cgroup_path",
+			"type",
+		}, nil,
+	)
+
 	memoryMigrateDesc = prometheus.NewDesc(
 		"cgroup_memory_migrate",
 		"Memory migrate status for a given container and pod.",
@@ -70,6 +78,15 @@ var (
 		}, nil,
 	)
 
+	cpuUsageDesc = prometheus.NewDesc(
+		"cgroup_cpu_usage",
+		"Total CPU time in nanoseconds consumed by a given container and pod.",
+		[]string{
+			"cgroup_path",
+			"type",
+		}, nil,
+	)
+
 	hugeTlbUsageDesc = prometheus.NewDesc(
 		"cgroup_hugetlb_usage",
 		"Hugepages usage for a given container and pod.",
@@ -93,8 +110,6 @@ var (
 )
 
 var (
-	// cgroupRoot is the mount point for the cgroup (v1) filesystem
-	cgroupRoot = "/sys/fs/cgroup"
 	// our logger instance
 	log = logger.NewLogger("cgroupstats")
 )
@@ -139,6 +154,32 @@ func updateCPUAcctUsageMetric(ch chan<- prometheus.Metric, path string, metric [
 	}
 }
 
+func updateCPUUsageMetric(ch chan<- prometheus.Metric, path string, metric cgroups.CPUUsage) {
+	for typ, value := range map[string]int64{
+		"Total":  metric.Total,
+		"User":   metric.User,
+		"System": metric.System,
+	} {
+		ch <- prometheus.MustNewConstMetric(
+			cpuUsageDesc,
+			prometheus.CounterValue,
+			float64(value),
+			path, typ,
+		)
+	}
+}
+
+func updateMemoryStatMetric(ch chan<- prometheus.Metric, path string, metric map[string]int64) {
+	for key, value := range metric {
+		ch <- prometheus.MustNewConstMetric(
+			memoryStatDesc,
+			prometheus.GaugeValue,
+			float64(value),
+			path, key,
+		)
+	}
+}
+
 func updateMemoryMigrateMetric(ch chan<- prometheus.Metric, path string, migrate bool) {
 	migrateValue := 0
 	if migrate {
@@ -159,6 +200,9 @@ func updateMemoryUsageMetric(ch chan<- prometheus.Metric, path string, metric cg
 		float64(metric.Bytes),
 		path, "Bytes",
 	)
+	if metric.MaxBytes < 0 {
+		return
+	}
 	ch <- prometheus.MustNewConstMetric(
 		memoryUsageDesc,
 		prometheus.GaugeValue,
@@ -245,6 +289,9 @@ func updateHugeTlbUsageMetric(ch chan<- prometheus.Metric, path string, metric [
 			float64(hugeTlbUsage.Bytes),
 			path, hugeTlbUsage.Size, "Bytes",
 		)
+		if hugeTlbUsage.MaxBytes < 0 {
+			continue
+		}
 		ch <- prometheus.MustNewConstMetric(
 			hugeTlbUsageDesc,
 			prometheus.GaugeValue,
@@ -273,7 +320,7 @@ func walkCgroups() []string {
 
 	containerDirs := []string{}
 
-	cpuset := filepath.Join(cgroupRoot, "cpuset")
+	cpuset := cgroups.ControllerDir("cpuset")
 	filepath.Walk(filepath.Join(cpuset, kubepodsDir),
 		func(path string, info os.FileInfo, err error) error {
 			if err != nil {
@@ -312,7 +359,7 @@ func walkCgroups() []string {
 }
 
 func cgroupPath(controller, path string) string {
-	return filepath.Join(cgroupRoot, controller, path)
+	return filepath.Join(cgroups.ControllerDir(controller), path)
 }
 
 // Collect implements prometheus.Collector interface
@@ -344,20 +391,20 @@ func (c collector) Collect(ch chan<- prometheus.Metric) {
 		},
 		func(path string) {
 			defer wg.Done()
-			migrate, err := cgroups.GetCPUSetMemoryMigrate(cgroupPath("cpuset", path))
+			stat, err := cgroups.GetMemoryStat(cgroupPath("memory", path))
 			if err == nil {
-				updateMemoryMigrateMetric(ch, path, migrate)
+				updateMemoryStatMetric(ch, path, stat)
 			} else {
-				log.Error("failed to collect memory migration stats for %s: %v", path, err)
+				log.Error("failed to collect memory stats for %s: %v", path, err)
 			}
 		},
 		func(path string) {
 			defer wg.Done()
-			cpuAcctUsage, err := cgroups.GetCPUAcctStats(cgroupPath("cpuacct", path))
+			usage, err := cgroups.GetCPUUsage(cgroupPath("cpuacct", path))
 			if err == nil {
-				updateCPUAcctUsageMetric(ch, path, cpuAcctUsage)
+				updateCPUUsageMetric(ch, path, usage)
 			} else {
-				log.Error("failed to collect CPU accounting stats for %s: %v", path, err)
+				log.Error("failed to collect CPU usage stats for %s: %v", path, err)
 			}
 		},
 		func(path string) {
@@ -380,6 +427,11 @@ func (c collector) Collect(ch chan<- prometheus.Metric) {
 		},
 	}
 
+	// Memory migration and per-CPU accounting are only available in cgroup v1.
+	if cgroups.GetMode() != cgroups.Unified {
+		collectors = append(collectors, v1Collectors(ch, &wg)...)
+	}
+
 	for _, path := range walkCgroups() {
 		wg.Add(len(collectors))
 		for _, fn := range collectors {
@@ -391,10 +443,31 @@ func (c collector) Collect(ch chan<- prometheus.Metric) {
 	wg.Wait()
 }
 
-func init() {
-	flag.StringVar(&cgroupRoot, "cgroup-path", cgroupRoot,
-		"Path to cgroup filesystem mountpoint")
+// v1Collectors returns the collectors for cgroup v1-only statistics.
+func v1Collectors(ch chan<- prometheus.Metric, wg *sync.WaitGroup) []func(string) {
+	return []func(string){
+		func(path string) {
+			defer wg.Done()
+			migrate, err := cgroups.GetCPUSetMemoryMigrate(cgroupPath("cpuset", path))
+			if err == nil {
+				updateMemoryMigrateMetric(ch, path, migrate)
+			} else {
+				log.Error("failed to collect memory migration stats for %s: %v", path, err)
+			}
+		},
+		func(path string) {
+			defer wg.Done()
+			cpuAcctUsage, err := cgroups.GetCPUAcctStats(cgroupPath("cpuacct", path))
+			if err == nil {
+				updateCPUAcctUsageMetric(ch, path, cpuAcctUsage)
+			} else {
+				log.Error("failed to collect CPU accounting stats for %s: %v", path, err)
+			}
+		},
+	}
+}
 
+func init() {
 	err := metrics.RegisterCollector("cgroupstats", NewCollector)
 	if err != nil {
 		log.Error("failed register cgroupstats collector: %v", err)
@@ -15,6 +15,7 @@
 package config
 
 import (
+	"fmt"
 	"github.com/ghodss/yaml"
 	"reflect"
 	"strings"
@@ -31,6 +32,11 @@ type GetConfigFn func() interface{}
 // NotifyFn is used to notify a module about configuration changes.
 type NotifyFn func(Event, Source) error
 
+// CheckFn is used to ask a module to check a candidate configuration without taking it
+// into use. The candidate is a pointer to a decoded copy of the module data, of the same
+// type as the registered data, or nil for modules with no data of their own.
+type CheckFn func(candidate interface{}, source Source) error
+
 // Event describes what triggered an invocation of a configuration notification callback.
 type Event string
 
@@ -63,7 +69,8 @@ type Module struct {
 	name        string             // name relative to parent, last part of path
 	children    map[string]*Module // modules nested under this module
 	getdefault  GetConfigFn        // getter for default configuration
-	notifiers   []NotifyFn         // update notification callbacks
+	notifiers   []*NotifyFn        // update notification callbacks
+	checkers    []*CheckFn         // configuration check callbacks
 	noValidate  bool               // omit data validation
 }
 
@@ -88,6 +95,22 @@ func SetConfig(cfg map[string]string) error {
 	return setconfig(data, ConfigExternal)
 }
 
+// CheckConfig validates configuration from an external source without taking it into use.
+// All problems found are returned as ValidationErrors.
+func CheckConfig(cfg map[string]string) error {
+	data, err := DataFromStringMap(cfg)
+	if err != nil {
+		return ValidationErrors{{Message: fmt.Sprintf("invalid configuration data: %v", err)}}
+	}
+	return checkconfig(data, ConfigExternal)
+}
+
+// RevertConfig rolls back the configuration to a snapshot previously taken using GetConfig.
+func RevertConfig(snapshot Data) {
+	log.Info("reverting configuration...")
+	revertconfig(snapshot, true)
+}
+
 // SetConfigFromFile updates the configuration from the given file.
 func SetConfigFromFile(path string) error {
 	data, err := DataFromFile(path)
@@ -107,6 +130,41 @@ func (m *Module) AddNotify(fn NotifyFn) error {
 	return WithNotify(fn).apply(m)
 }
 
+// AttachNotify attaches the given update notification callback to the module,
+// returning a function for detaching it.
+func (m *Module) AttachNotify(fn NotifyFn) func() {
+	notifier := &fn
+	m.notifiers = append(m.notifiers, notifier)
+	return func() {
+		for i, n := range m.notifiers {
+			if n == notifier {
+				m.notifiers = append(m.notifiers[:i:i], m.notifiers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// AddCheck attaches the given configuration check callback to the module.
+func (m *Module) AddCheck(fn CheckFn) error {
+	return WithCheck(fn).apply(m)
+}
+
+// AttachCheck attaches the given configuration check callback to the module,
+// returning a function for detaching it.
+func (m *Module) AttachCheck(fn CheckFn) func() {
+	checker := &fn
+	m.checkers = append(m.checkers, checker)
+	return func() {
+		for i, c := range m.checkers {
+			if c == checker {
+				m.checkers = append(m.checkers[:i:i], m.checkers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
 // Register registers a unit of configuration data to be handled by this package.
 func Register(path, description string, ptr interface{}, getfn GetConfigFn, opts ...Option) *Module {
 	m := lookup(path)
@@ -137,9 +195,11 @@ func setconfig(data Data, source Source) error {
 	}
 
 	log.Info("validating configuration...")
-	err = main.validate(data)
-	if err != nil {
-		return err
+	errs := ValidationErrors{}
+	main.validate(data, &errs)
+	if len(errs) > 0 {
+		errs.sort()
+		return errs
 	}
 
 	log.Info("applying configuration...")
@@ -160,11 +220,30 @@ func setconfig(data Data, source Source) error {
 	return nil
 }
 
+// checkconfig does a dry-run update of the configuration, collecting all errors found.
+func checkconfig(data Data, source Source) error {
+	log.Info("checking configuration...")
+	errs := ValidationErrors{}
+	main.validate(data, &errs)
+	copies := map[*Module]interface{}{}
+	main.tryconfigure(data, copies, &errs)
+	if len(errs) == 0 {
+		main.verify(copies, source, &errs)
+	}
+
+	if len(errs) > 0 {
+		errs.sort()
+		return errs
+	}
+
+	return nil
+}
+
 // revertconfig reverts configuration using a previously taken snapshot
 func revertconfig(snapshot Data, notify bool) {
 	err := main.configure(snapshot, true)
 	if err != nil {
-		log.Error("failed to rever configuration: %v", err)
+		log.Error("failed to revert configuration: %v", err)
 	}
 
 	if !notify {
@@ -275,6 +354,71 @@ func (m *Module) apply(cfg Data) error {
 	return nil
 }
 
+// tryconfigure decodes the configuration of the module and its submodules into
+// copies of their data, collecting all errors. The live data is left intact.
+func (m *Module) tryconfigure(data Data, copies map[*Module]interface{}, errs *ValidationErrors) {
+	modcfg, subcfg := data.split(m.hasChild)
+	if !m.isImplicit() {
+		ptr, err := m.tryapply(modcfg)
+		if err != nil {
+			// try to pinpoint the offending fields by applying them one by one
+			found := false
+			for field, value := range modcfg {
+				if _, err := m.tryapply(Data{field: value}); err != nil {
+					errs.add(m.path, field, "%v", err)
+					found = true
+				}
+			}
+			if !found {
+				errs.add(m.path, "", "%v", err)
+			}
+		} else {
+			copies[m] = ptr
+		}
+	}
+
+	for name, child := range m.children {
+		childcfg, err := subcfg.pick(name, true)
+		if err != nil {
+			errs.add(child.path, "", "failed to pick configuration: %v", err)
+			continue
+		}
+		child.tryconfigure(childcfg, copies, errs)
+	}
+}
+
+// tryapply applies the given module-local configuration to a copy of the module data.
+func (m *Module) tryapply(cfg Data) (interface{}, error) {
+	// start from a deep copy of the live data, or the defaults, just like apply
+	base := m.ptr
+	if len(cfg) == 0 {
+		base = m.getdefault()
+	}
+	current, err := DataFromObject(base)
+	if err != nil {
+		return nil, configError("module %s: failed to copy configuration: %v", m.path, err)
+	}
+
+	ptr := reflect.New(reflect.TypeOf(m.ptr).Elem()).Interface()
+	if err := decode(current, ptr); err != nil {
+		return nil, configError("module %s: failed to copy configuration: %v", m.path, err)
+	}
+	if err := decode(cfg, ptr); err != nil {
+		return nil, err
+	}
+
+	return ptr, nil
+}
+
+// decode decodes the given module-local configuration into the given module data.
+func decode(cfg Data, ptr interface{}) error {
+	raw, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(raw, ptr)
+}
+
 // notify notifies this module and its children about a configuration change.
 func (m *Module) notify(event Event, source Source) error {
 	for _, child := range m.children {
@@ -284,7 +428,7 @@ func (m *Module) notify(event Event, source Source) error {
 	}
 
 	for _, fn := range m.notifiers {
-		if err := fn(event, source); err != nil {
+		if err := (*fn)(event, source); err != nil {
 			return configError("module %s rejected %v configuration: %v", m.path, event, err)
 		}
 	}
@@ -292,6 +436,20 @@ func (m *Module) notify(event Event, source Source) error {
 	return nil
 }
 
+// verify asks this module and its children to check their decoded copies of the
+// configuration, collecting all errors.
+func (m *Module) verify(copies map[*Module]interface{}, source Source, errs *ValidationErrors) {
+	for _, child := range m.children {
+		child.verify(copies, source, errs)
+	}
+
+	for _, fn := range m.checkers {
+		if err := (*fn)(copies[m], source); err != nil {
+			errs.merge(m.path, err)
+		}
+	}
+}
+
 // check performs basic sanity checks on the module.
 func (m *Module) check() {
 	ptrType := reflect.TypeOf(m.ptr)
@@ -315,26 +473,13 @@ func (m *Module) check() {
 }
 
 // validate checks that each field of data refers to either module data or a submodule.
-func (m *Module) validate(data Data) error {
+func (m *Module) validate(data Data, errs *ValidationErrors) {
 	log.Debug("validating data for module %s...", m.path)
 
 	modcfg, subcfg := data.split(m.hasChild)
 	fields := map[string]struct{}{}
 
-	if m.isImplicit() {
-		if len(modcfg) > 0 {
-			names := []string{}
-			for name := range modcfg {
-				names = append(names, name)
-			}
-			if !m.noValidate {
-				return configError("implicit module %s: given configuration data %s",
-					m.path, strings.Join(names, ","))
-			}
-			log.Error("implicit module %s: given configuration date %s",
-				m.path, strings.Join(names, ","))
-		}
-	} else {
+	if !m.isImplicit() {
 		ptr := reflect.ValueOf(m.ptr).Elem()
 		for i := 0; i < ptr.NumField(); i++ {
 			field := ptr.Type().Field(i)
@@ -344,11 +489,17 @@ func (m *Module) validate(data Data) error {
 	}
 
 	for field := range modcfg {
-		if _, ok := fields[field]; !ok {
-			if !m.noValidate {
-				return configError("module %s: given unknown configuration data %s", m.path, field)
-			}
-			log.Error("module %s: given unknown configuration data %s", m.path, field)
+		if _, ok := fields[field]; ok {
+			continue
+		}
+		msg := "unknown configuration data"
+		if m.isImplicit() {
+			msg = "configuration data given for implicit module"
+		}
+		if !m.noValidate {
+			errs.add(m.path, field, msg)
+		} else {
+			log.Error("module %s: %s %s", m.path, msg, field)
 		}
 	}
 
@@ -356,25 +507,15 @@ func (m *Module) validate(data Data) error {
 	for name, child := range m.children {
 		childcfg, err := subcfg.pick(name, true)
 		if err != nil {
-			return configError("module %s: failed to pick configuration for child %s: %v",
-				m.path, child.path, err)
-		}
-		err = child.validate(childcfg)
-		if err != nil {
-			return err
+			errs.add(child.path, "", "failed to pick configuration: %v", err)
+			continue
 		}
+		child.validate(childcfg, errs)
 	}
 
-	if len(subcfg) > 0 {
-		unconsumed := []string{}
-		for name := range subcfg {
-			unconsumed = append(unconsumed, name)
-		}
-		return configError("module %s: no child corresponding to data %s",
-			m.path, strings.Join(unconsumed, ","))
+	for name := range subcfg {
+		errs.add(m.path, name, "no child corresponding to data")
 	}
-
-	return nil
 }
 
 // fieldName returns the name used to refer to the struct field in JSON/YAML encoding.
@@ -0,0 +1,206 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+)
+
+type checkOptions struct {
+	Count int    `json:"count"`
+	Name  string `json:"name"`
+}
+
+func TestCheckConfig(t *testing.T) {
+	opt := &checkOptions{}
+	defaults := func() interface{} { return &checkOptions{Count: 1, Name: "default"} }
+	updates := 0
+	notify := func(event Event, source Source) error {
+		if event == UpdateEvent {
+			updates++
+		}
+		return nil
+	}
+	check := func(candidate interface{}, source Source) error {
+		if candidate.(*checkOptions).Count > 10 {
+			return &ValidationError{Field: "count", Message: "too large"}
+		}
+		return nil
+	}
+
+	Register("check-test", "CheckConfig test module.", opt, defaults,
+		WithNotify(notify), WithCheck(check))
+	if err := SetConfig(map[string]string{}); err != nil {
+		t.Fatalf("failed to set default configuration: %v", err)
+	}
+	updates = 0
+
+	tcs := []struct {
+		name   string
+		config string
+		errors []ValidationError
+	}{
+		{
+			name:   "valid configuration",
+			config: "count: 5\nname: test",
+		},
+		{
+			name:   "unknown field",
+			config: "count: 5\nbogus: 1",
+			errors: []ValidationError{{Module: "check-test", Field: "bogus"}},
+		},
+		{
+			name:   "invalid field",
+			config: "count: five\nname: test",
+			errors: []ValidationError{{Module: "check-test", Field: "count"}},
+		},
+		{
+			name:   "rejected by notifier",
+			config: "count: 20",
+			errors: []ValidationError{{Module: "check-test", Field: "count", Message: "too large"}},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckConfig(map[string]string{"check-test": tc.config})
+
+			if opt.Count != 1 || opt.Name != "default" {
+				t.Errorf("configuration changed by check: %+v", *opt)
+			}
+			if updates != 0 {
+				t.Errorf("configuration update notification sent by check")
+			}
+
+			if len(tc.errors) == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("expected ValidationErrors, got %T (%v)", err, err)
+			}
+			if len(errs) != len(tc.errors) {
+				t.Fatalf("expected %d errors, got %d (%v)", len(tc.errors), len(errs), errs)
+			}
+			for i, expected := range tc.errors {
+				if errs[i].Module != expected.Module || errs[i].Field != expected.Field {
+					t.Errorf("expected error for %s/%s, got %s/%s",
+						expected.Module, expected.Field, errs[i].Module, errs[i].Field)
+				}
+				if expected.Message != "" && errs[i].Message != expected.Message {
+					t.Errorf("expected error message %q, got %q", expected.Message, errs[i].Message)
+				}
+			}
+		})
+	}
+}
+
+type copyOptions struct {
+	Labels map[string]string `json:"labels"`
+}
+
+func TestCheckConfigLeavesDataIntact(t *testing.T) {
+	opt := &copyOptions{}
+	defaults := func() interface{} { return &copyOptions{Labels: map[string]string{"x": "y"}} }
+	checked := map[string]string{}
+	seen := map[string]string{}
+	check := func(candidate interface{}, source Source) error {
+		checked = candidate.(*copyOptions).Labels
+		seen = opt.Labels
+		return nil
+	}
+
+	Register("copy-test", "CheckConfig copy test module.", opt, defaults, WithCheck(check))
+	if err := SetConfig(map[string]string{}); err != nil {
+		t.Fatalf("failed to set default configuration: %v", err)
+	}
+	live := opt.Labels
+
+	if err := CheckConfig(map[string]string{"copy-test": "labels:\n  a: b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if checked["a"] != "b" {
+		t.Errorf("expected checked configuration to have label a=b, got %v", checked)
+	}
+	if len(seen) != 1 || seen["x"] != "y" {
+		t.Errorf("live configuration changed during check: %v", seen)
+	}
+	if len(live) != 1 || len(opt.Labels) != 1 || opt.Labels["x"] != "y" {
+		t.Errorf("configuration changed by check: %v (%v)", opt.Labels, live)
+	}
+}
+
+func TestRevertConfig(t *testing.T) {
+	opt := &checkOptions{}
+	defaults := func() interface{} { return &checkOptions{Count: 1, Name: "default"} }
+	events := []Event{}
+	notify := func(event Event, source Source) error {
+		events = append(events, event)
+		return nil
+	}
+
+	Register("revert-test", "RevertConfig test module.", opt, defaults, WithNotify(notify))
+	if err := SetConfig(map[string]string{"revert-test": "count: 2\nname: old"}); err != nil {
+		t.Fatalf("failed to set initial configuration: %v", err)
+	}
+
+	snapshot, err := GetConfig()
+	if err != nil {
+		t.Fatalf("failed to take configuration snapshot: %v", err)
+	}
+	if err := SetConfig(map[string]string{"revert-test": "count: 3\nname: new"}); err != nil {
+		t.Fatalf("failed to update configuration: %v", err)
+	}
+	if opt.Count != 3 || opt.Name != "new" {
+		t.Fatalf("configuration not updated: %+v", *opt)
+	}
+
+	events = []Event{}
+	RevertConfig(snapshot)
+
+	if opt.Count != 2 || opt.Name != "old" {
+		t.Errorf("configuration not reverted: %+v", *opt)
+	}
+	if len(events) != 1 || events[0] != RevertEvent {
+		t.Errorf("expected a single %s notification, got %v", RevertEvent, events)
+	}
+}
+
+func TestAttachNotify(t *testing.T) {
+	opt := &checkOptions{}
+	defaults := func() interface{} { return &checkOptions{Count: 1, Name: "default"} }
+	m := Register("attach-test", "AttachNotify test module.", opt, defaults)
+
+	first, second := 0, 0
+	detach := m.AttachNotify(func(Event, Source) error { first++; return nil })
+	m.AttachNotify(func(Event, Source) error { second++; return nil })
+
+	if err := SetConfig(map[string]string{"attach-test": "count: 2"}); err != nil {
+		t.Fatalf("failed to set configuration: %v", err)
+	}
+	detach()
+	if err := SetConfig(map[string]string{"attach-test": "count: 3"}); err != nil {
+		t.Fatalf("failed to set configuration: %v", err)
+	}
+
+	if first != 1 || second != 2 {
+		t.Errorf("expected 1 notification before detaching and 2 without, got %d and %d",
+			first, second)
+	}
+}
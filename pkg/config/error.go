@@ -16,9 +16,78 @@ package config
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // configError creates a formatted configuration-specific error.
 func configError(format string, args ...interface{}) error {
 	return fmt.Errorf("config error: "+format, args...)
 }
+
+// ValidationError describes a problem found in the configuration of a module.
+type ValidationError struct {
+	Module  string // module the problem was found in
+	Field   string // offending field of the module, if known
+	Message string // description of the problem
+}
+
+// ValidationErrors is the collection of problems found in a configuration.
+type ValidationErrors []*ValidationError
+
+// Error returns the string representation of a validation error.
+func (e *ValidationError) Error() string {
+	switch {
+	case e.Module == "":
+		return e.Message
+	case e.Field == "":
+		return "module " + e.Module + ": " + e.Message
+	default:
+		return "module " + e.Module + ", field " + e.Field + ": " + e.Message
+	}
+}
+
+// Error returns the string representation of validation errors.
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Error())
+	}
+	return "config error: " + strings.Join(msgs, "; ")
+}
+
+// add records a new validation error.
+func (errs *ValidationErrors) add(module, field, format string, args ...interface{}) {
+	*errs = append(*errs, &ValidationError{
+		Module:  module,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// merge records the errors returned by a module, defaulting to the given module.
+func (errs *ValidationErrors) merge(module string, err error) {
+	switch verr := err.(type) {
+	case *ValidationError:
+		if verr.Module == "" {
+			verr.Module = module
+		}
+		*errs = append(*errs, verr)
+	case ValidationErrors:
+		for _, e := range verr {
+			errs.merge(module, e)
+		}
+	default:
+		errs.add(module, "", "%v", err)
+	}
+}
+
+// sort sorts validation errors by module and field.
+func (errs ValidationErrors) sort() {
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Module != errs[j].Module {
+			return errs[i].Module < errs[j].Module
+		}
+		return errs[i].Field < errs[j].Field
+	})
+}
@@ -20,7 +20,7 @@ func WithNotify(fn NotifyFn) Option {
 		switch o.(type) {
 		case *Module:
 			m := o.(*Module)
-			m.notifiers = append(m.notifiers, fn)
+			m.notifiers = append(m.notifiers, &fn)
 		default:
 			return configError("WithNotify is not valid for object of type %T", o)
 		}
@@ -28,6 +28,20 @@ func WithNotify(fn NotifyFn) Option {
 	})
 }
 
+// WithCheck specifies a function to be called to check configuration without taking it into use.
+func WithCheck(fn CheckFn) Option {
+	return newFuncOption(func(o interface{}) error {
+		switch o.(type) {
+		case *Module:
+			m := o.(*Module)
+			m.checkers = append(m.checkers, &fn)
+		default:
+			return configError("WithCheck is not valid for object of type %T", o)
+		}
+		return nil
+	})
+}
+
 // WithoutDataValidation specifies that data passed to this module should not be validated.
 func WithoutDataValidation() Option {
 	return newFuncOption(func(o interface{}) error {
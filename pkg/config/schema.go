@@ -0,0 +1,240 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+const (
+	// SchemaVersion is the JSON Schema draft we produce schemas for.
+	SchemaVersion = "http://json-schema.org/draft-07/schema#"
+)
+
+// Schema is a (subset of a) JSON Schema describing configuration data.
+type Schema struct {
+	Version              string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+}
+
+// SchemaDescriber is implemented by configuration data types which describe their own schema.
+// Types with custom JSON marshalling or with a fixed set of valid values should implement it.
+type SchemaDescriber interface {
+	// ConfigSchema returns the schema of the data type.
+	ConfigSchema() *Schema
+}
+
+var (
+	marshalerType     = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// GetSchema returns the JSON Schema for the full configuration.
+func GetSchema() (*Schema, error) {
+	s, err := main.schema()
+	if err != nil {
+		return nil, err
+	}
+
+	s.Version = SchemaVersion
+	s.Title = "cri-resmgr configuration"
+
+	return s, nil
+}
+
+// schema returns the schema for the given module and its submodules.
+func (m *Module) schema() (*Schema, error) {
+	s := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	if !m.isImplicit() {
+		s = typeSchema(reflect.TypeOf(m.ptr), map[reflect.Type]bool{})
+		if s.Properties == nil {
+			s.Properties = make(map[string]*Schema)
+		}
+		s.Description = m.description
+		if m.help != "" {
+			s.Description += "\n\n" + m.help
+		}
+
+		defaults, err := DataFromObject(m.getdefault())
+		if err != nil {
+			return nil, configError("module %s: failed to get defaults: %v", m.path, err)
+		}
+		s.setDefault(map[string]interface{}(defaults))
+	}
+
+	for name, child := range m.children {
+		cs, err := child.schema()
+		if err != nil {
+			return nil, err
+		}
+		s.Properties[name] = cs
+	}
+
+	if !m.noValidate {
+		s.AdditionalProperties = false
+	}
+
+	return s, nil
+}
+
+// typeSchema returns the schema for the given data type.
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Ptr {
+		return typeSchema(t.Elem(), visiting)
+	}
+
+	if d, ok := reflect.New(t).Interface().(SchemaDescriber); ok {
+		return d.ConfigSchema()
+	}
+
+	switch {
+	case t.Implements(marshalerType) || reflect.PtrTo(t).Implements(marshalerType):
+		// type gets inferred from defaults, if we have any
+		return &Schema{}
+	case t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType):
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem(), visiting)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: typeSchema(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		structSchema(s, t, visiting)
+		return s
+	}
+
+	return &Schema{}
+}
+
+// structSchema collects the properties of the given struct type into the schema.
+func structSchema(s *Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue
+		}
+		if f.Tag.Get("json") == "-" {
+			continue
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				structSchema(s, ft, visiting)
+			}
+			continue
+		}
+		s.Properties[fieldName(f)] = typeSchema(f.Type, visiting)
+	}
+}
+
+// setDefault sets the given default value for the schema and its properties.
+func (s *Schema) setDefault(value interface{}) {
+	if value == nil {
+		return
+	}
+
+	if obj, ok := value.(map[string]interface{}); ok && len(s.Properties) > 0 {
+		for name, v := range obj {
+			if ps, ok := s.Properties[name]; ok {
+				ps.setDefault(v)
+			}
+		}
+		return
+	}
+
+	if s.Type == "" && len(s.Enum) == 0 && len(s.OneOf) == 0 {
+		s.Type = jsonType(value)
+	}
+	s.Default = value
+}
+
+// jsonType returns the JSON Schema type corresponding to the given unmarshalled value.
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "boolean"
+	case float64, int, int64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// EnumSchema returns a schema for a data type with the given valid values.
+func EnumSchema(description string, values ...interface{}) *Schema {
+	s := &Schema{Description: description, Enum: values}
+
+	kind := ""
+	for _, v := range values {
+		t := jsonType(v)
+		if kind != "" && t != kind {
+			return s
+		}
+		kind = t
+	}
+	s.Type = kind
+
+	return s
+}
+
+// String returns the schema as indented JSON.
+func (s *Schema) String() string {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "<failed to marshal schema: " + err.Error() + ">"
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,108 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+type schemaColor string
+
+func (c *schemaColor) ConfigSchema() *Schema {
+	return EnumSchema("A color.", "red", "green", "blue")
+}
+
+type schemaLimit string
+
+func (l *schemaLimit) ConfigSchema() *Schema {
+	return &Schema{OneOf: []*Schema{{Type: "string"}, {Type: "number"}}}
+}
+
+type schemaOptions struct {
+	Enabled bool
+	Count   int         `json:"count"`
+	Ratio   float64     `json:",omitempty"`
+	Names   []string    `json:"names,omitempty"`
+	Color   schemaColor `json:"color"`
+	Colors  map[string]*schemaColor
+	Limit   schemaLimit   `json:"limit"`
+	Nested  *schemaNested `json:"nested,omitempty"`
+	Ignored string        `json:"-"`
+	private int
+}
+
+type schemaNested struct {
+	Path string
+}
+
+func TestSchema(t *testing.T) {
+	opt := &schemaOptions{}
+	defaults := func() interface{} {
+		return &schemaOptions{Enabled: true, Count: 3, Color: "green", Limit: "2"}
+	}
+	Register("schema-test", "Schema test module.", opt, defaults)
+	Register("schema-test.child", "Schema test child module.", &schemaNested{},
+		func() interface{} { return &schemaNested{Path: "/tmp"} })
+
+	schema, err := GetSchema()
+	if err != nil {
+		t.Fatalf("failed to get schema: %v", err)
+	}
+	if schema.Version != SchemaVersion {
+		t.Errorf("expected schema version %q, got %q", SchemaVersion, schema.Version)
+	}
+
+	module, ok := schema.Properties["schema-test"]
+	if !ok {
+		t.Fatalf("no schema for module schema-test")
+	}
+	if module.Description != "Schema test module." {
+		t.Errorf("unexpected module description %q", module.Description)
+	}
+	if module.AdditionalProperties != false {
+		t.Errorf("expected additional properties to be disallowed")
+	}
+
+	expected := map[string]*Schema{
+		"Enabled": {Type: "boolean", Default: true},
+		"count":   {Type: "integer", Default: float64(3)},
+		"Ratio":   {Type: "number"},
+		"names":   {Type: "array", Items: &Schema{Type: "string"}},
+		"color": {Type: "string", Description: "A color.",
+			Enum: []interface{}{"red", "green", "blue"}, Default: "green"},
+		"Colors": {Type: "object", AdditionalProperties: &Schema{Type: "string",
+			Description: "A color.", Enum: []interface{}{"red", "green", "blue"}}},
+		"limit": {OneOf: []*Schema{{Type: "string"}, {Type: "number"}}, Default: "2"},
+		"nested": {Type: "object", Properties: map[string]*Schema{
+			"Path": {Type: "string"},
+		}},
+		"child": {Type: "object", Description: "Schema test child module.",
+			Properties: map[string]*Schema{
+				"Path": {Type: "string", Default: "/tmp"},
+			},
+			AdditionalProperties: false,
+		},
+	}
+
+	if len(module.Properties) != len(expected) {
+		t.Errorf("expected %d properties, got %d", len(expected), len(module.Properties))
+	}
+	for name, exp := range expected {
+		if !reflect.DeepEqual(module.Properties[name], exp) {
+			t.Errorf("property %s: expected schema %s, got %s", name, exp, module.Properties[name])
+		}
+	}
+}
@@ -36,6 +36,9 @@ const (
 	AllocIdleNodes
 	// AllocIdleCores requests allocation of full idle cores (all threads in core).
 	AllocIdleCores
+	// AllocIdleCaches requests allocation of full idle cache domains (L3 domains and
+	// L2 clusters) and keeping allocations within as few L3 domains as possible.
+	AllocIdleCaches
 	// AllocDefault is the default allocation preferences.
 	AllocDefault = AllocIdlePackages | AllocIdleCores
 
@@ -63,9 +66,10 @@ type sysfsSingleton struct {
 	sys       *sysfs.System // wrapped sysfs.System instance
 	err       error         // error during recovery
 	cpusets   struct {      // cached cpusets per
-		pkg  map[sysfs.ID]cpuset.CPUSet // package,
-		node map[sysfs.ID]cpuset.CPUSet // node, and
-		core map[sysfs.ID]cpuset.CPUSet // CPU core
+		pkg   map[sysfs.ID]cpuset.CPUSet // package,
+		node  map[sysfs.ID]cpuset.CPUSet // node,
+		core  map[sysfs.ID]cpuset.CPUSet // CPU core, and
+		cache map[uint8][]cpuset.CPUSet  // cache domain (by cache level)
 	}
 }
 
@@ -88,15 +92,26 @@ var log = logger.NewLogger(logSource)
 // Get/discover sysfs.System.
 func (s *sysfsSingleton) get() (*sysfs.System, error) {
 	s.Do(func() {
-		s.sys, s.err = sysfs.DiscoverSystem(sysfs.DiscoverCPUTopology)
+		s.sys, s.err = sysfs.DiscoverSystem(sysfs.DiscoverCPUTopology | sysfs.DiscoverCache)
 		s.cpusets.pkg = make(map[sysfs.ID]cpuset.CPUSet)
 		s.cpusets.node = make(map[sysfs.ID]cpuset.CPUSet)
 		s.cpusets.core = make(map[sysfs.ID]cpuset.CPUSet)
+		s.cpusets.cache = make(map[uint8][]cpuset.CPUSet)
 	})
 
 	return s.sys, s.err
 }
 
+// SetSystem overrides the discovered system used for allocation, for instance for simulation.
+func SetSystem(sys *sysfs.System) {
+	system.Do(func() {})
+	system.sys, system.err = sys, nil
+	system.cpusets.pkg = make(map[sysfs.ID]cpuset.CPUSet)
+	system.cpusets.node = make(map[sysfs.ID]cpuset.CPUSet)
+	system.cpusets.core = make(map[sysfs.ID]cpuset.CPUSet)
+	system.cpusets.cache = make(map[uint8][]cpuset.CPUSet)
+}
+
 // PackageCPUSet gets the CPUSet for the given package.
 func (s *sysfsSingleton) PackageCPUSet(id sysfs.ID) cpuset.CPUSet {
 	if cset, ok := s.cpusets.pkg[id]; ok {
@@ -135,6 +150,21 @@ func (s *sysfsSingleton) CoreCPUSet(id sysfs.ID) cpuset.CPUSet {
 	return cset
 }
 
+// CacheCPUSets gets the CPUSets for all cache domains of the given level.
+func (s *sysfsSingleton) CacheCPUSets(level uint8) []cpuset.CPUSet {
+	if csets, ok := s.cpusets.cache[level]; ok {
+		return csets
+	}
+
+	csets := []cpuset.CPUSet{}
+	for _, c := range s.sys.CacheDomains(level) {
+		csets = append(csets, c.CPUSet())
+	}
+	s.cpusets.cache[level] = csets
+
+	return csets
+}
+
 // Pick packages, nodes or CPUs by filtering according to a function.
 func (s *sysfsSingleton) pick(idSlice []sysfs.ID, f IDFilter) []sysfs.ID {
 	ids := make([]sysfs.ID, len(idSlice))
@@ -214,6 +244,104 @@ func (a *CPUAllocator) takeIdlePackages() {
 	}
 }
 
+// Allocate full idle cache domains, L3 domains first, then L2 clusters.
+func (a *CPUAllocator) takeIdleCaches() {
+	a.Debug("* takeIdleCaches()...")
+
+	offline := a.sys.Offlined()
+
+	for _, level := range []uint8{3, 2} {
+		// pick idle cache domains, ignoring ones not larger than a single core
+		domains := []cpuset.CPUSet{}
+		for _, cset := range system.CacheCPUSets(level) {
+			cset = cset.Difference(offline)
+			if cset.IsEmpty() || !cset.Intersection(a.from).Equals(cset) {
+				continue
+			}
+			if cset.Size() <= system.CoreCPUSet(sysfs.ID(cset.ToSlice()[0])).Size() {
+				continue
+			}
+			domains = append(domains, cset)
+		}
+
+		// sorted by L3 domain preference, then by id
+		rank := a.rankCacheDomains()
+		sort.SliceStable(domains,
+			func(i, j int) bool {
+				iFirst, jFirst := domains[i].ToSlice()[0], domains[j].ToSlice()[0]
+				if iRank, jRank := rank[sysfs.ID(iFirst)], rank[sysfs.ID(jFirst)]; iRank != jRank {
+					return iRank < jRank
+				}
+				return iFirst < jFirst
+			})
+
+		a.Debug(" => idle L%d cache domains sorted by preference: %v", level, domains)
+
+		// take as many idle cache domains as we need/can
+		for _, cset := range domains {
+			a.Debug(" => considering L%d cache domain #%s...", level, cset)
+			if a.cnt >= cset.Size() {
+				a.Debug(" => taking L%d cache domain #%s...", level, cset)
+				a.result = a.result.Union(cset)
+				a.from = a.from.Difference(cset)
+				a.cnt -= cset.Size()
+
+				if a.cnt == 0 {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Rank CPUs by the preference of their L3 cache domain for allocation. Domains
+// already used by the allocation come first, then the ones that could fit the
+// rest of the allocation (best fit first), then the rest (most free CPUs first).
+func (a *CPUAllocator) rankCacheDomains() map[sysfs.ID]int {
+	type domain struct {
+		cset cpuset.CPUSet // CPUs in domain
+		colo int           // CPUs already in a.result
+		free int           // CPUs still free in a.from
+	}
+
+	offline := a.sys.Offlined()
+
+	domains := []*domain{}
+	for _, cset := range system.CacheCPUSets(3) {
+		domains = append(domains, &domain{
+			cset: cset,
+			colo: cset.Intersection(a.result).Size(),
+			free: cset.Intersection(a.from).Difference(offline).Size(),
+		})
+	}
+
+	sort.SliceStable(domains,
+		func(i, j int) bool {
+			iDom, jDom := domains[i], domains[j]
+			iFits, jFits := iDom.free >= a.cnt, jDom.free >= a.cnt
+
+			switch {
+			case iDom.colo != jDom.colo:
+				return iDom.colo > jDom.colo
+			case iFits != jFits:
+				return iFits
+			case iFits:
+				return iDom.free < jDom.free
+			default:
+				return iDom.free > jDom.free
+			}
+		})
+
+	rank := make(map[sysfs.ID]int)
+	for idx, d := range domains {
+		for _, id := range d.cset.ToSlice() {
+			rank[sysfs.ID(id)] = idx
+		}
+	}
+
+	return rank
+}
+
 // Allocate full idle CPU cores.
 func (a *CPUAllocator) takeIdleCores() {
 	a.Debug("* takeIdleCores()...")
@@ -227,9 +355,16 @@ func (a *CPUAllocator) takeIdleCores() {
 			return cset.Intersection(a.from).Equals(cset) && cset.ToSlice()[0] == int(id)
 		})
 
-	// sorted by id
+	// sorted by L3 domain preference if we're cache-aware, then by id
+	var rank map[sysfs.ID]int
+	if (a.flags & AllocIdleCaches) != 0 {
+		rank = a.rankCacheDomains()
+	}
 	sort.Slice(cores,
 		func(i, j int) bool {
+			if iRank, jRank := rank[cores[i]], rank[cores[j]]; iRank != jRank {
+				return iRank < jRank
+			}
 			return cores[i] < cores[j]
 		})
 
@@ -264,10 +399,16 @@ func (a *CPUAllocator) takeIdleThreads() {
 
 	a.Debug(" => idle threads unsorted: %v", cores)
 
+	var rank map[sysfs.ID]int
+	if (a.flags & AllocIdleCaches) != 0 {
+		rank = a.rankCacheDomains()
+	}
+
 	// sorted for preference by id, mimicking cpus_assignment.go for now:
 	//   IOW, prefer CPUs
 	//     - from packages with higher number of CPUs/cores already in a.result
 	//     - from packages with fewer remaining free CPUs/cores in a.from
+	//     - from preferred L3 cache domains, if we're cache-aware
 	//     - from cores with fewer remaining free CPUs/cores in a.from
 	//     - from packages with lower id
 	//     - with lower id
@@ -295,6 +436,7 @@ func (a *CPUAllocator) takeIdleThreads() {
 			// prefer CPUs from packages with
 			//   - higher number of CPUs/cores already in a.result, and
 			//   - fewer remaining free CPUs/cores in a.from
+			//   - from preferred L3 cache domains
 			//   - from cores with fewer remaining CPUs/cores in a.from
 			//   - lower id
 
@@ -303,6 +445,8 @@ func (a *CPUAllocator) takeIdleThreads() {
 				return iPkgColo > jPkgColo
 			case iPkgFree != jPkgFree:
 				return iPkgFree < jPkgFree
+			case rank[iCore] != rank[jCore]:
+				return rank[iCore] < rank[jCore]
 			case iCoreFree != jCoreFree:
 				return iCoreFree < jCoreFree
 			default:
@@ -337,6 +481,14 @@ func (a *CPUAllocator) allocate() cpuset.CPUSet {
 		}
 	}
 
+	if (a.flags & AllocIdleCaches) != 0 {
+		a.takeIdleCaches()
+
+		if a.cnt == 0 {
+			return a.result
+		}
+	}
+
 	if (a.flags & AllocIdleCores) != 0 {
 		a.takeIdleCores()
 
@@ -353,7 +505,7 @@ func (a *CPUAllocator) allocate() cpuset.CPUSet {
 	return cpuset.NewCPUSet()
 }
 
-func allocateCpus(from *cpuset.CPUSet, cnt int) (cpuset.CPUSet, error) {
+func allocateCpus(from *cpuset.CPUSet, cnt int, flags AllocFlag) (cpuset.CPUSet, error) {
 	var result cpuset.CPUSet
 	var err error
 
@@ -364,6 +516,7 @@ func allocateCpus(from *cpuset.CPUSet, cnt int) (cpuset.CPUSet, error) {
 		result, err, *from = from.Clone(), nil, cpuset.NewCPUSet()
 	default:
 		a := NewCPUAllocator(nil)
+		a.flags = flags
 		a.from = from.Clone()
 		a.cnt = cnt
 
@@ -377,7 +530,13 @@ func allocateCpus(from *cpuset.CPUSet, cnt int) (cpuset.CPUSet, error) {
 
 // AllocateCpus allocates a number of CPUs from the given set.
 func AllocateCpus(from *cpuset.CPUSet, cnt int) (cpuset.CPUSet, error) {
-	result, err := allocateCpus(from, cnt)
+	result, err := allocateCpus(from, cnt, AllocDefault)
+	return result, err
+}
+
+// AllocateCpusWithFlags allocates a number of CPUs from the given set with the given preferences.
+func AllocateCpusWithFlags(from *cpuset.CPUSet, cnt int, flags AllocFlag) (cpuset.CPUSet, error) {
+	result, err := allocateCpus(from, cnt, flags)
 	return result, err
 }
 
@@ -389,7 +548,7 @@ func ReleaseCpus(from *cpuset.CPUSet, cnt int) (cpuset.CPUSet, error) {
 		oset = from.Clone()
 	}
 
-	result, err := allocateCpus(from, from.Size()-cnt)
+	result, err := allocateCpus(from, from.Size()-cnt, AllocDefault)
 
 	if debug {
 		log.Info("ReleaseCpus(#%s, %d) => kept: #%s, released: #%s", oset.String(), cnt,
@@ -0,0 +1,131 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cpuallocator
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/testutils"
+)
+
+// createTestSysfs creates a fake sysfs for a single socket with 16 CPUs, 2 threads
+// per core, L2 clusters of 4 CPUs and two L3 domains of 8 CPUs.
+func createTestSysfs(t *testing.T, dir string) {
+	files := map[string]string{
+		"devices/system/node/node0/cpulist":  "0-15\n",
+		"devices/system/node/node0/distance": "10\n",
+	}
+	for cpu := 0; cpu < 16; cpu++ {
+		core, l2, l3 := cpu/2*2, cpu/4*4, cpu/8*8
+		path := fmt.Sprintf("devices/system/cpu/cpu%d/", cpu)
+		files[path+"online"] = "1\n"
+		files[path+"topology/physical_package_id"] = "0\n"
+		files[path+"topology/thread_siblings_list"] = fmt.Sprintf("%d-%d\n", core, core+1)
+		files[path+"node0/cpulist"] = "0-15\n"
+		for idx, c := range []struct {
+			level, kind, cpus, size string
+			id                      int
+		}{
+			{"1", "Data", fmt.Sprintf("%d-%d", core, core+1), "32K", core / 2},
+			{"1", "Instruction", fmt.Sprintf("%d-%d", core, core+1), "32K", core / 2},
+			{"2", "Unified", fmt.Sprintf("%d-%d", l2, l2+3), "2048K", l2 / 4},
+			{"3", "Unified", fmt.Sprintf("%d-%d", l3, l3+7), "16M", l3 / 8},
+		} {
+			index := fmt.Sprintf("%scache/index%d/", path, idx)
+			files[index+"level"] = c.level + "\n"
+			files[index+"type"] = c.kind + "\n"
+			files[index+"shared_cpu_list"] = c.cpus + "\n"
+			files[index+"size"] = c.size + "\n"
+			files[index+"id"] = fmt.Sprintf("%d\n", c.id)
+		}
+	}
+
+	testutils.CreateFiles(t, dir, files)
+}
+
+func TestAllocateIdleCaches(t *testing.T) {
+	dir, cleanup := testutils.TempDir(t, "cpuallocator-test")
+	defer cleanup()
+
+	createTestSysfs(t, dir)
+	sys, err := sysfs.DiscoverSystemAt(dir, sysfs.DiscoverCPUTopology|sysfs.DiscoverCache)
+	if err != nil {
+		t.Fatalf("failed to discover test sysfs: %v", err)
+	}
+	SetSystem(sys)
+
+	if l3 := sys.CacheDomains(3); len(l3) != 2 {
+		t.Fatalf("expected 2 L3 cache domains, got %d", len(l3))
+	}
+	if l2 := sys.CacheDomains(2); len(l2) != 4 {
+		t.Fatalf("expected 4 L2 cache domains, got %d", len(l2))
+	}
+
+	tcs := []struct {
+		name   string
+		from   string
+		cnt    int
+		flags  AllocFlag
+		result string
+	}{
+		{
+			name:   "idle cores without cache awareness",
+			from:   "2-15",
+			cnt:    4,
+			flags:  AllocDefault,
+			result: "2-5",
+		},
+		{
+			name:   "idle L3 domain",
+			from:   "0-15",
+			cnt:    8,
+			flags:  AllocDefault | AllocIdleCaches,
+			result: "0-7",
+		},
+		{
+			name:   "idle L2 cluster",
+			from:   "2-15",
+			cnt:    4,
+			flags:  AllocDefault | AllocIdleCaches,
+			result: "4-7",
+		},
+		{
+			name:   "single L3 domain",
+			from:   "0-2,8-13",
+			cnt:    5,
+			flags:  AllocDefault | AllocIdleCaches,
+			result: "8-12",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			from := cpuset.MustParse(tc.from)
+			result, err := AllocateCpusWithFlags(&from, tc.cnt, tc.flags)
+			if err != nil {
+				t.Fatalf("unexpected allocation error: %v", err)
+			}
+			if expected := cpuset.MustParse(tc.result); !result.Equals(expected) {
+				t.Errorf("expected allocation %s, got %s", expected, result)
+			}
+			if !from.Intersection(result).IsEmpty() {
+				t.Errorf("allocated CPUs %s left in free set %s", result, from)
+			}
+		})
+	}
+}
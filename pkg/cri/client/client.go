@@ -15,15 +15,20 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
 
 	api "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
 
+	"github.com/intel/cri-resource-manager/pkg/cri/versions"
 	"github.com/intel/cri-resource-manager/pkg/instrumentation"
 	logger "github.com/intel/cri-resource-manager/pkg/log"
 	"github.com/intel/cri-resource-manager/pkg/utils"
@@ -57,6 +62,8 @@ type Client interface {
 	HasRuntimeService() bool
 	// HasImageService checks if the client is configured with image services.
 	HasImageService() bool
+	// APIVersion returns the CRI API version negotiated with the runtime service.
+	APIVersion() string
 
 	// We expose full image and runtime client services.
 	api.ImageServiceClient
@@ -68,16 +75,35 @@ type client struct {
 	logger.Logger
 	api.ImageServiceClient
 	api.RuntimeServiceClient
-	options Options          // client options
-	icc     *grpc.ClientConn // our gRPC connection to the image service
-	rcc     *grpc.ClientConn // our gRPC connection to the runtime service
+	options  Options          // client options
+	icc      *grpc.ClientConn // our gRPC connection to the image service
+	rcc      *grpc.ClientConn // our gRPC connection to the runtime service
+	iversion *apiVersion      // API version negotiated for the image service
+	rversion *apiVersion      // API version negotiated for the runtime service
 }
 
 const (
 	// DontConnect is used to mark a socket to not be connected.
 	DontConnect = "-"
+
+	// apiVersionV1 is the v1 CRI API version.
+	apiVersionV1 = "v1"
+	// apiVersionV1alpha2 is the v1alpha2 CRI API version, used for our requests.
+	apiVersionV1alpha2 = "v1alpha2"
+	// negotiateTimeout is the timeout for probing the API version of a service.
+	negotiateTimeout = 5 * time.Second
 )
 
+// apiVersion is the CRI API version negotiated for a connection.
+//
+// We always use the v1alpha2 API internally. The v1 API is wire-compatible with
+// it, so we talk to v1 services by rewriting the method of requests, converting
+// requests to v1 and replies from v1 explicitly to keep any v1-only fields of
+// the requests we relay and of their replies.
+type apiVersion struct {
+	version string
+}
+
 // NewClient creates a new client instance.
 func NewClient(options Options) (Client, error) {
 	if options.ImageSocket == DontConnect && options.RuntimeSocket == DontConnect {
@@ -97,11 +123,18 @@ func (c *client) Connect(options ConnectOptions) error {
 	var err error
 
 	kind, socket := "image services", c.options.ImageSocket
-	if c.icc, err = c.connect(kind, socket, options); err != nil {
+	c.iversion = &apiVersion{}
+	if c.icc, err = c.connect(kind, socket, c.iversion, options); err != nil {
 		return err
 	}
 
 	if c.icc != nil {
+		err = c.negotiate(kind, c.icc, c.iversion, "ImageService/ImageFsInfo",
+			&api.ImageFsInfoRequest{}, &api.ImageFsInfoResponse{})
+		if err != nil {
+			c.Close()
+			return err
+		}
 		c.Debug("starting %s client on socket %s...", kind, socket)
 		c.ImageServiceClient = api.NewImageServiceClient(c.icc)
 	}
@@ -109,14 +142,24 @@ func (c *client) Connect(options ConnectOptions) error {
 	kind, socket = "runtime services", c.options.RuntimeSocket
 	if socket == c.options.ImageSocket {
 		c.rcc = c.icc
+		c.rversion = c.iversion
 	} else {
-		if c.rcc, err = c.connect(kind, socket, options); err != nil {
-			c.icc = nil
+		c.rversion = &apiVersion{}
+		if c.rcc, err = c.connect(kind, socket, c.rversion, options); err != nil {
+			c.Close()
 			return err
 		}
 	}
 
 	if c.rcc != nil {
+		if c.rcc != c.icc {
+			err = c.negotiate(kind, c.rcc, c.rversion, "RuntimeService/Version",
+				&api.VersionRequest{}, &api.VersionResponse{})
+			if err != nil {
+				c.Close()
+				return err
+			}
+		}
 		c.Debug("starting %s client on socket %s...", kind, socket)
 		c.RuntimeServiceClient = api.NewRuntimeServiceClient(c.rcc)
 	}
@@ -124,6 +167,44 @@ func (c *client) Connect(options ConnectOptions) error {
 	return nil
 }
 
+// APIVersion returns the CRI API version negotiated with the runtime service.
+func (c *client) APIVersion() string {
+	switch {
+	case c.rcc != nil:
+		return c.rversion.version
+	case c.icc != nil:
+		return c.iversion.version
+	}
+	return ""
+}
+
+// negotiate finds the CRI API version to use with a service by probing it with a request.
+func (c *client) negotiate(kind string, cc *grpc.ClientConn, v *apiVersion,
+	method string, req, rpl interface{}) error {
+	for _, version := range []string{apiVersionV1, apiVersionV1alpha2} {
+		v.version = version
+
+		ctx, cancel := context.WithTimeout(context.Background(), negotiateTimeout)
+		err := cc.Invoke(ctx, "/runtime."+apiVersionV1alpha2+"."+method, req, rpl)
+		cancel()
+
+		// Any reply other than an unimplemented or a transport error tells us
+		// that the service is there, even if it failed to process our probe.
+		switch status.Code(err) {
+		case codes.Unimplemented:
+			c.Debug("%s do not implement CRI %s API", kind, version)
+			continue
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+			return clientError("failed to probe CRI %s API of %s: %v", version, kind, err)
+		}
+
+		c.Info("using CRI %s API for %s", version, kind)
+		return nil
+	}
+
+	return clientError("%s implement none of the supported CRI API versions", kind)
+}
+
 // Close any open service connection.
 func (c *client) Close() {
 	if c.icc != nil {
@@ -172,7 +253,7 @@ func (c *client) HasImageService() bool {
 }
 
 // connect attempts to create a gRPC client connection to the given socket.
-func (c *client) connect(kind, socket string, options ConnectOptions) (*grpc.ClientConn, error) {
+func (c *client) connect(kind, socket string, v *apiVersion, options ConnectOptions) (*grpc.ClientConn, error) {
 	var cc *grpc.ClientConn
 	var err error
 
@@ -184,6 +265,7 @@ func (c *client) connect(kind, socket string, options ConnectOptions) (*grpc.Cli
 		grpc.WithInsecure(),
 		grpc.WithBlock(),
 		grpc.FailOnNonTempDialError(true),
+		grpc.WithUnaryInterceptor(v.intercept),
 		grpc.WithDialer(func(socket string, timeout time.Duration) (net.Conn, error) {
 			return net.DialTimeout("unix", socket, timeout)
 		}))
@@ -202,6 +284,49 @@ func (c *client) connect(kind, socket string, options ConnectOptions) (*grpc.Cli
 	return cc, nil
 }
 
+// intercept rewrites the method of a request according to the negotiated API version.
+func (v *apiVersion) intercept(ctx context.Context, method string, req, rpl interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	call := versions.FromContext(ctx, req)
+
+	if v.version != apiVersionV1 {
+		if call != nil && !call.Extensions.IsEmpty() {
+			return status.Errorf(codes.InvalidArgument,
+				"%s: v1-only fields of request not supported by CRI %s runtime", method, v.version)
+		}
+		return invoker(ctx, method, req, rpl, cc, opts...)
+	}
+
+	method = strings.Replace(method, "/runtime."+apiVersionV1alpha2+".", "/runtime."+apiVersionV1+".", 1)
+
+	var ext *versions.Extensions
+	if call != nil {
+		ext = call.Extensions
+	}
+	data, complete, err := versions.ToV1(req.(versions.Message), ext)
+	if err != nil {
+		return status.Errorf(codes.Internal, "%s: %v", method, err)
+	}
+	if !complete {
+		return status.Errorf(codes.InvalidArgument,
+			"%s: v1-only fields of request lost while processing it", method)
+	}
+
+	out := &versions.Raw{}
+	if err := invoker(ctx, method, &versions.Raw{Data: data}, out, cc, opts...); err != nil {
+		return err
+	}
+	ext, err = versions.FromV1(out.Data, rpl.(versions.Message))
+	if err != nil {
+		return status.Errorf(codes.Internal, "%s: %v", method, err)
+	}
+	if call != nil {
+		call.Reply = ext
+	}
+
+	return nil
+}
+
 // Return a formatted client-specific error.
 func clientError(format string, args ...interface{}) error {
 	return fmt.Errorf("cri/client: "+format, args...)
@@ -17,6 +17,7 @@ package resmgr
 import (
 	// List of builtin policies
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/eda"
+	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/external"
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/none"
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/static"
 	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/static-plus"
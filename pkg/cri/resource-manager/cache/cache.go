@@ -43,6 +43,8 @@ const (
 
 	// TagAVX512 tags containers that use AVX512 instructions.
 	TagAVX512 = "AVX512"
+	// TagPool tags containers with the name of the pool they are assigned to.
+	TagPool = "pool"
 )
 
 // PodState is the pod state in the runtime.
@@ -293,6 +295,10 @@ type Container interface {
 	SetCpusetCpus(string)
 	// SetCpusetMems sets the cgroup cpuset.mems of the container.
 	SetCpusetMems(string)
+	// SetResourceUpdates updates the container with resources from an update request.
+	// It returns true if the resource requirements of the container changed,
+	// and a function for undoing the update.
+	SetResourceUpdates(*cri.LinuxContainerResources) (bool, func())
 
 	// GetAffinity returns the annotated affinity expressions for this container.
 	GetAffinity() []*Affinity
@@ -328,6 +334,8 @@ type Container interface {
 	// ClearPending clears the pending change marker for the given controller.
 	ClearPending(string)
 
+	// GetTags returns a copy of all container tags.
+	GetTags() map[string]string
 	// GetTag gets the value of the given tag.
 	GetTag(string) (string, bool)
 	// SetTag sets the value of the given tag and returns its previous value..
@@ -462,6 +470,9 @@ type Cache interface {
 	GetActivePolicy() string
 	// SetActivePolicy updates the name of the active policy stored in the cache.
 	SetActivePolicy(string) error
+	// ResetActivePolicy clears the active policy and all its data stored in the cache,
+	// returning a function for restoring them.
+	ResetActivePolicy() (func() error, error)
 
 	// SetPolicyEntry sets the policy entry for a key.
 	SetPolicyEntry(string, interface{})
@@ -488,8 +499,9 @@ type Cache interface {
 }
 
 const (
-	// CacheVersion is the running version of the cache.
-	CacheVersion = "1"
+	// CacheVersion is the running version of the cache. Version 2 added memory
+	// grants to topology-aware allocations and block I/O class enforcement.
+	CacheVersion = "2"
 )
 
 // Our cache of objects.
@@ -554,6 +566,25 @@ func (cch *cache) SetActivePolicy(policy string) error {
 	return cch.Save()
 }
 
+// ResetActivePolicy clears the active policy and all its data stored in the cache,
+// returning a function for restoring them.
+func (cch *cache) ResetActivePolicy() (func() error, error) {
+	cch.Info("dropping cached data of policy %s...", cch.PolicyName)
+
+	name, data, raw := cch.PolicyName, cch.policyData, cch.PolicyJSON
+	restore := func() error {
+		cch.Info("restoring cached data of policy %s...", name)
+		cch.PolicyName, cch.policyData, cch.PolicyJSON = name, data, raw
+		return cch.Save()
+	}
+
+	cch.PolicyName = ""
+	cch.policyData = make(map[string]interface{})
+	cch.PolicyJSON = make(map[string]string)
+
+	return restore, cch.Save()
+}
+
 // SetConfig caches the given configuration.
 func (cch *cache) SetConfig(cfg *config.RawConfig) error {
 	old := cch.Cfg
@@ -719,6 +750,8 @@ func (cch *cache) LookupContainer(id string) (Container, bool) {
 func (cch *cache) LookupContainerByCgroup(path string) (Container, bool) {
 	cch.Debug("resolving %s to a container...", path)
 
+	path = "/" + strings.TrimPrefix(path, "/")
+
 	for id, c := range cch.Containers {
 		if id != c.CacheID {
 			continue
@@ -732,7 +765,7 @@ func (cch *cache) LookupContainerByCgroup(path string) (Container, bool) {
 			continue
 		}
 
-		if !strings.HasPrefix(path, parent+"/") {
+		if !strings.HasPrefix(path, "/"+strings.TrimPrefix(parent, "/")+"/") {
 			continue
 		}
 
@@ -1228,7 +1261,23 @@ func (cch *cache) Load() error {
 		return cacheError("failed to load cache from file '%s': %v", cch.filePath, err)
 	}
 
-	return cch.Restore(data)
+	version, err := snapshotVersion(data)
+	if err != nil {
+		return cacheError("failed to load cache from file '%s': %v", cch.filePath, err)
+	}
+
+	if version == CacheVersion {
+		return cch.Restore(data)
+	}
+
+	if data, err = cch.upgrade(data, version); err != nil {
+		return err
+	}
+	if err = cch.Restore(data); err != nil {
+		return err
+	}
+
+	return cch.Save()
 }
 
 func (cch *cache) ContainerDirectory(id string) string {
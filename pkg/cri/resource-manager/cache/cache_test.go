@@ -210,3 +210,59 @@ func TestLookupContainerByCgroup(t *testing.T) {
 		}
 	}
 }
+
+func TestResetActivePolicy(t *testing.T) {
+	cch, dir, err := createTmpCache()
+	defer removeTmpCache(dir)
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	if err := cch.SetActivePolicy("static-plus"); err != nil {
+		t.Fatalf("failed to set active policy: %v", err)
+	}
+	cch.SetPolicyEntry("test-entry", "test-value")
+	if err := cch.Save(); err != nil {
+		t.Fatalf("failed to save cache: %v", err)
+	}
+
+	restore, err := cch.ResetActivePolicy()
+	if err != nil {
+		t.Fatalf("failed to reset active policy: %v", err)
+	}
+	if err := cch.SetActivePolicy("topology-aware"); err != nil {
+		t.Fatalf("failed to set active policy: %v", err)
+	}
+
+	// check both the running cache and a reloaded copy of it
+	reloaded, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to reload cache: %v", err)
+	}
+	for _, c := range []Cache{cch, reloaded} {
+		if policy := c.GetActivePolicy(); policy != "topology-aware" {
+			t.Errorf("expected active policy topology-aware, got %s", policy)
+		}
+		value := ""
+		if c.GetPolicyEntry("test-entry", &value) {
+			t.Errorf("expected policy entry to be dropped, got %q", value)
+		}
+	}
+	// restoring brings back the original policy and its data
+	if err := restore(); err != nil {
+		t.Fatalf("failed to restore active policy: %v", err)
+	}
+	reloaded, err = NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to reload cache: %v", err)
+	}
+	for _, c := range []Cache{cch, reloaded} {
+		if policy := c.GetActivePolicy(); policy != "static-plus" {
+			t.Errorf("expected restored active policy static-plus, got %s", policy)
+		}
+		value := ""
+		if !c.GetPolicyEntry("test-entry", &value) || value != "test-value" {
+			t.Errorf("expected restored policy entry, got %q", value)
+		}
+	}
+}
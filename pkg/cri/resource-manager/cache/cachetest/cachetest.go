@@ -0,0 +1,88 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cachetest provides cache fixtures shared by the unit tests of other packages.
+package cachetest
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+// Pod describes a test pod with a single container.
+type Pod struct {
+	Name         string                       // pod name, also used as its ID
+	Namespace    string                       // pod namespace
+	CgroupParent string                       // pod cgroup parent, determines its QoS class
+	Labels       map[string]string            // pod labels
+	Annotations  map[string]string            // pod annotations
+	Resources    *cri.LinuxContainerResources // resources of the container
+}
+
+// NewCache creates a cache in a temporary directory and returns it with a function to remove it.
+func NewCache(t *testing.T) (cache.Cache, func()) {
+	dir, err := ioutil.TempDir("", "cache-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	cch, err := cache.NewCache(cache.Options{CacheDir: dir})
+	if err != nil {
+		cleanup()
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	return cch, cleanup
+}
+
+// CreateContainer inserts the pod into the cache and returns its container, named ctr.
+func CreateContainer(t *testing.T, cch cache.Cache, pod Pod) cache.Container {
+	podCfg := &cri.PodSandboxConfig{
+		Metadata: &cri.PodSandboxMetadata{
+			Name:      pod.Name,
+			Uid:       pod.Name + "-uid",
+			Namespace: pod.Namespace,
+		},
+		Labels:      pod.Labels,
+		Annotations: pod.Annotations,
+	}
+	if pod.CgroupParent != "" {
+		podCfg.Linux = &cri.LinuxPodSandboxConfig{CgroupParent: pod.CgroupParent}
+	}
+	resources := pod.Resources
+	if resources == nil {
+		resources = &cri.LinuxContainerResources{}
+	}
+
+	cch.InsertPod(pod.Name, &cri.RunPodSandboxRequest{Config: podCfg})
+	c, err := cch.InsertContainer(&cri.CreateContainerRequest{
+		PodSandboxId: pod.Name,
+		Config: &cri.ContainerConfig{
+			Metadata: &cri.ContainerMetadata{Name: "ctr"},
+			Linux:    &cri.LinuxContainerConfig{Resources: resources},
+		},
+		SandboxConfig: podCfg,
+	})
+	if err != nil {
+		t.Fatalf("failed to create container in pod %s: %v", pod.Name, err)
+	}
+
+	return c
+}
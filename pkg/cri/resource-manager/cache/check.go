@@ -0,0 +1,164 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+)
+
+// CheckReport describes the contents of a cache file and the problems found in it.
+type CheckReport struct {
+	// Path is the path of the checked cache file.
+	Path string
+	// Version is the version of the cache file.
+	Version string
+	// Upgrade lists the versions the cache file would get upgraded through when loaded.
+	Upgrade []string
+	// Policy is the name of the policy active when the cache was saved.
+	Policy string
+	// Pods is the number of cached pods.
+	Pods int
+	// Containers is the number of cached containers.
+	Containers int
+	// PolicyEntries are the keys of the policy entries in the cache.
+	PolicyEntries []string
+	// HasConfig tells whether the cache contains a configuration.
+	HasConfig bool
+	// Problems lists all the problems found.
+	Problems []string
+}
+
+// CheckFile checks the given cache file offline, without modifying it.
+func CheckFile(path string) (*CheckReport, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, cacheError("failed to read cache file '%s': %v", path, err)
+	}
+
+	r := &CheckReport{Path: path}
+
+	if r.Version, err = snapshotVersion(data); err != nil {
+		r.problem("%v", err)
+		return r, nil
+	}
+
+	if r.Version != CacheVersion {
+		if r.Upgrade, err = migrationPath(r.Version, CacheVersion); err != nil {
+			r.problem("%v", err)
+			return r, nil
+		}
+		if data, err = migrateSnapshot(data, CacheVersion); err != nil {
+			r.problem("%v", err)
+			return r, nil
+		}
+	}
+
+	cch := &cache{
+		Logger:     logger.NewLogger("cache"),
+		filePath:   path,
+		Pods:       make(map[string]*pod),
+		Containers: make(map[string]*container),
+		policyData: make(map[string]interface{}),
+		PolicyJSON: make(map[string]string),
+		implicit:   make(map[string]*ImplicitAffinity),
+	}
+	if err := cch.Restore(data); err != nil {
+		r.problem("%v", err)
+		return r, nil
+	}
+
+	cch.check(r)
+
+	return r, nil
+}
+
+// check checks the consistency of the cache, filling in the given report.
+func (cch *cache) check(r *CheckReport) {
+	r.Policy = cch.PolicyName
+	r.Pods = len(cch.Pods)
+	r.HasConfig = cch.Cfg != nil
+
+	for id, c := range cch.Containers {
+		if id != c.CacheID {
+			continue
+		}
+		r.Containers++
+
+		if _, ok := cch.Pods[c.PodID]; !ok {
+			r.problem("container %s (%s) refers to unknown pod %s", c.CacheID, c.ID, c.PodID)
+		}
+		if strings.HasPrefix(c.CacheID, "cache:") {
+			next, err := strconv.ParseUint(strings.TrimPrefix(c.CacheID, "cache:"), 16, 64)
+			if err != nil || next >= cch.NextID {
+				r.problem("container %s has a local cache ID not below next ID %d",
+					c.CacheID, cch.NextID)
+			}
+		}
+	}
+
+	for key, entry := range cch.PolicyJSON {
+		r.PolicyEntries = append(r.PolicyEntries, key)
+		if !json.Valid([]byte(entry)) {
+			r.problem("policy entry '%s' is not valid JSON", key)
+		}
+	}
+	sort.Strings(r.PolicyEntries)
+
+	if len(r.PolicyEntries) > 0 && r.Policy == "" {
+		r.problem("cache has policy entries but no active policy")
+	}
+
+	sort.Strings(r.Problems)
+}
+
+// problem records a problem found in the cache.
+func (r *CheckReport) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// String returns a human-readable form of the report.
+func (r *CheckReport) String() string {
+	lines := []string{
+		fmt.Sprintf("cache file:     %s", r.Path),
+		fmt.Sprintf("version:        %s", r.Version),
+	}
+	if len(r.Upgrade) > 0 {
+		lines = append(lines,
+			fmt.Sprintf("upgrade path:   %s", strings.Join(r.Upgrade, " -> ")))
+	}
+	lines = append(lines,
+		fmt.Sprintf("active policy:  %s", r.Policy),
+		fmt.Sprintf("pods:           %d", r.Pods),
+		fmt.Sprintf("containers:     %d", r.Containers),
+		fmt.Sprintf("policy entries: %s", strings.Join(r.PolicyEntries, ", ")),
+		fmt.Sprintf("configuration:  %v", r.HasConfig),
+	)
+	if len(r.Problems) == 0 {
+		lines = append(lines, "no problems found")
+	} else {
+		lines = append(lines, fmt.Sprintf("%d problem(s) found:", len(r.Problems)))
+		for _, p := range r.Problems {
+			lines = append(lines, "  - "+p)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -609,6 +609,70 @@ func (c *container) SetCpusetMems(value string) {
 	c.markPending(CRI)
 }
 
+func (c *container) SetResourceUpdates(req *cri.LinuxContainerResources) (bool, func()) {
+	if req == nil {
+		return false, func() {}
+	}
+
+	var linux *cri.LinuxContainerResources
+	if c.LinuxReq != nil {
+		saved := *c.LinuxReq
+		linux = &saved
+	}
+	resources := *c.Resources.DeepCopy()
+	undo := func() {
+		c.LinuxReq = linux
+		c.Resources = resources
+	}
+
+	if c.LinuxReq == nil {
+		c.LinuxReq = &cri.LinuxContainerResources{}
+	}
+
+	// only take the parameters present in the update request
+	if req.CpuPeriod != 0 {
+		c.LinuxReq.CpuPeriod = req.CpuPeriod
+	}
+	if req.CpuQuota != 0 {
+		c.LinuxReq.CpuQuota = req.CpuQuota
+	}
+	if req.CpuShares != 0 {
+		c.LinuxReq.CpuShares = req.CpuShares
+	}
+	if req.MemoryLimitInBytes != 0 {
+		c.LinuxReq.MemoryLimitInBytes = req.MemoryLimitInBytes
+	}
+	if req.OomScoreAdj != 0 {
+		c.LinuxReq.OomScoreAdj = req.OomScoreAdj
+	}
+	// Notes:
+	//   Cpusets are owned by the active policy, so we never take them
+	//   from the update request.
+
+	changed := false
+	updated := estimateComputeResources(req)
+	if c.Resources.Requests == nil {
+		c.Resources.Requests = v1.ResourceList{}
+	}
+	if c.Resources.Limits == nil {
+		c.Resources.Limits = v1.ResourceList{}
+	}
+	for name, qty := range updated.Requests {
+		if old, ok := c.Resources.Requests[name]; !ok || old.Cmp(qty) != 0 {
+			c.Resources.Requests[name] = qty
+			changed = true
+		}
+	}
+	for name, qty := range updated.Limits {
+		if old, ok := c.Resources.Limits[name]; !ok || old.Cmp(qty) != 0 {
+			c.Resources.Limits[name] = qty
+			changed = true
+		}
+	}
+
+	return changed, undo
+}
+
 func getTopologyHints(hostPath, containerPath string, readOnly bool) topology.Hints {
 
 	if readOnly {
@@ -798,6 +862,14 @@ func (c *container) HasPending(controller string) bool {
 	return pending
 }
 
+func (c *container) GetTags() map[string]string {
+	tags := make(map[string]string, len(c.Tags))
+	for key, value := range c.Tags {
+		tags[key] = value
+	}
+	return tags
+}
+
 func (c *container) GetTag(key string) (string, bool) {
 	value, ok := c.Tags[key]
 	return value, ok
@@ -20,6 +20,9 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+
+	v1 "k8s.io/api/core/v1"
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
 )
 
 func TestGetKubeletHint(t *testing.T) {
@@ -164,3 +167,60 @@ func TestKeysInNamespace(t *testing.T) {
 		})
 	}
 }
+
+func TestSetResourceUpdates(t *testing.T) {
+	tcases := []struct {
+		name        string
+		update      *cri.LinuxContainerResources
+		changed     bool
+		expectedCPU int64
+		cpuset      string
+	}{
+		{
+			name:        "nil update",
+			expectedCPU: 1000,
+			cpuset:      "0-1",
+		},
+		{
+			name:        "CPU request change",
+			update:      &cri.LinuxContainerResources{CpuShares: 2048},
+			changed:     true,
+			expectedCPU: 2000,
+			cpuset:      "0-1",
+		},
+		{
+			name:        "unchanged CPU request",
+			update:      &cri.LinuxContainerResources{CpuShares: 1024},
+			expectedCPU: 1000,
+			cpuset:      "0-1",
+		},
+	}
+
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &container{
+				LinuxReq: &cri.LinuxContainerResources{CpuShares: 1024, CpusetCpus: "0-1"},
+			}
+			c.Resources = estimateComputeResources(c.LinuxReq)
+
+			changed, undo := c.SetResourceUpdates(tc.update)
+			if changed != tc.changed {
+				t.Errorf("expected changed %v, got %v", tc.changed, changed)
+			}
+			cpu := c.Resources.Requests[v1.ResourceCPU]
+			if cpu.MilliValue() != tc.expectedCPU {
+				t.Errorf("expected CPU request %d, got %d", tc.expectedCPU, cpu.MilliValue())
+			}
+			if c.GetCpusetCpus() != tc.cpuset {
+				t.Errorf("expected cpuset %q, got %q", tc.cpuset, c.GetCpusetCpus())
+			}
+
+			undo()
+			cpu = c.Resources.Requests[v1.ResourceCPU]
+			if cpu.MilliValue() != 1000 || c.LinuxReq.CpuShares != 1024 {
+				t.Errorf("expected update to be undone, got CPU request %d, shares %d",
+					cpu.MilliValue(), c.LinuxReq.CpuShares)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+)
+
+// RawSnapshot is a version-agnostic, generic form of a cache snapshot used for migration.
+type RawSnapshot struct {
+	// Version is the version of the snapshot.
+	Version string
+	// Pods are the cached pods, by pod runtime ID.
+	Pods map[string]map[string]interface{}
+	// Containers are the cached containers, by container cache ID.
+	Containers map[string]map[string]interface{}
+	// PolicyName is the name of the policy active when the snapshot was taken.
+	PolicyName string
+	// PolicyJSON are the raw policy entries, by key.
+	PolicyJSON map[string]string
+	// Fields are all the other fields of the snapshot, in raw form.
+	Fields map[string]json.RawMessage
+}
+
+// MigrateFn upgrades a raw snapshot from one version to the next.
+type MigrateFn func(*RawSnapshot) error
+
+// migration is a registered snapshot upgrade from one version to the next.
+type migration struct {
+	to    string      // version the steps upgrade to
+	steps []MigrateFn // functions to do the upgrade, in registration order
+}
+
+// Registered migrations, by the version they upgrade from.
+var migrations = make(map[string]*migration)
+
+// RegisterMigration registers a step for upgrading cache snapshots from one version to another.
+// Several steps can be registered for the same upgrade, typically one by each package owning
+// data in the snapshot. The steps are run in the order they were registered.
+func RegisterMigration(from, to string, fn MigrateFn) error {
+	m, ok := migrations[from]
+	if !ok {
+		m = &migration{to: to}
+		migrations[from] = m
+	}
+	if m.to != to {
+		return cacheError("migration from version '%s' already registered (to '%s')",
+			from, m.to)
+	}
+
+	m.steps = append(m.steps, fn)
+
+	return nil
+}
+
+// migrationPath returns the versions a snapshot gets upgraded through to reach a target version.
+func migrationPath(from, target string) ([]string, error) {
+	path := []string{}
+	seen := map[string]struct{}{}
+
+	for version := from; version != target; {
+		m, ok := migrations[version]
+		if !ok {
+			return nil, cacheError("no migration path from version '%s' to '%s'",
+				from, target)
+		}
+		if _, ok := seen[version]; ok {
+			return nil, cacheError("migration loop detected at version '%s'", version)
+		}
+		seen[version] = struct{}{}
+		version = m.to
+		path = append(path, version)
+	}
+
+	return path, nil
+}
+
+// snapshotVersion returns the version of the given snapshot data.
+func snapshotVersion(data []byte) (string, error) {
+	s := struct{ Version string }{}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", cacheError("failed to unmarshal snapshot version: %v", err)
+	}
+	return s.Version, nil
+}
+
+// migrateSnapshot upgrades the given snapshot data to the target version.
+func migrateSnapshot(data []byte, target string) ([]byte, error) {
+	from, err := snapshotVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == target {
+		return data, nil
+	}
+
+	if _, err := migrationPath(from, target); err != nil {
+		return nil, err
+	}
+
+	raw := &RawSnapshot{}
+	if err := json.Unmarshal(data, raw); err != nil {
+		return nil, cacheError("failed to unmarshal snapshot for migration: %v", err)
+	}
+
+	for raw.Version != target {
+		m := migrations[raw.Version]
+		for _, migrate := range m.steps {
+			if err := migrate(raw); err != nil {
+				return nil, cacheError("failed to migrate snapshot from version '%s' to '%s': %v",
+					raw.Version, m.to, err)
+			}
+		}
+		raw.Version = m.to
+	}
+
+	return json.Marshal(raw)
+}
+
+// upgrade upgrades snapshot data to the running version, backing up the original data.
+func (cch *cache) upgrade(data []byte, version string) ([]byte, error) {
+	cch.Info("upgrading cache '%s' from version '%s' to '%s'...",
+		cch.filePath, version, CacheVersion)
+
+	upgraded, err := migrateSnapshot(data, CacheVersion)
+	if err != nil {
+		return nil, cacheError("failed to upgrade cache file '%s': %v", cch.filePath, err)
+	}
+
+	backup := cch.backupPath(version)
+	if err := ioutil.WriteFile(backup, data, 0644); err != nil {
+		return nil, cacheError("failed to back up cache file '%s' to '%s': %v",
+			cch.filePath, backup, err)
+	}
+
+	cch.Info("backed up cache version '%s' to '%s'", version, backup)
+
+	return upgraded, nil
+}
+
+// backupPath returns the path for backing up a cache file of the given version.
+func (cch *cache) backupPath(version string) string {
+	return cch.filePath + ".v" + version + ".bak"
+}
+
+// UnmarshalJSON implements JSON unmarshalling for raw snapshots.
+func (s *RawSnapshot) UnmarshalJSON(data []byte) error {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	known := map[string]interface{}{
+		"Version":    &s.Version,
+		"Pods":       &s.Pods,
+		"Containers": &s.Containers,
+		"PolicyName": &s.PolicyName,
+		"PolicyJSON": &s.PolicyJSON,
+	}
+	for name, ptr := range known {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		// use json.Number to preserve the exact value of all numeric fields
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(ptr); err != nil {
+			return cacheError("failed to unmarshal snapshot field %s: %v", name, err)
+		}
+		delete(fields, name)
+	}
+	s.Fields = fields
+
+	return nil
+}
+
+// MarshalJSON implements JSON marshalling for raw snapshots.
+func (s *RawSnapshot) MarshalJSON() ([]byte, error) {
+	obj := map[string]interface{}{}
+	for name, raw := range s.Fields {
+		obj[name] = raw
+	}
+
+	obj["Version"] = s.Version
+	obj["Pods"] = s.Pods
+	obj["Containers"] = s.Containers
+	obj["PolicyName"] = s.PolicyName
+	obj["PolicyJSON"] = s.PolicyJSON
+
+	return json.Marshal(obj)
+}
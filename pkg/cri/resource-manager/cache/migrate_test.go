@@ -0,0 +1,233 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testSnapshotV0 is a cache snapshot in a fictional pre-1 format, with container
+// names stored as ContainerName and policy entries stored with a 'legacy.' prefix.
+const testSnapshotV0 = `{
+  "Version": "test-0",
+  "Pods": {
+    "pod-1": {"ID": "pod-1", "UID": "uid-1", "Name": "pod", "Namespace": "default"}
+  },
+  "Containers": {
+    "uid-1:ctr": {"ID": "ctr-1", "PodID": "pod-1", "CacheID": "uid-1:ctr",
+                  "ContainerName": "ctr", "State": 2, "Tags": {"big": "18446744073709551615"}}
+  },
+  "NextID": 1,
+  "PolicyName": "test-policy",
+  "PolicyJSON": {"legacy.allocations": "{\"uid-1:ctr\":\"0-1\"}"}
+}`
+
+func init() {
+	RegisterMigration("test-0", "test-1", func(s *RawSnapshot) error {
+		for _, c := range s.Containers {
+			c["Name"] = c["ContainerName"]
+			delete(c, "ContainerName")
+		}
+		return nil
+	})
+	RegisterMigration("test-1", CacheVersion, func(s *RawSnapshot) error {
+		entries := map[string]string{}
+		for key, entry := range s.PolicyJSON {
+			entries[key[len("legacy."):]] = entry
+		}
+		s.PolicyJSON = entries
+		return nil
+	})
+	RegisterMigration("test-loop-a", "test-loop-b", func(*RawSnapshot) error { return nil })
+	RegisterMigration("test-loop-b", "test-loop-a", func(*RawSnapshot) error { return nil })
+}
+
+func TestMigrationPath(t *testing.T) {
+	tcs := []struct {
+		name    string
+		from    string
+		path    []string
+		invalid bool
+	}{
+		{name: "no upgrade needed", from: CacheVersion, path: []string{}},
+		{name: "single step", from: "test-1", path: []string{CacheVersion}},
+		{name: "multiple steps", from: "test-0", path: []string{"test-1", CacheVersion}},
+		{name: "unknown version", from: "test-unknown", invalid: true},
+		{name: "migration loop", from: "test-loop-a", invalid: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := migrationPath(tc.from, CacheVersion)
+			if tc.invalid {
+				if err == nil {
+					t.Errorf("expected error, got path %v", path)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(path) != len(tc.path) {
+				t.Fatalf("expected path %v, got %v", tc.path, path)
+			}
+			for i := range path {
+				if path[i] != tc.path[i] {
+					t.Errorf("expected path %v, got %v", tc.path, path)
+				}
+			}
+		})
+	}
+}
+
+func TestUpgradeOnLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-migrate-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cache")
+	if err := ioutil.WriteFile(path, []byte(testSnapshotV0), 0644); err != nil {
+		t.Fatalf("failed to write cache file: %v", err)
+	}
+
+	cch, err := NewCache(Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to load cache: %v", err)
+	}
+
+	c, ok := cch.LookupContainer("ctr-1")
+	if !ok {
+		t.Fatalf("failed to look up migrated container")
+	}
+	if c.GetName() != "ctr" {
+		t.Errorf("expected migrated container name ctr, got %q", c.GetName())
+	}
+	if tag, _ := c.GetTag("big"); tag != "18446744073709551615" {
+		t.Errorf("container tag not preserved by migration, got %q", tag)
+	}
+	if cch.GetActivePolicy() != "test-policy" {
+		t.Errorf("expected active policy test-policy, got %q", cch.GetActivePolicy())
+	}
+	allocations := map[string]string{}
+	if !cch.GetPolicyEntry("allocations", &allocations) || allocations["uid-1:ctr"] != "0-1" {
+		t.Errorf("policy entry not migrated, got %v", allocations)
+	}
+
+	backup, err := ioutil.ReadFile(path + ".vtest-0.bak")
+	if err != nil {
+		t.Fatalf("failed to read cache backup: %v", err)
+	}
+	if string(backup) != testSnapshotV0 {
+		t.Errorf("cache backup differs from original")
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read upgraded cache: %v", err)
+	}
+	s := struct{ Version string }{}
+	if err := json.Unmarshal(data, &s); err != nil || s.Version != CacheVersion {
+		t.Errorf("expected upgraded cache of version %s, got %q (%v)", CacheVersion, s.Version, err)
+	}
+}
+
+func TestCheckFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache-check-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tcs := []struct {
+		name     string
+		data     string
+		upgrade  int
+		problems int
+	}{
+		{
+			name:    "upgradable cache",
+			data:    testSnapshotV0,
+			upgrade: 2,
+		},
+		{
+			name:     "unknown version",
+			data:     `{"Version": "test-unknown"}`,
+			problems: 1,
+		},
+		{
+			name: "inconsistent cache",
+			data: `{"Version": "` + CacheVersion + `", "NextID": 1,
+			        "Containers": {"cache:1": {"ID": "ctr-1", "PodID": "pod-1", "CacheID": "cache:1"}},
+			        "PolicyJSON": {"entry": "{"}}`,
+			problems: 4,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "cache")
+			if err := ioutil.WriteFile(path, []byte(tc.data), 0644); err != nil {
+				t.Fatalf("failed to write cache file: %v", err)
+			}
+
+			r, err := CheckFile(path)
+			if err != nil {
+				t.Fatalf("failed to check cache file: %v", err)
+			}
+			if len(r.Upgrade) != tc.upgrade {
+				t.Errorf("expected %d upgrade steps, got %v", tc.upgrade, r.Upgrade)
+			}
+			if len(r.Problems) != tc.problems {
+				t.Errorf("expected %d problems, got %d:\n%s", tc.problems, len(r.Problems), r)
+			}
+
+			data, err := ioutil.ReadFile(path)
+			if err != nil || string(data) != tc.data {
+				t.Errorf("cache file modified by check")
+			}
+		})
+	}
+}
+
+func TestRegisterMigration(t *testing.T) {
+	steps := []string{}
+	step := func(name string) MigrateFn {
+		return func(*RawSnapshot) error {
+			steps = append(steps, name)
+			return nil
+		}
+	}
+
+	if err := RegisterMigration("test-multi-0", "test-multi-1", step("first")); err != nil {
+		t.Fatalf("failed to register migration: %v", err)
+	}
+	if err := RegisterMigration("test-multi-0", "test-multi-1", step("second")); err != nil {
+		t.Errorf("failed to register second step of migration: %v", err)
+	}
+	if err := RegisterMigration("test-multi-0", "test-multi-2", step("conflict")); err == nil {
+		t.Errorf("expected conflicting migration to be rejected")
+	}
+
+	if _, err := migrateSnapshot([]byte(`{"Version": "test-multi-0"}`), "test-multi-1"); err != nil {
+		t.Fatalf("failed to migrate snapshot: %v", err)
+	}
+	if len(steps) != 2 || steps[0] != "first" || steps[1] != "second" {
+		t.Errorf("expected steps first and second in order, got %v", steps)
+	}
+}
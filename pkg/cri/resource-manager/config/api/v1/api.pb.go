@@ -28,10 +28,12 @@ type SetConfigRequest struct {
 	// Name
 	NodeName string `protobuf:"bytes,1,opt,name=node_name,json=nodeName,proto3" json:"node_name,omitempty"`
 	// Key-value map of ConfigMap data
-	Config               map[string]string `protobuf:"bytes,2,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
-	XXX_unrecognized     []byte            `json:"-"`
-	XXX_sizecache        int32             `json:"-"`
+	Config map[string]string `protobuf:"bytes,2,rep,name=config,proto3" json:"config,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Only validate the configuration, do not apply it.
+	ValidateOnly         bool     `protobuf:"varint,3,opt,name=validate_only,json=validateOnly,proto3" json:"validate_only,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *SetConfigRequest) Reset()         { *m = SetConfigRequest{} }
@@ -73,12 +75,21 @@ func (m *SetConfigRequest) GetConfig() map[string]string {
 	return nil
 }
 
+func (m *SetConfigRequest) GetValidateOnly() bool {
+	if m != nil {
+		return m.ValidateOnly
+	}
+	return false
+}
+
 type SetConfigReply struct {
 	// If not empty, indicate an error that happened while trying to apply new configuration.
-	Error                string   `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Error string `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	// Validation errors found in the configuration, per module and field.
+	Errors               []*ConfigError `protobuf:"bytes,2,rep,name=errors,proto3" json:"errors,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}       `json:"-"`
+	XXX_unrecognized     []byte         `json:"-"`
+	XXX_sizecache        int32          `json:"-"`
 }
 
 func (m *SetConfigReply) Reset()         { *m = SetConfigReply{} }
@@ -113,10 +124,77 @@ func (m *SetConfigReply) GetError() string {
 	return ""
 }
 
+func (m *SetConfigReply) GetErrors() []*ConfigError {
+	if m != nil {
+		return m.Errors
+	}
+	return nil
+}
+
+// ConfigError describes a single problem found in the configuration.
+type ConfigError struct {
+	// Configuration module the error was found in.
+	Module string `protobuf:"bytes,1,opt,name=module,proto3" json:"module,omitempty"`
+	// Offending field of the module, if known.
+	Field string `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`
+	// Description of the problem.
+	Message              string   `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ConfigError) Reset()         { *m = ConfigError{} }
+func (m *ConfigError) String() string { return proto.CompactTextString(m) }
+func (*ConfigError) ProtoMessage()    {}
+func (*ConfigError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2d9bc9cf5b527561, []int{2}
+}
+
+func (m *ConfigError) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ConfigError.Unmarshal(m, b)
+}
+func (m *ConfigError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ConfigError.Marshal(b, m, deterministic)
+}
+func (m *ConfigError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigError.Merge(m, src)
+}
+func (m *ConfigError) XXX_Size() int {
+	return xxx_messageInfo_ConfigError.Size(m)
+}
+func (m *ConfigError) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigError proto.InternalMessageInfo
+
+func (m *ConfigError) GetModule() string {
+	if m != nil {
+		return m.Module
+	}
+	return ""
+}
+
+func (m *ConfigError) GetField() string {
+	if m != nil {
+		return m.Field
+	}
+	return ""
+}
+
+func (m *ConfigError) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*SetConfigRequest)(nil), "v1.SetConfigRequest")
 	proto.RegisterMapType((map[string]string)(nil), "v1.SetConfigRequest.ConfigEntry")
 	proto.RegisterType((*SetConfigReply)(nil), "v1.SetConfigReply")
+	proto.RegisterType((*ConfigError)(nil), "v1.ConfigError")
 }
 
 func init() {
@@ -124,23 +202,28 @@ func init() {
 }
 
 var fileDescriptor_2d9bc9cf5b527561 = []byte{
-	// 243 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x32, 0x28, 0xc8, 0x4e, 0xd7,
-	0x4f, 0x2e, 0xca, 0xd4, 0x2f, 0x4a, 0x2d, 0xce, 0x2f, 0x2d, 0x4a, 0x4e, 0xd5, 0xcd, 0x4d, 0xcc,
-	0x4b, 0x4c, 0x4f, 0x2d, 0xd2, 0x4f, 0xce, 0xcf, 0x4b, 0xcb, 0x4c, 0xd7, 0x4f, 0x2c, 0xc8, 0xd4,
-	0x2f, 0x33, 0x04, 0x51, 0x7a, 0x05, 0x45, 0xf9, 0x25, 0xf9, 0x42, 0x4c, 0x65, 0x86, 0x4a, 0x4b,
-	0x18, 0xb9, 0x04, 0x82, 0x53, 0x4b, 0x9c, 0xc1, 0x4a, 0x82, 0x52, 0x0b, 0x4b, 0x53, 0x8b, 0x4b,
-	0x84, 0xa4, 0xb9, 0x38, 0xf3, 0xf2, 0x53, 0x52, 0xe3, 0xf3, 0x12, 0x73, 0x53, 0x25, 0x18, 0x15,
-	0x18, 0x35, 0x38, 0x83, 0x38, 0x40, 0x02, 0x7e, 0x89, 0xb9, 0xa9, 0x42, 0x16, 0x5c, 0x6c, 0x10,
-	0x03, 0x25, 0x98, 0x14, 0x98, 0x35, 0xb8, 0x8d, 0x14, 0xf4, 0xca, 0x0c, 0xf5, 0xd0, 0x8d, 0xd0,
-	0x83, 0xf0, 0x5c, 0xf3, 0x4a, 0x8a, 0x2a, 0x83, 0xa0, 0xea, 0xa5, 0x2c, 0xb9, 0xb8, 0x91, 0x84,
-	0x85, 0x04, 0xb8, 0x98, 0xb3, 0x53, 0x2b, 0xa1, 0xe6, 0x83, 0x98, 0x42, 0x22, 0x5c, 0xac, 0x65,
-	0x89, 0x39, 0xa5, 0xa9, 0x12, 0x4c, 0x60, 0x31, 0x08, 0xc7, 0x8a, 0xc9, 0x82, 0x51, 0x49, 0x8d,
-	0x8b, 0x0f, 0xc9, 0x8a, 0x82, 0x1c, 0xb0, 0xda, 0xd4, 0xa2, 0xa2, 0xfc, 0x22, 0xa8, 0x7e, 0x08,
-	0xc7, 0xc8, 0x91, 0x8b, 0x0d, 0xa2, 0x48, 0xc8, 0x9c, 0x8b, 0x13, 0xae, 0x43, 0x48, 0x04, 0x9b,
-	0x1b, 0xa5, 0x84, 0xd0, 0x44, 0x0b, 0x72, 0x2a, 0x95, 0x18, 0x9c, 0x58, 0xa2, 0x98, 0xca, 0x0c,
-	0x93, 0xd8, 0xc0, 0x41, 0x64, 0x0c, 0x08, 0x00, 0x00, 0xff, 0xff, 0xb6, 0x6e, 0xc6, 0x28, 0x56,
-	0x01, 0x00, 0x00,
+	// 322 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x91, 0x4d, 0x4f, 0xb3, 0x40,
+	0x10, 0xc7, 0x1f, 0xe8, 0x23, 0x96, 0xa9, 0x2f, 0xcd, 0xa6, 0x31, 0xa4, 0x5e, 0x08, 0x1e, 0xec,
+	0x45, 0x90, 0x7a, 0xb0, 0x7a, 0x53, 0xe3, 0xd5, 0x26, 0x18, 0x2f, 0x5e, 0x9a, 0xb5, 0x4c, 0x09,
+	0xe9, 0xb2, 0x8b, 0xcb, 0x4b, 0xc2, 0x47, 0xf4, 0x5b, 0x19, 0x96, 0xa5, 0x69, 0x1a, 0x4f, 0xcc,
+	0xef, 0xcf, 0xbc, 0xfc, 0x67, 0x16, 0x6e, 0xf3, 0x6d, 0x12, 0xac, 0x65, 0x1a, 0x48, 0x2c, 0x44,
+	0x25, 0xd7, 0x78, 0x93, 0x51, 0x4e, 0x13, 0x94, 0xc1, 0x5a, 0xf0, 0x4d, 0x9a, 0x04, 0x34, 0x4f,
+	0x83, 0x3a, 0x6c, 0x3f, 0x7e, 0x2e, 0x45, 0x29, 0x88, 0x59, 0x87, 0xde, 0x8f, 0x01, 0xe3, 0x77,
+	0x2c, 0x5f, 0x54, 0x4a, 0x84, 0xdf, 0x15, 0x16, 0x25, 0xb9, 0x04, 0x9b, 0x8b, 0x18, 0x57, 0x9c,
+	0x66, 0xe8, 0x18, 0xae, 0x31, 0xb3, 0xa3, 0x61, 0x2b, 0xbc, 0xd1, 0x0c, 0xc9, 0x02, 0xac, 0xae,
+	0xa1, 0x63, 0xba, 0x83, 0xd9, 0x68, 0xee, 0xfa, 0x75, 0xe8, 0x1f, 0xb6, 0xf0, 0x3b, 0x7a, 0xe5,
+	0xa5, 0x6c, 0x22, 0x9d, 0x4f, 0xae, 0xe0, 0xb4, 0xa6, 0x2c, 0x8d, 0x69, 0x89, 0x2b, 0xc1, 0x59,
+	0xe3, 0x0c, 0x5c, 0x63, 0x36, 0x8c, 0x4e, 0x7a, 0x71, 0xc9, 0x59, 0x33, 0x7d, 0x80, 0xd1, 0x5e,
+	0x2d, 0x19, 0xc3, 0x60, 0x8b, 0x8d, 0x36, 0xd1, 0x86, 0x64, 0x02, 0x47, 0x35, 0x65, 0x15, 0x3a,
+	0xa6, 0xd2, 0x3a, 0x78, 0x34, 0x17, 0x86, 0xb7, 0x84, 0xb3, 0x3d, 0x1f, 0x39, 0x53, 0xb9, 0x28,
+	0xa5, 0x90, 0xba, 0xbe, 0x03, 0x72, 0x0d, 0x96, 0x0a, 0x0a, 0xbd, 0xc1, 0x79, 0xbb, 0x81, 0x1e,
+	0xda, 0xea, 0x91, 0xfe, 0xed, 0x7d, 0xec, 0xbc, 0xa8, 0xba, 0x0b, 0xb0, 0x32, 0x11, 0x57, 0xac,
+	0xbf, 0x89, 0xa6, 0x76, 0xca, 0x26, 0x45, 0x16, 0xf7, 0x8e, 0x14, 0x10, 0x07, 0x8e, 0x33, 0x2c,
+	0x0a, 0x9a, 0xa0, 0xda, 0xd3, 0x8e, 0x7a, 0x9c, 0x3f, 0x81, 0xd5, 0xb5, 0x25, 0xf7, 0x60, 0xef,
+	0x1c, 0x93, 0xc9, 0x5f, 0x87, 0x9c, 0x92, 0x03, 0x35, 0x67, 0x8d, 0xf7, 0xef, 0xf9, 0xff, 0xa7,
+	0x59, 0x87, 0x5f, 0x96, 0x7a, 0xc7, 0xbb, 0xdf, 0x01, 0x00, 0xc1, 0x8f, 0x65, 0x9d, 0xfb, 0x01,
+	0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
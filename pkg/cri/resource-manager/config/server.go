@@ -25,6 +25,7 @@ import (
 
 	"google.golang.org/grpc"
 
+	pkgcfg "github.com/intel/cri-resource-manager/pkg/config"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/config/api/v1"
 	"github.com/intel/cri-resource-manager/pkg/log"
 )
@@ -32,6 +33,9 @@ import (
 // SetConfigCb is a callback function for SetConfig request
 type SetConfigCb func(*RawConfig) error
 
+// CheckConfigCb is a callback function for validate-only SetConfig request
+type CheckConfigCb func(*RawConfig) error
+
 // Server is the interface for our gRPC server.
 type Server interface {
 	Start(string) error
@@ -41,16 +45,18 @@ type Server interface {
 // server implements Server.
 type server struct {
 	log.Logger
-	sync.Mutex               // lock for gRPC server against concurrent per-request goroutines.
-	server      *grpc.Server // gRPC server instance
-	setConfigCb SetConfigCb
+	sync.Mutex                 // lock for gRPC server against concurrent per-request goroutines.
+	server        *grpc.Server // gRPC server instance
+	setConfigCb   SetConfigCb
+	checkConfigCb CheckConfigCb
 }
 
 // NewConfigServer creates new Server instance.
-func NewConfigServer(cb SetConfigCb) (Server, error) {
+func NewConfigServer(setCb SetConfigCb, checkCb CheckConfigCb) (Server, error) {
 	s := &server{
-		Logger:      log.NewLogger("config-server"),
-		setConfigCb: cb,
+		Logger:        log.NewLogger("config-server"),
+		setConfigCb:   setCb,
+		checkConfigCb: checkCb,
 	}
 	return s, nil
 }
@@ -100,6 +106,16 @@ func (s *server) SetConfig(ctx context.Context, req *v1.SetConfigRequest) (*v1.S
 	s.Debug("REQUEST: %s", req)
 
 	reply := &v1.SetConfigReply{}
+
+	if req.ValidateOnly {
+		err := s.checkConfigCb(&RawConfig{NodeName: req.NodeName, Data: req.Config})
+		if err != nil {
+			reply.Error = fmt.Sprintf("invalid configuration: %v", err)
+			reply.Errors = configErrors(err)
+		}
+		return reply, nil
+	}
+
 	err := s.setConfigCb(&RawConfig{NodeName: req.NodeName, Data: req.Config})
 	if err != nil {
 		reply.Error = fmt.Sprintf("failed to apply configuration: %v", err)
@@ -108,6 +124,31 @@ func (s *server) SetConfig(ctx context.Context, req *v1.SetConfigRequest) (*v1.S
 	return reply, nil
 }
 
+// configErrors converts configuration validation errors to their gRPC representation.
+func configErrors(err error) []*v1.ConfigError {
+	var verrs pkgcfg.ValidationErrors
+
+	switch e := err.(type) {
+	case pkgcfg.ValidationErrors:
+		verrs = e
+	case *pkgcfg.ValidationError:
+		verrs = pkgcfg.ValidationErrors{e}
+	default:
+		verrs = pkgcfg.ValidationErrors{{Message: err.Error()}}
+	}
+
+	cfgErrors := make([]*v1.ConfigError, 0, len(verrs))
+	for _, e := range verrs {
+		cfgErrors = append(cfgErrors, &v1.ConfigError{
+			Module:  e.Module,
+			Field:   e.Field,
+			Message: e.Message,
+		})
+	}
+
+	return cfgErrors
+}
+
 func serverError(format string, args ...interface{}) error {
 	return fmt.Errorf(format, args...)
 }
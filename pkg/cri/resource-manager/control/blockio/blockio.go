@@ -15,8 +15,16 @@
 package blockio
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 
+	"golang.org/x/sys/unix"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
 	"github.com/intel/cri-resource-manager/pkg/config"
 	"github.com/intel/cri-resource-manager/pkg/cri/client"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
@@ -28,11 +36,25 @@ import (
 const (
 	// BlockIOController is the name of the block I/O controller.
 	BlockIOController = cache.BlockIO
+	// assignmentFile is the container data file we store assignments in.
+	assignmentFile = "blockio.json"
+	// tagUnenforced marks containers whose block I/O class has never been enforced.
+	tagUnenforced = "blockio-unenforced"
 )
 
 // blockio encapsulates the runtime state of our block I/O enforcment/controller.
 type blockio struct {
-	cache cache.Cache // resource manager cache
+	cache    cache.Cache            // resource manager cache
+	root     string                 // block I/O cgroup root directory
+	v2       bool                   // whether root is a cgroup v2 hierarchy
+	assigned map[string]*assignment // parameters applied to containers
+}
+
+// assignment records the block I/O parameters applied to a container.
+type assignment struct {
+	Class  string                    // block I/O class
+	Dir    string                    // container cgroup directory
+	Params cgroups.BlockIOParameters // applied parameters
 }
 
 // Our singleton block I/O controller instance.
@@ -44,14 +66,28 @@ var log logger.Logger = logger.NewLogger(BlockIOController)
 // getBlockIOController returns our singleton block I/O controller instance.
 func getBlockIOController() control.Controller {
 	if singleton == nil {
-		singleton = &blockio{}
+		singleton = &blockio{
+			assigned: make(map[string]*assignment),
+		}
 	}
 	return singleton
 }
 
 // Start initializes the controller for enforcing decisions.
 func (ctl *blockio) Start(cache cache.Cache, client client.Client) error {
+	root, v2, err := discoverCgroupRoot()
+	if err != nil {
+		return blockioError("failed to start: %v", err)
+	}
+
 	ctl.cache = cache
+	ctl.root = root
+	ctl.v2 = v2
+
+	log.Info("using block I/O cgroup hierarchy %s (cgroup v2: %v)", root, v2)
+
+	ctl.restoreAssignments()
+	ctl.enforceUpgraded()
 
 	return nil
 }
@@ -99,13 +135,18 @@ func (ctl *blockio) PostUpdateHook(c cache.Container) error {
 
 // PostStop is the block I/O controller post-stop hook.
 func (ctl *blockio) PostStopHook(c cache.Container) error {
-	return nil
+	return ctl.revert(c)
 }
 
 // assign assigns the container to the given block I/O class.
 func (ctl *blockio) assign(c cache.Container, class string) error {
 	if class == "" {
-		return nil
+		return ctl.revert(c)
+	}
+
+	params, err := classParameters(class)
+	if err != nil {
+		return blockioError("failed to assign %s to class %s: %v", c.PrettyName(), class, err)
 	}
 
 	pod, ok := c.GetPod()
@@ -113,20 +154,136 @@ func (ctl *blockio) assign(c cache.Container, class string) error {
 		return blockioError("failed to get Pod for %s", c.PrettyName())
 	}
 
-	pids, err := utils.GetProcessInContainer(pod.GetCgroupParentDir(), c.GetID())
-	if err != nil {
-		return blockioError("failed to get process list of %s: %v", c.PrettyName(), err)
+	dir := utils.FindContainerCgroupDir(ctl.root, pod.GetCgroupParentDir(), c.GetID())
+	if dir == "" {
+		return blockioError("failed to find block I/O cgroup directory of %s", c.PrettyName())
+	}
+
+	// undo settings of a previous class for devices the new class does not cover
+	if prev, ok := ctl.assigned[c.GetCacheID()]; ok && prev.Class != class {
+		if err := ctl.apply(prev.Dir, prev.Params.Reset(ctl.v2)); err != nil {
+			log.Warn("failed to reset block I/O class %s of %s: %v", prev.Class, c.PrettyName(), err)
+		}
 	}
 
-	for _, pid := range pids {
-		log.Info(" *** should assign pid %s to block I/O class %s...", pid, class)
+	if params.IsEmpty() {
+		log.Debug("block I/O class %s of %s has no device parameters", class, c.PrettyName())
+	} else if err := ctl.apply(dir, params); err != nil {
+		return blockioError("failed to assign %s to class %s: %v", c.PrettyName(), class, err)
 	}
 
+	ctl.assigned[c.GetCacheID()] = &assignment{Class: class, Dir: dir, Params: params}
+	ctl.saveAssignment(c)
+
 	log.Info("container %s assigned to class %s", c.PrettyName(), class)
 
 	return nil
 }
 
+// revert undoes the block I/O parameters applied to the container.
+func (ctl *blockio) revert(c cache.Container) error {
+	prev, ok := ctl.assigned[c.GetCacheID()]
+	if !ok {
+		return nil
+	}
+	delete(ctl.assigned, c.GetCacheID())
+	ctl.saveAssignment(c)
+
+	if _, err := os.Stat(prev.Dir); os.IsNotExist(err) {
+		return nil
+	}
+	if err := ctl.apply(prev.Dir, prev.Params.Reset(ctl.v2)); err != nil {
+		return blockioError("failed to reset block I/O class %s of %s: %v",
+			prev.Class, c.PrettyName(), err)
+	}
+
+	log.Info("container %s removed from class %s", c.PrettyName(), prev.Class)
+
+	return nil
+}
+
+// saveAssignment stores the assignment of the container in its cache data directory.
+func (ctl *blockio) saveAssignment(c cache.Container) {
+	a, ok := ctl.assigned[c.GetCacheID()]
+	if !ok {
+		dir := ctl.cache.ContainerDirectory(c.GetCacheID())
+		if dir != "" {
+			os.Remove(filepath.Join(dir, assignmentFile))
+		}
+		return
+	}
+
+	data, err := json.Marshal(a)
+	if err != nil {
+		log.Error("failed to marshal block I/O assignment of %s: %v", c.PrettyName(), err)
+		return
+	}
+	if err := ctl.cache.WriteFile(c.GetCacheID(), assignmentFile, 0644, data); err != nil {
+		log.Error("failed to save block I/O assignment of %s: %v", c.PrettyName(), err)
+	}
+}
+
+// restoreAssignments restores the assignments of all containers saved by saveAssignment.
+func (ctl *blockio) restoreAssignments() {
+	for _, c := range ctl.cache.GetContainers() {
+		dir := ctl.cache.ContainerDirectory(c.GetCacheID())
+		if dir == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(dir, assignmentFile))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Error("failed to read block I/O assignment of %s: %v", c.PrettyName(), err)
+			}
+			continue
+		}
+		a := &assignment{}
+		if err := json.Unmarshal(data, a); err != nil {
+			log.Error("failed to restore block I/O assignment of %s: %v", c.PrettyName(), err)
+			continue
+		}
+		ctl.assigned[c.GetCacheID()] = a
+		log.Info("restored assignment of %s to class %s", c.PrettyName(), a.Class)
+	}
+}
+
+// enforceUpgraded enforces the classes of running containers marked by upgradeAssignments.
+func (ctl *blockio) enforceUpgraded() {
+	for _, c := range ctl.cache.GetContainers() {
+		if _, ok := c.DeleteTag(tagUnenforced); !ok {
+			continue
+		}
+		if _, ok := ctl.assigned[c.GetCacheID()]; ok || c.GetState() != cache.ContainerStateRunning {
+			continue
+		}
+		if err := ctl.assign(c, ctl.BlockIOClass(c)); err != nil {
+			log.Error("failed to enforce block I/O class of upgraded %s: %v", c.PrettyName(), err)
+		}
+	}
+}
+
+// upgradeAssignments upgrades version 1 caches, saved by a controller which never applied
+// any block I/O parameters. It marks all containers for enforcing their class on startup.
+func upgradeAssignments(s *cache.RawSnapshot) error {
+	for _, c := range s.Containers {
+		tags, _ := c["Tags"].(map[string]interface{})
+		if tags == nil {
+			tags = make(map[string]interface{})
+			c["Tags"] = tags
+		}
+		tags[tagUnenforced] = "true"
+	}
+	return nil
+}
+
+// apply writes block I/O parameters to a cgroup directory.
+func (ctl *blockio) apply(dir string, params cgroups.BlockIOParameters) error {
+	if ctl.v2 {
+		return cgroups.SetIOParameters(dir, params)
+	}
+	return cgroups.SetBlkioParameters(dir, params)
+}
+
 // BlockIOClass determines the effective block I/O class for a container.
 func (ctl *blockio) BlockIOClass(c cache.Container) string {
 	cclass := c.GetBlockIOClass()
@@ -146,12 +303,214 @@ func (ctl *blockio) BlockIOClass(c cache.Container) string {
 	return bioclass
 }
 
+// checkClassDefinitions checks that all class definitions can be parsed.
+func (o *options) checkClassDefinitions() error {
+	for class, definition := range o.ClassDefinitions {
+		for _, dp := range definition {
+			if _, err := dp.parse(); err != nil {
+				return blockioError("invalid definition for class %s: %v", class, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configCheck is our runtime configuration check callback.
+func (ctl *blockio) configCheck(candidate interface{}, source config.Source) error {
+	return candidate.(*options).checkClassDefinitions()
+}
+
 // configNotify is our runtime configuration notification callback.
 func (ctl *blockio) configNotify(event config.Event, source config.Source) error {
-	log.Info("configuration updated")
+	log.Info("configuration %v", event)
+
+	if err := opt.checkClassDefinitions(); err != nil {
+		return err
+	}
+
+	if ctl.cache == nil {
+		return nil
+	}
+
+	for _, c := range ctl.cache.GetContainers() {
+		if c.GetState() != cache.ContainerStateRunning {
+			continue
+		}
+		if err := ctl.assign(c, ctl.BlockIOClass(c)); err != nil {
+			log.Error("%v", err)
+		}
+	}
+
 	return nil
 }
 
+// indices of throttling settings in deviceSettings
+const (
+	readBps = iota
+	writeBps
+	readIOPS
+	writeIOPS
+	throttleCount
+)
+
+// deviceSettings are the parsed settings of a DeviceParameters entry, 0 if unset.
+type deviceSettings struct {
+	weight   int64
+	throttle [throttleCount]int64
+}
+
+// parse parses and validates the settings of a DeviceParameters entry.
+func (dp *DeviceParameters) parse() (*deviceSettings, error) {
+	ds := &deviceSettings{}
+
+	if dp.Weight < 0 {
+		return nil, fmt.Errorf("invalid negative weight %d", dp.Weight)
+	}
+	ds.weight = dp.Weight
+
+	for idx, v := range [throttleCount]struct {
+		name  string
+		value string
+	}{
+		readBps:   {"ThrottleReadBps", dp.ThrottleReadBps},
+		writeBps:  {"ThrottleWriteBps", dp.ThrottleWriteBps},
+		readIOPS:  {"ThrottleReadIOPS", dp.ThrottleReadIOPS},
+		writeIOPS: {"ThrottleWriteIOPS", dp.ThrottleWriteIOPS},
+	} {
+		if v.value == "" {
+			continue
+		}
+		qty, err := resource.ParseQuantity(v.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", v.name, v.value, err)
+		}
+		if qty.Value() <= 0 {
+			return nil, fmt.Errorf("invalid %s %q: must be positive", v.name, v.value)
+		}
+		ds.throttle[idx] = qty.Value()
+	}
+
+	for _, glob := range dp.Devices {
+		if _, err := filepath.Match(glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid device pattern %q: %v", glob, err)
+		}
+	}
+
+	return ds, nil
+}
+
+// classParameters resolves the definition of a block I/O class to cgroup parameters.
+func classParameters(class string) (cgroups.BlockIOParameters, error) {
+	params := cgroups.NewBlockIOParameters()
+	definition, ok := opt.ClassDefinitions[class]
+	if !ok {
+		return params, nil
+	}
+
+	// later entries override earlier ones for devices matched by both
+	devices := [][2]int64{}
+	settings := map[[2]int64]*deviceSettings{}
+	for _, dp := range definition {
+		ds, err := dp.parse()
+		if err != nil {
+			return params, err
+		}
+		if len(dp.Devices) == 0 {
+			if ds.weight > 0 {
+				params.Weight = ds.weight
+			}
+			continue
+		}
+		for _, glob := range dp.Devices {
+			for _, dev := range resolveDevices(glob) {
+				cur, ok := settings[dev]
+				if !ok {
+					cur = &deviceSettings{}
+					settings[dev] = cur
+					devices = append(devices, dev)
+				}
+				if ds.weight > 0 {
+					cur.weight = ds.weight
+				}
+				for idx, value := range ds.throttle {
+					if value > 0 {
+						cur.throttle[idx] = value
+					}
+				}
+			}
+		}
+	}
+
+	for _, dev := range devices {
+		ds := settings[dev]
+		if ds.weight > 0 {
+			params.WeightDevice = append(params.WeightDevice,
+				cgroups.DeviceWeight{Major: dev[0], Minor: dev[1], Weight: ds.weight})
+		}
+		for idx, rates := range [throttleCount]*[]cgroups.DeviceRate{
+			readBps:   &params.ThrottleReadBpsDevice,
+			writeBps:  &params.ThrottleWriteBpsDevice,
+			readIOPS:  &params.ThrottleReadIOPSDevice,
+			writeIOPS: &params.ThrottleWriteIOPSDevice,
+		} {
+			if ds.throttle[idx] > 0 {
+				*rates = append(*rates,
+					cgroups.DeviceRate{Major: dev[0], Minor: dev[1], Rate: ds.throttle[idx]})
+			}
+		}
+	}
+
+	return params, nil
+}
+
+// resolveDevices returns the major and minor numbers of block devices matching a glob.
+func resolveDevices(glob string) [][2]int64 {
+	paths, err := filepath.Glob(glob)
+	if err != nil {
+		log.Error("invalid device pattern %q: %v", glob, err)
+		return nil
+	}
+
+	devices := [][2]int64{}
+	for _, path := range paths {
+		st := unix.Stat_t{}
+		if err := unix.Stat(path, &st); err != nil {
+			log.Warn("failed to stat device %s: %v", path, err)
+			continue
+		}
+		if st.Mode&unix.S_IFMT != unix.S_IFBLK {
+			log.Debug("ignoring %s, not a block device", path)
+			continue
+		}
+		major, minor := int64(unix.Major(uint64(st.Rdev))), int64(unix.Minor(uint64(st.Rdev)))
+		// block I/O throttling and weights can't be applied to partitions
+		partition := fmt.Sprintf("/sys/dev/block/%d:%d/partition", major, minor)
+		if _, err := os.Stat(partition); err == nil {
+			log.Debug("ignoring %s, a partition", path)
+			continue
+		}
+		devices = append(devices, [2]int64{major, minor})
+	}
+
+	return devices
+}
+
+// discoverCgroupRoot discovers the cgroup hierarchy used for block I/O control.
+func discoverCgroupRoot() (string, bool, error) {
+	if cgroups.GetMode() != cgroups.Unified {
+		dir := cgroups.ControllerDir("blkio")
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, false, nil
+		}
+	}
+	for _, controller := range cgroups.UnifiedControllers() {
+		if controller == "io" {
+			return cgroups.UnifiedDir(), true, nil
+		}
+	}
+	return "", false, fmt.Errorf("no cgroup v1 blkio or v2 io controller found")
+}
+
 // blockioError creates an block I/O-controller-specific formatted error message.
 func blockioError(format string, args ...interface{}) error {
 	return fmt.Errorf("block I/O: "+format, args...)
@@ -160,4 +519,7 @@ func blockioError(format string, args ...interface{}) error {
 // Register us as a controller.
 func init() {
 	control.Register(BlockIOController, "Block I/O controller", getBlockIOController())
+	if err := cache.RegisterMigration("1", "2", upgradeAssignments); err != nil {
+		log.Error("failed to register cache migration: %v", err)
+	}
 }
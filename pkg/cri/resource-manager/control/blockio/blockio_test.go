@@ -0,0 +1,267 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockio
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+func TestParseDeviceParameters(t *testing.T) {
+	tcases := []struct {
+		name     string
+		dp       DeviceParameters
+		expected *deviceSettings
+	}{
+		{
+			name:     "weight only",
+			dp:       DeviceParameters{Weight: 200},
+			expected: &deviceSettings{weight: 200},
+		},
+		{
+			name: "throttling with units",
+			dp: DeviceParameters{
+				Devices:           []string{"/dev/nvme*"},
+				ThrottleReadBps:   "100M",
+				ThrottleWriteBps:  "1Ki",
+				ThrottleReadIOPS:  "2k",
+				ThrottleWriteIOPS: "500",
+			},
+			expected: &deviceSettings{
+				throttle: [throttleCount]int64{100000000, 1024, 2000, 500},
+			},
+		},
+		{
+			name: "negative weight",
+			dp:   DeviceParameters{Weight: -1},
+		},
+		{
+			name: "unparsable rate",
+			dp:   DeviceParameters{ThrottleReadBps: "fast"},
+		},
+		{
+			name: "zero rate",
+			dp:   DeviceParameters{ThrottleWriteIOPS: "0"},
+		},
+		{
+			name: "invalid device glob",
+			dp:   DeviceParameters{Devices: []string{"/dev/sd[a"}},
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds, err := tc.dp.parse()
+			switch {
+			case tc.expected == nil && err == nil:
+				t.Errorf("expected error, got %+v", *ds)
+			case tc.expected != nil && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.expected != nil && *ds != *tc.expected:
+				t.Errorf("expected %+v, got %+v", *tc.expected, *ds)
+			}
+		})
+	}
+}
+
+// createTestContainer creates a cache in dir with a container in it, and a block I/O
+// cgroup hierarchy for the container. It returns the cache, the container, the cgroup
+// root and a function to read the block I/O weight of the container.
+func createTestContainer(t *testing.T, dir string) (cache.Cache, cache.Container, string, func() string) {
+	cch, err := cache.NewCache(cache.Options{CacheDir: filepath.Join(dir, "cache")})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	podCfg := &cri.PodSandboxConfig{
+		Metadata: &cri.PodSandboxMetadata{Name: "pod", Uid: "pod-uid", Namespace: "default"},
+		Linux:    &cri.LinuxPodSandboxConfig{CgroupParent: "/kubepods/pod-uid"},
+	}
+	cch.InsertPod("pod-id", &cri.RunPodSandboxRequest{Config: podCfg})
+	c, err := cch.InsertContainer(&cri.CreateContainerRequest{
+		PodSandboxId: "pod-id",
+		Config: &cri.ContainerConfig{
+			Metadata: &cri.ContainerMetadata{Name: "ctr"},
+			Linux:    &cri.LinuxContainerConfig{Resources: &cri.LinuxContainerResources{}},
+		},
+		SandboxConfig: podCfg,
+	})
+	if err != nil {
+		t.Fatalf("failed to create container: %v", err)
+	}
+	if _, err := cch.UpdateContainerID(c.GetCacheID(),
+		&cri.CreateContainerResponse{ContainerId: "ctr-id"}); err != nil {
+		t.Fatalf("failed to update container ID: %v", err)
+	}
+
+	root := filepath.Join(dir, "blkio")
+	weight := filepath.Join(root, "kubepods", "pod-uid", "ctr-id", "blkio.weight")
+	if err := os.MkdirAll(filepath.Dir(weight), 0755); err != nil {
+		t.Fatalf("failed to create cgroup directory: %v", err)
+	}
+	if err := ioutil.WriteFile(weight, []byte("500"), 0644); err != nil {
+		t.Fatalf("failed to create cgroup entry: %v", err)
+	}
+	readWeight := func() string {
+		data, err := ioutil.ReadFile(weight)
+		if err != nil {
+			t.Fatalf("failed to read cgroup entry: %v", err)
+		}
+		return strings.TrimSpace(string(data))
+	}
+
+	return cch, c, root, readWeight
+}
+
+func TestAssignmentPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blockio-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cch, c, root, readWeight := createTestContainer(t, dir)
+
+	saved := opt.ClassDefinitions
+	opt.ClassDefinitions = map[string][]*DeviceParameters{"gold": {{Weight: 800}}}
+	defer func() { opt.ClassDefinitions = saved }()
+
+	ctl := &blockio{cache: cch, root: root, assigned: make(map[string]*assignment)}
+	if err := ctl.assign(c, "gold"); err != nil {
+		t.Fatalf("failed to assign container: %v", err)
+	}
+	if w := readWeight(); w != "800" {
+		t.Errorf("expected weight 800 after assignment, got %s", w)
+	}
+
+	// a restarted controller must know and be able to revert the assignment
+	restarted := &blockio{cache: cch, root: root, assigned: make(map[string]*assignment)}
+	restarted.restoreAssignments()
+	if a, ok := restarted.assigned[c.GetCacheID()]; !ok || a.Class != "gold" {
+		t.Fatalf("assignment not restored, got %+v", restarted.assigned)
+	}
+	if err := restarted.revert(c); err != nil {
+		t.Fatalf("failed to revert assignment: %v", err)
+	}
+	if w := readWeight(); w != "500" {
+		t.Errorf("expected default weight 500 after revert, got %s", w)
+	}
+
+	restarted = &blockio{cache: cch, root: root, assigned: make(map[string]*assignment)}
+	restarted.restoreAssignments()
+	if len(restarted.assigned) != 0 {
+		t.Errorf("reverted assignment restored, got %+v", restarted.assigned)
+	}
+}
+
+func TestUpgradeAssignments(t *testing.T) {
+	dir, err := ioutil.TempDir("", "blockio-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	cch, c, root, readWeight := createTestContainer(t, dir)
+	c.UpdateState(cache.ContainerStateRunning)
+	c.SetBlockIOClass("gold")
+	if err := cch.Save(); err != nil {
+		t.Fatalf("failed to save cache: %v", err)
+	}
+
+	// turn the saved cache into a version 1 one and reload it
+	path := filepath.Join(dir, "cache", "cache")
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read cache: %v", err)
+	}
+	snapshot := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("failed to unmarshal cache: %v", err)
+	}
+	snapshot["Version"] = json.RawMessage(`"1"`)
+	if data, err = json.Marshal(snapshot); err != nil {
+		t.Fatalf("failed to marshal cache: %v", err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write cache: %v", err)
+	}
+	upgraded, err := cache.NewCache(cache.Options{CacheDir: filepath.Join(dir, "cache")})
+	if err != nil {
+		t.Fatalf("failed to load version 1 cache: %v", err)
+	}
+	if c, _ = upgraded.LookupContainer(c.GetCacheID()); c == nil {
+		t.Fatalf("container lost in upgrade")
+	}
+
+	saved := opt.ClassDefinitions
+	opt.ClassDefinitions = map[string][]*DeviceParameters{"gold": {{Weight: 800}}}
+	defer func() { opt.ClassDefinitions = saved }()
+
+	// a controller started on an upgraded cache enforces classes never enforced before
+	ctl := &blockio{cache: upgraded, root: root, assigned: make(map[string]*assignment)}
+	ctl.restoreAssignments()
+	ctl.enforceUpgraded()
+	if w := readWeight(); w != "800" {
+		t.Errorf("expected weight 800 after upgrade, got %s", w)
+	}
+	if _, ok := c.GetTag(tagUnenforced); ok {
+		t.Errorf("container still marked unenforced after upgrade")
+	}
+
+	// but only once
+	if err := ioutil.WriteFile(filepath.Join(root, "kubepods", "pod-uid", "ctr-id", "blkio.weight"),
+		[]byte("500"), 0644); err != nil {
+		t.Fatalf("failed to reset cgroup entry: %v", err)
+	}
+	restarted := &blockio{cache: upgraded, root: root, assigned: make(map[string]*assignment)}
+	restarted.restoreAssignments()
+	restarted.enforceUpgraded()
+	if w := readWeight(); w != "500" {
+		t.Errorf("expected class enforced only once, got weight %s", w)
+	}
+}
+
+func TestClassParameters(t *testing.T) {
+	saved := opt.ClassDefinitions
+	defer func() { opt.ClassDefinitions = saved }()
+
+	opt.ClassDefinitions = map[string][]*DeviceParameters{
+		"weighted": {{Weight: 300}, {Weight: 400}},
+		"invalid":  {{ThrottleReadBps: "fast"}},
+	}
+
+	params, err := classParameters("weighted")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if params.Weight != 400 {
+		t.Errorf("expected later entry to override weight to 400, got %d", params.Weight)
+	}
+
+	params, err = classParameters("undefined")
+	if err != nil || !params.IsEmpty() {
+		t.Errorf("expected empty parameters for undefined class, got %+v (%v)", params, err)
+	}
+
+	if _, err := classParameters("invalid"); err == nil {
+		t.Errorf("expected error for invalid class definition")
+	}
+}
@@ -18,21 +18,48 @@ var configHelp = `
 Resource Manager Block I/O enforcement controller.
 
 The Block I/O controller enforces container policy block I/O decisions
-using the blkio cgroup pseudo-filesystem. It takes the assigned block I/O
-class of a container and puts all processes in the containers' cgroup to
-that class. If the container is not assigned to any block I/O class by a
-policy, the block I/O controller will use the QOS class of the container.
+using the blkio (cgroup v1) or io (cgroup v2) cgroup controller. It takes
+the assigned block I/O class of a container and applies the parameters of
+that class to the container's cgroup when the container is started or
+updated. The parameters are reverted when the container is stopped. The
+applied parameters are saved with the container in the cache, so they are
+reverted correctly across restarts of the resource manager. If the
+container is not assigned to any block I/O class by a policy, the block I/O
+controller will use the QOS class of the container.
 
 The controller can be configured to map the containers' assigned class to
-a real block I/O class before the blkio-level assignment takes place.
+a real block I/O class before the cgroup-level assignment takes place.
+
+Block I/O classes are defined as a list of device parameters. Each entry
+sets a proportional weight and/or read/write bandwidth (bytes per second)
+and operation (IOPS) throttling limits for the devices matching any of the
+given device paths or globs. Throttling values accept unit suffixes, like
+100M or 1Gi. If several entries match the same device, the later entries
+override the earlier ones. An entry without devices sets the default weight
+of the class.
 
 Here is a sample configuration fragment to set up a class mapping for the
-3 Kubernetes QoS classes and define a default class.
+3 Kubernetes QoS classes, define a default class and the parameters of the
+classes.
 
   blockio:
     Classes:
-      Guaranteed: class1
-      Burstable: class2
-      BestEffort: class3
-      "*": class4
+      Guaranteed: HighPrio
+      Burstable: Normal
+      BestEffort: Throttled
+      "*": Normal
+    ClassDefinitions:
+      HighPrio:
+        - Weight: 800
+      Normal:
+        - Weight: 400
+      Throttled:
+        - Weight: 100
+        - Devices:
+            - /dev/sd*
+            - /dev/nvme*
+          ThrottleReadBps: 50M
+          ThrottleWriteBps: 20M
+          ThrottleReadIOPS: 1k
+          ThrottleWriteIOPS: 500
 `
@@ -20,8 +20,26 @@ import (
 
 // options captures our configurable parameters.
 type options struct {
-	// Class is a assigned to actual RDT class map.
+	// Class is a assigned to actual block I/O class map.
 	Classes map[string]string `json:",omitempty"`
+	// ClassDefinitions defines the parameters of each block I/O class.
+	ClassDefinitions map[string][]*DeviceParameters `json:",omitempty"`
+}
+
+// DeviceParameters defines block I/O parameters for a set of devices.
+type DeviceParameters struct {
+	// Devices is a list of device paths or globs, for instance /dev/nvme*.
+	Devices []string `json:",omitempty"`
+	// Weight is the proportional block I/O weight for the devices.
+	Weight int64 `json:",omitempty"`
+	// ThrottleReadBps limits reading in bytes per second, for instance 100M.
+	ThrottleReadBps string `json:",omitempty"`
+	// ThrottleWriteBps limits writing in bytes per second.
+	ThrottleWriteBps string `json:",omitempty"`
+	// ThrottleReadIOPS limits read operations per second.
+	ThrottleReadIOPS string `json:",omitempty"`
+	// ThrottleWriteIOPS limits write operations per second.
+	ThrottleWriteIOPS string `json:",omitempty"`
 }
 
 // Our runtime configuration.
@@ -29,11 +47,15 @@ var opt = defaultOptions().(*options)
 
 // defaultOptions returns a new options instance, all initialized to defaults.
 func defaultOptions() interface{} {
-	return &options{Classes: make(map[string]string)}
+	return &options{
+		Classes:          make(map[string]string),
+		ClassDefinitions: make(map[string][]*DeviceParameters),
+	}
 }
 
 // Register us for configuration handling.
 func init() {
+	ctl := getBlockIOController().(*blockio)
 	config.Register("resource-manager.blockio", configHelp, opt, defaultOptions,
-		config.WithNotify(getBlockIOController().(*blockio).configNotify))
+		config.WithNotify(ctl.configNotify), config.WithCheck(ctl.configCheck))
 }
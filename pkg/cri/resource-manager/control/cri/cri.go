@@ -122,6 +122,9 @@ func (ctl *crictl) PostUpdateHook(c cache.Container) error {
 		update = &criapi.UpdateContainerResourcesRequest{
 			ContainerId: c.GetID(),
 		}
+		if err := c.SetCRIRequest(update); err != nil {
+			return criError("post-update hook: %v", err)
+		}
 	} else {
 		if update, ok = request.(*criapi.UpdateContainerResourcesRequest); !ok {
 			return criError("post-update hook: update request of wrong type (%T)", request)
@@ -56,7 +56,7 @@ func (o *options) ControllerMode(name string) mode {
 
 // configNotify is our configuration update notification callback.
 func (o *options) configNotify(event config.Event, source config.Source) error {
-	log.Info("configuration updated")
+	log.Info("configuration %v", event)
 	for name, controller := range controllers {
 		controller.mode = o.ControllerMode(name)
 	}
@@ -84,6 +84,11 @@ func (m mode) MarshalJSON() ([]byte, error) {
 	return json.Marshal(m.String())
 }
 
+// ConfigSchema describes mode for config schema generation.
+func (m *mode) ConfigSchema() *config.Schema {
+	return config.EnumSchema("Controller mode.", "disabled", "required", "optional", "relaxed")
+}
+
 // UnmarshalJSON is the JSON unmarshaller for mode.
 func (m *mode) UnmarshalJSON(raw []byte) error {
 	var str string
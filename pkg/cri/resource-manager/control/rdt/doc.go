@@ -36,4 +36,16 @@ mappings for the 3 Kubernetes QoS classes and defines also a default class.
       Burstable: ModerateRDT
       BestEffort: RestrictedRDT
       "*": RestrictedRDT
+
+Policies can also assign containers L3 cache and memory bandwidth budgets.
+These are enforced using dynamic classes, which are created on demand and
+named after the budget, for instance dynamic-l3-50-mb-70. Containers with
+identical budgets share the same dynamic class. Dynamic classes are never
+mapped and they are removed once no container uses them.
+
+If the system supports RDT monitoring, the controller also puts the processes
+of each container into a monitoring group of its own, named after the ID of
+the container, under the class of the container. The L3 cache occupancy and
+memory bandwidth of these groups is exported as metrics. Monitoring can be
+disabled by setting Monitoring to false.
 `
@@ -27,6 +27,8 @@ type options struct {
 	ResctrlPath string
 	// Class is a assigned to actual RDT class map.
 	Classes map[string]string
+	// Monitoring enables per-container RDT monitoring groups.
+	Monitoring bool
 }
 
 // Our runtime configuration.
@@ -47,6 +49,7 @@ func defaultOptions() interface{} {
 	return &options{
 		ResctrlPath: resctrlPath(),
 		Classes:     make(map[string]string),
+		Monitoring:  true,
 	}
 }
 
@@ -17,11 +17,14 @@ package rdt
 import (
 	"fmt"
 
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
 	"github.com/intel/cri-resource-manager/pkg/cri/client"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/control"
+	"github.com/intel/cri-resource-manager/pkg/instrumentation"
 	"github.com/intel/cri-resource-manager/pkg/rdt"
-	"github.com/intel/cri-resource-manager/pkg/utils"
 
 	"github.com/intel/cri-resource-manager/pkg/config"
 	logger "github.com/intel/cri-resource-manager/pkg/log"
@@ -66,6 +69,11 @@ func (ctl *rdtctl) Start(cache cache.Cache, client client.Client) error {
 	ctl.rdt = &rdtc
 	ctl.cache = cache
 
+	if rdt.MonSupported() {
+		ctl.exportMonitoring()
+		ctl.monitorRunning()
+	}
+
 	return nil
 }
 
@@ -112,6 +120,14 @@ func (ctl *rdtctl) PostUpdateHook(c cache.Container) error {
 
 // PostStop is the RDT controller post-stop hook.
 func (ctl *rdtctl) PostStopHook(c cache.Container) error {
+	if err := (*ctl.rdt).DeleteMonGroup(c.GetID()); err != nil {
+		log.Warn("failed to remove monitoring group of container %s: %v", c.PrettyName(), err)
+	}
+	if rdt.IsDynamicClass(c.GetRDTClass()) {
+		if err := (*ctl.rdt).PruneBudgetClasses(); err != nil {
+			log.Warn("failed to prune unused dynamic classes: %v", err)
+		}
+	}
 	return nil
 }
 
@@ -121,12 +137,19 @@ func (ctl *rdtctl) assign(c cache.Container, class string) error {
 		return nil
 	}
 
+	if budget, ok := rdt.ParseDynamicClass(class); ok {
+		if _, err := (*ctl.rdt).SetBudgetClass(budget); err != nil {
+			return rdtError("failed to set up class %s for container %s: %v",
+				class, c.PrettyName(), err)
+		}
+	}
+
 	pod, ok := c.GetPod()
 	if !ok {
 		return rdtError("failed to get pod of container %s", c.PrettyName())
 	}
 
-	pids, err := utils.GetProcessInContainer(pod.GetCgroupParentDir(), c.GetID())
+	pids, err := cgroups.GetContainerTasks(pod.GetCgroupParentDir(), c.GetID())
 	if err != nil {
 		return rdtError("failed to get process list for container %s: %v", c.PrettyName(), err)
 	}
@@ -137,12 +160,85 @@ func (ctl *rdtctl) assign(c cache.Container, class string) error {
 
 	log.Info("container %s assigned to class %s", c.PrettyName(), class)
 
+	if opt.Monitoring && rdt.MonSupported() {
+		ctl.monitor(c, class, pids...)
+	}
+
 	return nil
 }
 
+// exportMonitoring registers the RDT monitoring collector for exporting metrics.
+func (ctl *rdtctl) exportMonitoring() {
+	collector, err := rdt.NewCollector()
+	if err != nil {
+		log.Error("failed to create RDT monitoring collector: %v", err)
+		return
+	}
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(collector); err != nil {
+		log.Error("failed to register RDT monitoring collector: %v", err)
+		return
+	}
+	instrumentation.RegisterGatherer(reg)
+}
+
+// monitorRunning sets up RDT monitoring groups for all running containers,
+// removing any other (stale) monitoring groups.
+func (ctl *rdtctl) monitorRunning() {
+	active := []string{}
+	if opt.Monitoring {
+		for _, c := range ctl.cache.GetContainers() {
+			if c.GetState() != cache.ContainerStateRunning {
+				continue
+			}
+			if class := ctl.RDTClass(c); class != "" && ctl.monitor(c, class) {
+				active = append(active, c.GetID())
+			}
+		}
+	}
+
+	if err := (*ctl.rdt).PruneMonGroups(active...); err != nil {
+		log.Warn("failed to remove stale monitoring groups: %v", err)
+	}
+}
+
+// monitor sets up the RDT monitoring group of the container.
+func (ctl *rdtctl) monitor(c cache.Container, class string, pids ...string) bool {
+	pod, ok := c.GetPod()
+	if !ok {
+		log.Warn("failed to get pod of container %s for monitoring", c.PrettyName())
+		return false
+	}
+
+	if len(pids) == 0 {
+		var err error
+		pids, err = cgroups.GetContainerTasks(pod.GetCgroupParentDir(), c.GetID())
+		if err != nil {
+			log.Warn("failed to get process list for container %s: %v", c.PrettyName(), err)
+			return false
+		}
+	}
+
+	labels := map[string]string{
+		"namespace": pod.GetNamespace(),
+		"pod":       pod.GetName(),
+		"container": c.GetName(),
+	}
+	if err := (*ctl.rdt).SetMonGroup(class, c.GetID(), labels, pids...); err != nil {
+		log.Warn("failed to set up monitoring for container %s: %v", c.PrettyName(), err)
+		return false
+	}
+
+	return true
+}
+
 // RDTClass determines the effective RDT class for a container.
 func (ctl *rdtctl) RDTClass(c cache.Container) string {
 	cclass := c.GetRDTClass()
+	if rdt.IsDynamicClass(cclass) {
+		log.Debug("RDT class for %s: dynamic %q", c.PrettyName(), cclass)
+		return cclass
+	}
 	if cclass == "" {
 		cclass = string(c.GetQOSClass())
 	}
@@ -160,7 +256,7 @@ func (ctl *rdtctl) RDTClass(c cache.Container) string {
 
 // configNotify is our runtime configuration notification callback.
 func (ctl *rdtctl) configNotify(event config.Event, source config.Source) error {
-	log.Info("configuration updated")
+	log.Info("configuration %v", event)
 	return nil
 }
 
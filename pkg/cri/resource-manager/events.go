@@ -15,10 +15,12 @@
 package resmgr
 
 import (
+	"context"
 	"time"
 
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/metrics"
+	"github.com/intel/cri-resource-manager/pkg/instrumentation"
 	logger "github.com/intel/cri-resource-manager/pkg/log"
 )
 
@@ -32,12 +34,17 @@ func (m *resmgr) setupEventProcessing() error {
 	m.events = make(chan interface{}, 8)
 	m.stop = make(chan interface{})
 	options := metrics.Options{
-		PollInterval: opt.MetricsTimer,
-		Events:       m.events,
+		PollInterval:        opt.MetricsTimer,
+		Events:              m.events,
+		AvxThreshold:        opt.AvxThreshold,
+		AvxReleaseThreshold: opt.AvxRelease,
+		AvxSmoothing:        opt.AvxSmoothing,
+		ContainerLabels:     m.containerLabels,
 	}
 	if m.metrics, err = metrics.NewMetrics(options); err != nil {
 		return resmgrError("failed to create metrics (pre)processor: %v", err)
 	}
+	instrumentation.RegisterGatherer(m.metrics)
 
 	return nil
 }
@@ -51,6 +58,12 @@ func (m *resmgr) startEventProcessing() error {
 	stop := m.stop
 	go func() {
 		rebalanceTimer := time.NewTicker(opt.RebalanceTimer)
+		var topologyTimer <-chan time.Time
+		if opt.TopologyTimer > 0 {
+			ticker := time.NewTicker(opt.TopologyTimer)
+			defer ticker.Stop()
+			topologyTimer = ticker.C
+		}
 		for {
 			select {
 			case _ = <-stop:
@@ -61,6 +74,10 @@ func (m *resmgr) startEventProcessing() error {
 				if err := m.RebalanceContainers(); err != nil {
 					evtlog.Error("rebalancing failed: %v", err)
 				}
+			case _ = <-topologyTimer:
+				if err := m.publishTopologyZones(); err != nil {
+					evtlog.Error("%v", err)
+				}
 			}
 		}
 	}()
@@ -110,30 +127,70 @@ func (m *resmgr) processAvx(e *metrics.AvxEvent) bool {
 		return false
 	}
 
-	changes := false
+	changed := []cache.Container{}
 	for cgroup, active := range e.Updates {
-		c, ok := m.resolveCgroupPath(cgroup)
+		c, ok := m.cache.LookupContainerByCgroup(cgroup)
 		if !ok {
 			continue
 		}
-		// XXX This is just for testing, we should effectively drive state transitions
-		//     through a low-pass filter.
 		if active {
 			if _, wasTagged := c.SetTag(cache.TagAVX512, "true"); !wasTagged {
 				evtlog.Info("container %s STARTED using AVX512 instructions", c.PrettyName())
+				changed = append(changed, c)
 			}
 		} else {
 			if _, wasTagged := c.DeleteTag(cache.TagAVX512); wasTagged {
 				evtlog.Info("container %s STOPPED using AVX512 instructions", c.PrettyName())
+				changed = append(changed, c)
 			}
 		}
 	}
-	return changes
+
+	if len(changed) == 0 {
+		return false
+	}
+
+	method := "AVX512"
+	for _, c := range changed {
+		if c.GetState() != cache.ContainerStateRunning {
+			continue
+		}
+		if _, err := m.policy.ReallocateResources(c); err != nil {
+			evtlog.Error("%s: failed to reallocate %s: %v", method, c.PrettyName(), err)
+		}
+	}
+
+	if err := m.runPostUpdateHooks(context.Background(), method); err != nil {
+		evtlog.Error("%s: failed to run post-update hooks: %v", method, err)
+	}
+
+	m.cache.Save()
+
+	return true
 }
 
-// resolveCgroupPath resolves a cgroup path to a container.
-func (m *resmgr) resolveCgroupPath(path string) (cache.Container, bool) {
+// containerLabels resolves a cgroup path to metrics labels of the container.
+func (m *resmgr) containerLabels(path string) (map[string]string, bool) {
 	m.Lock()
 	defer m.Unlock()
-	return m.cache.LookupContainerByCgroup(path)
+
+	c, ok := m.cache.LookupContainerByCgroup(path)
+	if !ok {
+		return nil, false
+	}
+
+	pod := ""
+	if p, ok := c.GetPod(); ok {
+		pod = p.GetName()
+	}
+	pool, _ := c.GetTag(cache.TagPool)
+
+	return map[string]string{
+		"namespace": c.GetNamespace(),
+		"pod":       pod,
+		"container": c.GetName(),
+		"qos_class": string(c.GetQOSClass()),
+		"pool":      pool,
+		"cpuset":    c.GetCpusetCpus(),
+	}, true
 }
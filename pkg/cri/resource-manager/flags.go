@@ -18,6 +18,7 @@ import (
 	"flag"
 	"time"
 
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/metrics"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/sockets"
 )
 
@@ -34,6 +35,10 @@ type options struct {
 	ForceConfig    string
 	MetricsTimer   time.Duration
 	RebalanceTimer time.Duration
+	TopologyTimer  time.Duration
+	AvxThreshold   float64
+	AvxRelease     float64
+	AvxSmoothing   float64
 }
 
 // Relay command line options.
@@ -63,4 +68,12 @@ func init() {
 		"Interval for polling/gathering runtime metrics data. Use 'disable' for disabling.")
 	flag.DurationVar(&opt.RebalanceTimer, "rebalance-interval", 5*time.Minute,
 		"Minimum interval between two container rebalancing attempts. Use 'disable' for disabling.")
+	flag.DurationVar(&opt.TopologyTimer, "topology-export-interval", 1*time.Minute,
+		"Interval for publishing per-zone free resources as a node annotation. Use 0 for disabling.")
+	flag.Float64Var(&opt.AvxThreshold, "avx512-threshold", metrics.DefaultAvxThreshold,
+		"Average AVX512 usage (0 - 1) above which a container is considered an AVX512 user.")
+	flag.Float64Var(&opt.AvxRelease, "avx512-release-threshold", metrics.DefaultAvxReleaseThreshold,
+		"Average AVX512 usage (0 - 1) below which a container stops being an AVX512 user.")
+	flag.Float64Var(&opt.AvxSmoothing, "avx512-smoothing", metrics.DefaultAvxSmoothing,
+		"Weight (0 - 1) of the latest sample in the average AVX512 usage of a container.")
 }
@@ -0,0 +1,80 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resmgr
+
+import (
+	pkgcfg "github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/introspect"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	"github.com/intel/cri-resource-manager/pkg/instrumentation"
+)
+
+const (
+	// IntrospectPath is the HTTP path introspection data is served at.
+	IntrospectPath = "/introspect"
+)
+
+// Our introspection HTTP handler, registered once on the instrumentation mux.
+var introspectHandler *introspect.Handler
+
+// setupIntrospection sets up serving our state on the instrumentation HTTP mux.
+func (m *resmgr) setupIntrospection() {
+	if introspectHandler == nil {
+		introspectHandler = introspect.NewHandler()
+		instrumentation.GetHTTPMux().Handle(IntrospectPath, introspectHandler)
+	}
+	introspectHandler.Set(m.introspect)
+}
+
+// introspect collects the current state of the resource manager.
+func (m *resmgr) introspect() (*introspect.State, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	state := &introspect.State{
+		Policy:     policy.ActivePolicy(),
+		Containers: make(map[string]*introspect.Container),
+	}
+
+	if m.policy != nil {
+		m.policy.Introspect(state)
+	}
+
+	for _, c := range m.cache.GetContainers() {
+		ic := &introspect.Container{
+			ID:           c.GetID(),
+			Name:         c.GetName(),
+			Namespace:    c.GetNamespace(),
+			CpusetCpus:   c.GetCpusetCpus(),
+			CpusetMems:   c.GetCpusetMems(),
+			RDTClass:     c.GetRDTClass(),
+			BlockIOClass: c.GetBlockIOClass(),
+			Tags:         c.GetTags(),
+			Pending:      c.GetPending(),
+		}
+		if pod, ok := c.GetPod(); ok {
+			ic.Pod = pod.GetName()
+		}
+		state.Containers[c.GetCacheID()] = ic
+	}
+
+	cfg, err := pkgcfg.GetConfig()
+	if err != nil {
+		return nil, resmgrError("failed to get active configuration: %v", err)
+	}
+	state.Config = cfg
+
+	return state, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package introspect
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+)
+
+// State is the introspected state of the resource manager.
+type State struct {
+	// Policy is the name of the active policy.
+	Policy string `json:"policy"`
+	// Backends describes the state of the active policy backends, one per partition.
+	Backends []*Backend `json:"backends,omitempty"`
+	// Containers describes the resource assignments of all containers, by cache ID.
+	Containers map[string]*Container `json:"containers"`
+	// Config is the active configuration.
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// Backend is the introspected state of a policy backend.
+type Backend struct {
+	// Name is the name of the backend.
+	Name string `json:"name"`
+	// Partition is the name of the partition the backend manages, if partitioned.
+	Partition string `json:"partition,omitempty"`
+	// Pools describes the pools of the backend, by pool name.
+	Pools map[string]*Pool `json:"pools,omitempty"`
+	// Grants describes the resources granted to containers, by cache ID.
+	Grants map[string]*Grant `json:"grants,omitempty"`
+}
+
+// Pool is the introspected state of a resource pool of a policy.
+type Pool struct {
+	// Name is the name of the pool.
+	Name string `json:"name"`
+	// Parent is the name of the parent pool, empty for the root pool.
+	Parent string `json:"parent,omitempty"`
+	// Children are the names of the child pools.
+	Children []string `json:"children,omitempty"`
+	// CPUs is the full set of CPUs in the pool.
+	CPUs string `json:"cpus"`
+	// Memory is the set of memory nodes in the pool.
+	Memory string `json:"memory"`
+	// IsolatedCPUs are the isolated CPUs of the pool available for allocation.
+	IsolatedCPUs string `json:"isolatedCPUs"`
+	// SharableCPUs are the sharable CPUs of the pool available for allocation.
+	SharableCPUs string `json:"sharableCPUs"`
+	// GrantedShares is the amount of shared CPU (in milli-CPU) granted from the pool.
+	GrantedShares int `json:"grantedShares"`
+}
+
+// Grant is the introspected resource grant of a container.
+type Grant struct {
+	// Pool is the name of the pool the container was assigned to.
+	Pool string `json:"pool"`
+	// ExclusiveCPUs are the CPUs exclusively allocated to the container.
+	ExclusiveCPUs string `json:"exclusiveCPUs,omitempty"`
+	// SharedCPUs are the CPUs the container shares with others.
+	SharedCPUs string `json:"sharedCPUs,omitempty"`
+	// SharedPortion is the amount of shared CPU (in milli-CPU) granted to the container.
+	SharedPortion int `json:"sharedPortion,omitempty"`
+	// Memset is the set of memory nodes the container is allowed to use.
+	Memset string `json:"memset,omitempty"`
+	// MemoryLimit is the amount of memory (in bytes) granted to the container.
+	MemoryLimit uint64 `json:"memoryLimit,omitempty"`
+}
+
+// Container is the introspected resource assignment of a container.
+type Container struct {
+	// ID is the runtime ID of the container.
+	ID string `json:"id"`
+	// Name is the name of the container.
+	Name string `json:"name"`
+	// Pod is the name of the pod of the container.
+	Pod string `json:"pod,omitempty"`
+	// Namespace is the namespace of the pod of the container.
+	Namespace string `json:"namespace,omitempty"`
+	// CpusetCpus is the cpuset assigned to the container.
+	CpusetCpus string `json:"cpusetCpus,omitempty"`
+	// CpusetMems is the memset assigned to the container.
+	CpusetMems string `json:"cpusetMems,omitempty"`
+	// RDTClass is the RDT class of the container.
+	RDTClass string `json:"rdtClass,omitempty"`
+	// BlockIOClass is the block I/O class of the container.
+	BlockIOClass string `json:"blockioClass,omitempty"`
+	// Tags are the tags of the container.
+	Tags map[string]string `json:"tags,omitempty"`
+	// Pending are the controllers with pending changes for the container.
+	Pending []string `json:"pending,omitempty"`
+}
+
+// StateFn returns the current introspected state.
+type StateFn func() (*State, error)
+
+// Handler serves the introspected state as JSON over HTTP.
+type Handler struct {
+	sync.Mutex
+	state StateFn
+}
+
+// Our logger instance.
+var log = logger.NewLogger("introspect")
+
+// NewHandler creates a new HTTP handler for serving introspection data.
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// Set sets the function used to introspect the current state.
+func (h *Handler) Set(fn StateFn) {
+	h.Lock()
+	defer h.Unlock()
+	h.state = fn
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	h.Lock()
+	fn := h.state
+	h.Unlock()
+
+	if fn == nil {
+		http.Error(w, "introspection not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	state, err := fn()
+	if err != nil {
+		log.Error("failed to introspect state: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		log.Error("failed to marshal introspection data: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
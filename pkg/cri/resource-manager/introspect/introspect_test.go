@@ -0,0 +1,87 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package introspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := NewHandler()
+
+	get := func(method string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, "/introspect", nil))
+		return rec
+	}
+
+	if rec := get(http.MethodGet); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d without state, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	h.Set(func() (*State, error) {
+		return nil, fmt.Errorf("failed")
+	})
+	if rec := get(http.MethodGet); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d on error, got %d", http.StatusInternalServerError, rec.Code)
+	}
+
+	expected := &State{
+		Policy: "topology-aware",
+		Backends: []*Backend{
+			{
+				Name: "topology-aware",
+				Pools: map[string]*Pool{
+					"socket #0": {Name: "socket #0", CPUs: "0-3", SharableCPUs: "1-3"},
+				},
+				Grants: map[string]*Grant{
+					"c0": {Pool: "socket #0", ExclusiveCPUs: "0"},
+				},
+			},
+		},
+		Containers: map[string]*Container{
+			"c0": {ID: "0123", Name: "ctr0", CpusetCpus: "0", Tags: map[string]string{"avx512": "true"}},
+		},
+	}
+	h.Set(func() (*State, error) {
+		return expected, nil
+	})
+
+	if rec := get(http.MethodPost); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %d for POST, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+
+	rec := get(http.MethodGet)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	state := &State{}
+	if err := json.Unmarshal(rec.Body.Bytes(), state); err != nil {
+		t.Fatalf("failed to unmarshal introspection data: %v", err)
+	}
+	if state.Policy != expected.Policy || len(state.Backends) != 1 {
+		t.Errorf("unexpected introspection data: %s", rec.Body.String())
+	}
+	if g := state.Backends[0].Grants["c0"]; g == nil || g.ExclusiveCPUs != "0" {
+		t.Errorf("unexpected grant in introspection data: %s", rec.Body.String())
+	}
+	if c := state.Containers["c0"]; c == nil || c.Tags["avx512"] != "true" {
+		t.Errorf("unexpected container in introspection data: %s", rec.Body.String())
+	}
+}
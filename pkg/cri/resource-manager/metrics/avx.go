@@ -21,13 +21,79 @@ import (
 	"github.com/intel/cri-resource-manager/pkg/cgroups"
 )
 
+//
+// Raw AVX512 usage is the ratio of context switches with AVX512 state to all
+// context switches of a cgroup during a polling interval. Raw usage tends to
+// be noisy, so we drive state transitions through a low-pass filter with
+// hysteresis: we keep an exponential moving average of the usage of each
+// cgroup and declare the cgroup an AVX512 user once its average rises above
+// the activation threshold, and no longer a user once its average drops below
+// the (lower) deactivation threshold. Cgroups without context switches during
+// an interval are considered to have zero usage.
+//
+
+const (
+	// avxForgetThreshold is the average usage below which we forget inactive cgroups.
+	avxForgetThreshold = 0.001
+)
+
 // AvxEvent describes cgroup/container AVX512 instruction usage.
 type AvxEvent struct {
-	// Updates contains updates to cgroup/container AVX512 instruction usage.
+	// Updates contains cgroups/containers with changed AVX512 instruction usage.
 	Updates map[string]bool
 }
 
+// avxState is the filtered AVX512 usage state of a cgroup.
+type avxState struct {
+	average float64 // exponential moving average of usage
+	active  bool    // whether the cgroup is considered an AVX512 user
+}
+
+// collectAvxEvents filters raw AVX512 usage and reports usage state changes.
 func (m *Metrics) collectAvxEvents(raw map[string]*model.MetricFamily) *AvxEvent {
+	ratio := m.avxUsage(raw)
+	if ratio == nil {
+		return nil
+	}
+
+	if m.avx == nil {
+		m.avx = make(map[string]*avxState)
+	}
+	for cgroup := range ratio {
+		if _, ok := m.avx[cgroup]; !ok {
+			m.avx[cgroup] = &avxState{}
+		}
+	}
+
+	updates := map[string]bool{}
+	for cgroup, state := range m.avx {
+		use := ratio[cgroup]
+		state.average = m.opts.AvxSmoothing*use + (1.0-m.opts.AvxSmoothing)*state.average
+
+		switch {
+		case !state.active && state.average >= m.opts.AvxThreshold:
+			state.active = true
+			updates[cgroup] = true
+		case state.active && state.average < m.opts.AvxReleaseThreshold:
+			state.active = false
+			updates[cgroup] = false
+		case !state.active && state.average < avxForgetThreshold:
+			delete(m.avx, cgroup)
+		}
+
+		log.Debug(" %s AVX ratio = %f, average = %f, active?: %v", cgroup, use,
+			state.average, state.active)
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	return &AvxEvent{Updates: updates}
+}
+
+// avxUsage calculates the raw AVX512 usage of cgroups from raw metrics.
+func (m *Metrics) avxUsage(raw map[string]*model.MetricFamily) map[string]float64 {
 	all, ok := raw["all_switch_count_per_cgroup"]
 	if !ok {
 		return nil
@@ -42,26 +108,21 @@ func (m *Metrics) collectAvxEvents(raw map[string]*model.MetricFamily) *AvxEvent
 
 	ratio := map[string]float64{}
 	for _, v := range avx.Metric {
-		cgroup, err := filepath.Rel(cgroups.V2path, v.Label[0].GetValue())
+		cgroup, err := filepath.Rel(cgroups.UnifiedDir(), v.Label[0].GetValue())
 		if err != nil {
 			continue
 		}
 		ratio[cgroup] = v.Gauge.GetValue()
 	}
 	for _, v := range all.Metric {
-		cgroup, err := filepath.Rel(cgroups.V2path, v.Label[0].GetValue())
+		cgroup, err := filepath.Rel(cgroups.UnifiedDir(), v.Label[0].GetValue())
 		if err != nil {
 			continue
 		}
-		ratio[cgroup] /= v.Gauge.GetValue()
-	}
-
-	usage := map[string]bool{}
-	for cgroup, use := range ratio {
-		active := use >= m.opts.AvxThreshold
-		log.Debug(" %s AVX ratio = %f, active?: %v", cgroup, use, active)
-		usage[cgroup] = active
+		if total := v.Gauge.GetValue(); total > 0 {
+			ratio[cgroup] /= total
+		}
 	}
 
-	return &AvxEvent{Updates: usage}
+	return ratio
 }
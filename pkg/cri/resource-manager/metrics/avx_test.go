@@ -0,0 +1,88 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	model "github.com/prometheus/client_model/go"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
+)
+
+// avxSample creates raw AVX512 metrics with the given usage ratio per cgroup.
+func avxSample(usage map[string]float64) map[string]*model.MetricFamily {
+	all := &model.MetricFamily{Name: proto.String("all_switch_count_per_cgroup")}
+	avx := &model.MetricFamily{Name: proto.String("avx_switch_count_per_cgroup")}
+	for cgroup, ratio := range usage {
+		path := filepath.Join(cgroups.UnifiedDir(), cgroup)
+		all.Metric = append(all.Metric, gauge(100, "cgroup", path))
+		avx.Metric = append(avx.Metric, gauge(100*ratio, "cgroup", path))
+	}
+	return map[string]*model.MetricFamily{
+		all.GetName(): all,
+		avx.GetName(): avx,
+	}
+}
+
+func TestAvxHysteresis(t *testing.T) {
+	m := &Metrics{
+		opts: Options{
+			AvxThreshold:        0.2,
+			AvxReleaseThreshold: 0.1,
+			AvxSmoothing:        0.5,
+		},
+	}
+
+	const cgroup = "kubepods.slice/pod0/ctr0.scope"
+	samples := []struct {
+		usage    float64
+		expected *bool
+	}{
+		{usage: 0.3, expected: nil},            // average 0.15, below threshold
+		{usage: 0.3, expected: boolPtr(true)},  // average 0.225, activated
+		{usage: 0.0, expected: nil},            // average 0.1125, above release threshold
+		{usage: 0.3, expected: nil},            // average 0.206, still active
+		{usage: 0.0, expected: nil},            // average 0.103, still active
+		{usage: 0.0, expected: boolPtr(false)}, // average 0.051, deactivated
+		{usage: 0.15, expected: nil},           // average 0.101, below threshold
+	}
+
+	for i, s := range samples {
+		usage := map[string]float64{}
+		if s.usage > 0 {
+			usage[cgroup] = s.usage
+		}
+		e := m.collectAvxEvents(avxSample(usage))
+
+		switch {
+		case s.expected == nil && e != nil:
+			t.Errorf("sample #%d: expected no AVX512 state change, got %v", i, e.Updates)
+		case s.expected != nil && e == nil:
+			t.Errorf("sample #%d: expected AVX512 state change to %v, got none", i, *s.expected)
+		case s.expected != nil:
+			if active, ok := e.Updates[cgroup]; !ok || active != *s.expected {
+				t.Errorf("sample #%d: expected AVX512 state change to %v, got %v",
+					i, *s.expected, e.Updates)
+			}
+		}
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -33,6 +34,10 @@ import (
 const (
 	// DefaultAvxThreshold is the cutoff below which a cgroup/container is not an AVX user.
 	DefaultAvxThreshold = float64(0.1)
+	// DefaultAvxReleaseThreshold is the cutoff below which an AVX user stops being one.
+	DefaultAvxReleaseThreshold = float64(0.05)
+	// DefaultAvxSmoothing is the default smoothing factor for AVX usage.
+	DefaultAvxSmoothing = float64(0.5)
 )
 
 // Event is a set of metrics events we deliver to be acted upon.
@@ -48,14 +53,23 @@ type Options struct {
 	Events chan interface{}
 	// AvxThreshold is the threshold (0 - 1) for a cgroup to be considered AVX512-active
 	AvxThreshold float64
+	// AvxReleaseThreshold is the threshold (0 - 1) for a cgroup to be considered AVX512-inactive
+	AvxReleaseThreshold float64
+	// AvxSmoothing is the weight (0 - 1) of the latest sample in average AVX512 usage
+	AvxSmoothing float64
+	// ContainerLabels resolves a cgroup path to labels of a managed container.
+	ContainerLabels func(string) (map[string]string, bool)
 }
 
 // Metrics implements collecting, caching and processing of raw metrics.
 type Metrics struct {
-	opts Options               // metrics collecting options
-	g    prometheus.Gatherer   // prometheus/raw metrics gatherer
-	stop chan interface{}      // channel to stop polling goroutine
-	raw  []*model.MetricFamily // latest set of raw metrics
+	sync.RWMutex
+	opts     Options               // metrics collecting options
+	g        prometheus.Gatherer   // prometheus/raw metrics gatherer
+	stop     chan interface{}      // channel to stop polling goroutine
+	raw      []*model.MetricFamily // latest set of raw metrics
+	exported []*model.MetricFamily // latest set of relabeled metrics for export
+	avx      map[string]*avxState  // filtered AVX512 usage state of cgroups
 }
 
 // Our logger instance.
@@ -69,6 +83,20 @@ func NewMetrics(opts Options) (*Metrics, error) {
 	if opts.AvxThreshold == 0.0 {
 		opts.AvxThreshold = DefaultAvxThreshold
 	}
+	if opts.AvxReleaseThreshold == 0.0 {
+		opts.AvxReleaseThreshold = DefaultAvxReleaseThreshold
+	}
+	if opts.AvxSmoothing == 0.0 {
+		opts.AvxSmoothing = DefaultAvxSmoothing
+	}
+	if opts.AvxReleaseThreshold > opts.AvxThreshold {
+		return nil, metricsError("invalid options, AVX512 release threshold %f > threshold %f",
+			opts.AvxReleaseThreshold, opts.AvxThreshold)
+	}
+	if opts.AvxSmoothing < 0.0 || opts.AvxSmoothing > 1.0 {
+		return nil, metricsError("invalid options, AVX512 smoothing %f not in [0, 1]",
+			opts.AvxSmoothing)
+	}
 
 	g, err := metrics.NewMetricGatherer()
 	if err != nil {
@@ -126,9 +154,22 @@ func (m *Metrics) poll() error {
 		return metricsError("failed to poll raw metrics: %v", err)
 	}
 	m.raw = f
+
+	exported := m.relabel(f)
+	m.Lock()
+	m.exported = exported
+	m.Unlock()
+
 	return nil
 }
 
+// Gather implements the prometheus.Gatherer interface for relabeled metrics.
+func (m *Metrics) Gather() ([]*model.MetricFamily, error) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.exported, nil
+}
+
 // process processes the collected raw metrics.
 func (m *Metrics) process() error {
 	raw := map[string]*model.MetricFamily{}
@@ -0,0 +1,140 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	model "github.com/prometheus/client_model/go"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
+)
+
+//
+// Raw metrics identify containers by cgroup path. Before exporting them,
+// we resolve these paths to containers and replace the cgroup labels with
+// labels identifying the container (namespace, pod, container, QoS class,
+// pool and cpuset). Metrics of cgroups which do not belong to any managed
+// container are dropped. Metrics without any cgroup labels are exported
+// as such.
+//
+
+// cgroupLabels are the names of raw metric labels holding a cgroup path.
+var cgroupLabels = map[string]struct{}{
+	"cgroup":      {},
+	"cgroup_path": {},
+}
+
+// relabel relabels raw metrics for export.
+func (m *Metrics) relabel(raw []*model.MetricFamily) []*model.MetricFamily {
+	resolved := map[string]map[string]string{}
+	exported := make([]*model.MetricFamily, 0, len(raw))
+
+	for _, f := range raw {
+		metrics := make([]*model.Metric, 0, len(f.Metric))
+		seen := map[string]struct{}{}
+
+		for _, metric := range f.Metric {
+			relabeled, ok := m.relabelMetric(metric, resolved)
+			if !ok {
+				continue
+			}
+			key := labelKey(relabeled.Label)
+			if _, dup := seen[key]; dup {
+				continue
+			}
+			seen[key] = struct{}{}
+			metrics = append(metrics, relabeled)
+		}
+
+		if len(metrics) > 0 {
+			exported = append(exported, &model.MetricFamily{
+				Name:   f.Name,
+				Help:   f.Help,
+				Type:   f.Type,
+				Metric: metrics,
+			})
+		}
+	}
+
+	return exported
+}
+
+// relabelMetric replaces the cgroup labels of a metric with container labels.
+func (m *Metrics) relabelMetric(metric *model.Metric, resolved map[string]map[string]string) (*model.Metric, bool) {
+	cgroup := ""
+	labels := make([]*model.LabelPair, 0, len(metric.Label))
+	for _, l := range metric.Label {
+		if _, ok := cgroupLabels[l.GetName()]; ok {
+			cgroup = l.GetValue()
+		} else {
+			labels = append(labels, l)
+		}
+	}
+
+	if cgroup == "" {
+		return metric, true
+	}
+
+	container, ok := resolved[cgroup]
+	if !ok {
+		container, ok = m.resolveCgroup(cgroup)
+		if !ok {
+			container = nil
+		}
+		resolved[cgroup] = container
+	}
+	if container == nil {
+		return nil, false
+	}
+
+	for name, value := range container {
+		labels = append(labels, &model.LabelPair{
+			Name:  proto.String(name),
+			Value: proto.String(value),
+		})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].GetName() < labels[j].GetName()
+	})
+
+	relabeled := *metric
+	relabeled.Label = labels
+
+	return &relabeled, true
+}
+
+// resolveCgroup resolves a cgroup path to the labels of a managed container.
+func (m *Metrics) resolveCgroup(cgroup string) (map[string]string, bool) {
+	if m.opts.ContainerLabels == nil {
+		return nil, false
+	}
+	if rel, err := filepath.Rel(cgroups.UnifiedDir(), cgroup); err == nil && !strings.HasPrefix(rel, "..") {
+		cgroup = rel
+	}
+	return m.opts.ContainerLabels(cgroup)
+}
+
+// labelKey returns a key identifying a set of sorted labels.
+func labelKey(labels []*model.LabelPair) string {
+	key := ""
+	for _, l := range labels {
+		key += l.GetName() + "=" + l.GetValue() + ","
+	}
+	return key
+}
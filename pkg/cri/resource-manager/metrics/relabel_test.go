@@ -0,0 +1,95 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	model "github.com/prometheus/client_model/go"
+)
+
+func gauge(value float64, labels ...string) *model.Metric {
+	m := &model.Metric{Gauge: &model.Gauge{Value: proto.Float64(value)}}
+	for i := 0; i < len(labels); i += 2 {
+		m.Label = append(m.Label, &model.LabelPair{
+			Name:  proto.String(labels[i]),
+			Value: proto.String(labels[i+1]),
+		})
+	}
+	return m
+}
+
+func TestRelabel(t *testing.T) {
+	m := &Metrics{
+		opts: Options{
+			ContainerLabels: func(path string) (map[string]string, bool) {
+				if path != "kubepods.slice/pod0/ctr0.scope" {
+					return nil, false
+				}
+				return map[string]string{"pod": "pod0", "container": "ctr0"}, true
+			},
+		},
+	}
+
+	raw := []*model.MetricFamily{
+		{
+			Name: proto.String("cgroup_memory_usage"),
+			Type: model.MetricType_GAUGE.Enum(),
+			Metric: []*model.Metric{
+				gauge(1, "cgroup_path", "kubepods.slice/pod0/ctr0.scope", "type", "Bytes"),
+				gauge(2, "cgroup_path", "kubepods.slice/other/ctr1.scope", "type", "Bytes"),
+			},
+		},
+		{
+			Name: proto.String("unmanaged"),
+			Type: model.MetricType_GAUGE.Enum(),
+			Metric: []*model.Metric{
+				gauge(3, "cgroup", "/sys/fs/cgroup/unified/system.slice/foo.service"),
+			},
+		},
+		{
+			Name: proto.String("last_cpu_avx_task_switches"),
+			Type: model.MetricType_GAUGE.Enum(),
+			Metric: []*model.Metric{
+				gauge(4, "cpu_id", "0"),
+			},
+		},
+	}
+
+	exported := m.relabel(raw)
+	if len(exported) != 2 {
+		t.Fatalf("expected 2 exported metric families, got %d", len(exported))
+	}
+
+	usage := exported[0]
+	if usage.GetName() != "cgroup_memory_usage" || len(usage.Metric) != 1 {
+		t.Fatalf("expected a single relabeled memory usage metric, got %v", usage)
+	}
+	expected := []string{"container=ctr0", "pod=pod0", "type=Bytes"}
+	labels := usage.Metric[0].Label
+	if len(labels) != len(expected) {
+		t.Fatalf("expected labels %v, got %v", expected, labels)
+	}
+	for i, l := range labels {
+		if l.GetName()+"="+l.GetValue() != expected[i] {
+			t.Errorf("expected label #%d %s, got %s=%s", i, expected[i], l.GetName(), l.GetValue())
+		}
+	}
+
+	if exported[1].GetName() != "last_cpu_avx_task_switches" || len(exported[1].Metric) != 1 {
+		t.Errorf("expected metric without cgroup labels to be exported as such, got %v", exported[1])
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resmgr
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/introspect"
+	"github.com/intel/cri-resource-manager/pkg/instrumentation"
+)
+
+const (
+	// failedByPolicy is the failure reason for allocations refused by the policy.
+	failedByPolicy = "policy"
+	// failedByHooks is the failure reason for allocations failing in controller hooks.
+	failedByHooks = "controller"
+	// failedByRuntime is the failure reason for containers failing to get created by the runtime.
+	failedByRuntime = "runtime"
+)
+
+var (
+	allocationsDesc = prometheus.NewDesc(
+		"policy_allocations_total",
+		"Number of successful resource allocations for containers.",
+		nil, nil,
+	)
+	releasesDesc = prometheus.NewDesc(
+		"policy_releases_total",
+		"Number of resource releases of containers.",
+		nil, nil,
+	)
+	failuresDesc = prometheus.NewDesc(
+		"policy_allocation_failures_total",
+		"Number of failed resource allocations for containers, by reason.",
+		[]string{"reason"}, nil,
+	)
+	rebalanceDesc = prometheus.NewDesc(
+		"policy_rebalance_moves_total",
+		"Number of container resource assignments changed by rebalancing.",
+		nil, nil,
+	)
+	pendingDesc = prometheus.NewDesc(
+		"controller_pending_containers",
+		"Number of containers with changes pending enforcement, by controller.",
+		[]string{"controller"}, nil,
+	)
+	sharableDesc = prometheus.NewDesc(
+		"pool_sharable_cpus",
+		"Number of free sharable CPUs in a policy pool.",
+		[]string{"pool"}, nil,
+	)
+	grantedDesc = prometheus.NewDesc(
+		"pool_granted_cpu_shares",
+		"Amount of shared CPU (in milli-CPU) granted to containers from a policy pool.",
+		[]string{"pool"}, nil,
+	)
+)
+
+// policyMetrics collects metrics about the decisions of the resource manager.
+type policyMetrics struct {
+	m           *resmgr
+	allocations uint64
+	releases    uint64
+	failures    map[string]uint64
+	rebalanced  uint64
+}
+
+// setupPolicyMetrics sets up collecting and exporting policy decision metrics.
+func (m *resmgr) setupPolicyMetrics() error {
+	m.stats = &policyMetrics{
+		m:        m,
+		failures: make(map[string]uint64),
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := reg.Register(m.stats); err != nil {
+		return resmgrError("failed to register policy metrics: %v", err)
+	}
+	instrumentation.RegisterGatherer(reg)
+
+	return nil
+}
+
+// allocated records a successful allocation.
+func (pm *policyMetrics) allocated() {
+	pm.allocations++
+}
+
+// released records a release.
+func (pm *policyMetrics) released() {
+	pm.releases++
+}
+
+// failed records a failed allocation.
+func (pm *policyMetrics) failed(reason string) {
+	pm.failures[reason]++
+}
+
+// moved records containers moved by rebalancing.
+func (pm *policyMetrics) moved(count int) {
+	pm.rebalanced += uint64(count)
+}
+
+// Describe implements prometheus.Collector.
+func (pm *policyMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- allocationsDesc
+	ch <- releasesDesc
+	ch <- failuresDesc
+	ch <- rebalanceDesc
+	ch <- pendingDesc
+	ch <- sharableDesc
+	ch <- grantedDesc
+}
+
+// Collect implements prometheus.Collector.
+func (pm *policyMetrics) Collect(ch chan<- prometheus.Metric) {
+	pm.m.Lock()
+	defer pm.m.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(allocationsDesc, prometheus.CounterValue,
+		float64(pm.allocations))
+	ch <- prometheus.MustNewConstMetric(releasesDesc, prometheus.CounterValue,
+		float64(pm.releases))
+	for _, reason := range []string{failedByPolicy, failedByHooks, failedByRuntime} {
+		ch <- prometheus.MustNewConstMetric(failuresDesc, prometheus.CounterValue,
+			float64(pm.failures[reason]), reason)
+	}
+	ch <- prometheus.MustNewConstMetric(rebalanceDesc, prometheus.CounterValue,
+		float64(pm.rebalanced))
+
+	pending := map[string]int{}
+	for _, c := range pm.m.cache.GetPendingContainers() {
+		for _, controller := range c.GetPending() {
+			pending[controller]++
+		}
+	}
+	for controller, count := range pending {
+		ch <- prometheus.MustNewConstMetric(pendingDesc, prometheus.GaugeValue,
+			float64(count), controller)
+	}
+
+	if pm.m.policy == nil {
+		return
+	}
+
+	state := &introspect.State{}
+	pm.m.policy.Introspect(state)
+	for _, b := range state.Backends {
+		for _, pool := range b.Pools {
+			name := pool.Name
+			if b.Partition != "" {
+				name = b.Partition + "/" + name
+			}
+			sharable, err := cpuset.Parse(pool.SharableCPUs)
+			if err != nil {
+				pm.m.Error("failed to parse sharable CPUs of pool %s: %v", name, err)
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(sharableDesc, prometheus.GaugeValue,
+				float64(sharable.Size()), name)
+			ch <- prometheus.MustNewConstMetric(grantedDesc, prometheus.GaugeValue,
+				float64(pool.GrantedShares), name)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resmgr
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+func TestPolicyMetrics(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy-metrics-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := &resmgr{}
+	if m.cache, err = cache.NewCache(cache.Options{CacheDir: dir}); err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	if err := m.setupPolicyMetrics(); err != nil {
+		t.Fatalf("failed to set up policy metrics: %v", err)
+	}
+
+	m.stats.allocated()
+	m.stats.allocated()
+	m.stats.released()
+	m.stats.failed(failedByPolicy)
+	m.stats.failed(failedByRuntime)
+	m.stats.failed(failedByRuntime)
+	m.stats.moved(3)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m.stats)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	values := map[string]float64{}
+	for _, f := range families {
+		for _, metric := range f.Metric {
+			name := f.GetName()
+			for _, l := range metric.Label {
+				name += "/" + l.GetValue()
+			}
+			values[name] = metric.GetCounter().GetValue()
+		}
+	}
+
+	expected := map[string]float64{
+		"policy_allocations_total":                    2,
+		"policy_releases_total":                       1,
+		"policy_allocation_failures_total/policy":     1,
+		"policy_allocation_failures_total/controller": 0,
+		"policy_allocation_failures_total/runtime":    2,
+		"policy_rebalance_moves_total":                3,
+	}
+	for name, value := range expected {
+		if values[name] != value {
+			t.Errorf("expected %s to be %v, got %v", name, value, values[name])
+		}
+	}
+}
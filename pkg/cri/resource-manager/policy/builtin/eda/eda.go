@@ -126,9 +126,9 @@ func (eda *eda) UpdateResources(c cache.Container) error {
 }
 
 // Rebalance tries to find an optimal allocation of resources for the current containers.
-func (eda *eda) Rebalance() (bool, error) {
+func (eda *eda) Rebalance() (int, error) {
 	eda.Debug("(not) rebalancing containers...")
-	return false, nil
+	return 0, nil
 }
 
 // ExportResourceData provides resource data to export for the container.
@@ -0,0 +1,1334 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: pkg/cri/resource-manager/policy/builtin/external/api/v1/api.proto
+
+package v1
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type StartRequest struct {
+	// Version of the API used by cri-resmgr.
+	ApiVersion uint32 `protobuf:"varint,1,opt,name=api_version,json=apiVersion,proto3" json:"api_version,omitempty"`
+	// Hardware topology of the node.
+	Topology *Topology `protobuf:"bytes,2,opt,name=topology,proto3" json:"topology,omitempty"`
+	// CPUs available to the policy, as a cpuset string.
+	AvailableCpus string `protobuf:"bytes,3,opt,name=available_cpus,json=availableCpus,proto3" json:"available_cpus,omitempty"`
+	// CPUs reserved for system and kube services, as a cpuset or a CPU quantity string.
+	ReservedCpus string `protobuf:"bytes,4,opt,name=reserved_cpus,json=reservedCpus,proto3" json:"reserved_cpus,omitempty"`
+	// Containers to allocate resources for.
+	Add []*Container `protobuf:"bytes,5,rep,name=add,proto3" json:"add,omitempty"`
+	// Containers to release resources of.
+	Del                  []*Container `protobuf:"bytes,6,rep,name=del,proto3" json:"del,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *StartRequest) Reset()         { *m = StartRequest{} }
+func (m *StartRequest) String() string { return proto.CompactTextString(m) }
+func (*StartRequest) ProtoMessage()    {}
+func (*StartRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{0}
+}
+
+func (m *StartRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StartRequest.Unmarshal(m, b)
+}
+func (m *StartRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StartRequest.Marshal(b, m, deterministic)
+}
+func (m *StartRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StartRequest.Merge(m, src)
+}
+func (m *StartRequest) XXX_Size() int {
+	return xxx_messageInfo_StartRequest.Size(m)
+}
+func (m *StartRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StartRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StartRequest proto.InternalMessageInfo
+
+func (m *StartRequest) GetApiVersion() uint32 {
+	if m != nil {
+		return m.ApiVersion
+	}
+	return 0
+}
+
+func (m *StartRequest) GetTopology() *Topology {
+	if m != nil {
+		return m.Topology
+	}
+	return nil
+}
+
+func (m *StartRequest) GetAvailableCpus() string {
+	if m != nil {
+		return m.AvailableCpus
+	}
+	return ""
+}
+
+func (m *StartRequest) GetReservedCpus() string {
+	if m != nil {
+		return m.ReservedCpus
+	}
+	return ""
+}
+
+func (m *StartRequest) GetAdd() []*Container {
+	if m != nil {
+		return m.Add
+	}
+	return nil
+}
+
+func (m *StartRequest) GetDel() []*Container {
+	if m != nil {
+		return m.Del
+	}
+	return nil
+}
+
+type SyncRequest struct {
+	// Containers to allocate resources for.
+	Add []*Container `protobuf:"bytes,1,rep,name=add,proto3" json:"add,omitempty"`
+	// Containers to release resources of.
+	Del                  []*Container `protobuf:"bytes,2,rep,name=del,proto3" json:"del,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *SyncRequest) Reset()         { *m = SyncRequest{} }
+func (m *SyncRequest) String() string { return proto.CompactTextString(m) }
+func (*SyncRequest) ProtoMessage()    {}
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{1}
+}
+
+func (m *SyncRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncRequest.Unmarshal(m, b)
+}
+func (m *SyncRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncRequest.Marshal(b, m, deterministic)
+}
+func (m *SyncRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncRequest.Merge(m, src)
+}
+func (m *SyncRequest) XXX_Size() int {
+	return xxx_messageInfo_SyncRequest.Size(m)
+}
+func (m *SyncRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncRequest proto.InternalMessageInfo
+
+func (m *SyncRequest) GetAdd() []*Container {
+	if m != nil {
+		return m.Add
+	}
+	return nil
+}
+
+func (m *SyncRequest) GetDel() []*Container {
+	if m != nil {
+		return m.Del
+	}
+	return nil
+}
+
+type ContainerRequest struct {
+	// The container to act on.
+	Container            *Container `protobuf:"bytes,1,opt,name=container,proto3" json:"container,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
+}
+
+func (m *ContainerRequest) Reset()         { *m = ContainerRequest{} }
+func (m *ContainerRequest) String() string { return proto.CompactTextString(m) }
+func (*ContainerRequest) ProtoMessage()    {}
+func (*ContainerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{2}
+}
+
+func (m *ContainerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContainerRequest.Unmarshal(m, b)
+}
+func (m *ContainerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContainerRequest.Marshal(b, m, deterministic)
+}
+func (m *ContainerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContainerRequest.Merge(m, src)
+}
+func (m *ContainerRequest) XXX_Size() int {
+	return xxx_messageInfo_ContainerRequest.Size(m)
+}
+func (m *ContainerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContainerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContainerRequest proto.InternalMessageInfo
+
+func (m *ContainerRequest) GetContainer() *Container {
+	if m != nil {
+		return m.Container
+	}
+	return nil
+}
+
+type RebalanceRequest struct {
+	// All current containers.
+	Containers           []*Container `protobuf:"bytes,1,rep,name=containers,proto3" json:"containers,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
+	XXX_unrecognized     []byte       `json:"-"`
+	XXX_sizecache        int32        `json:"-"`
+}
+
+func (m *RebalanceRequest) Reset()         { *m = RebalanceRequest{} }
+func (m *RebalanceRequest) String() string { return proto.CompactTextString(m) }
+func (*RebalanceRequest) ProtoMessage()    {}
+func (*RebalanceRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{3}
+}
+
+func (m *RebalanceRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RebalanceRequest.Unmarshal(m, b)
+}
+func (m *RebalanceRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RebalanceRequest.Marshal(b, m, deterministic)
+}
+func (m *RebalanceRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RebalanceRequest.Merge(m, src)
+}
+func (m *RebalanceRequest) XXX_Size() int {
+	return xxx_messageInfo_RebalanceRequest.Size(m)
+}
+func (m *RebalanceRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RebalanceRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RebalanceRequest proto.InternalMessageInfo
+
+func (m *RebalanceRequest) GetContainers() []*Container {
+	if m != nil {
+		return m.Containers
+	}
+	return nil
+}
+
+type DecisionReply struct {
+	// Resource allocation decisions for containers.
+	Decisions []*Decision `protobuf:"bytes,1,rep,name=decisions,proto3" json:"decisions,omitempty"`
+	// Whether rebalancing changed any allocations.
+	Changed bool `protobuf:"varint,2,opt,name=changed,proto3" json:"changed,omitempty"`
+	// If not empty, indicate an error that happened while processing the request.
+	Error                string   `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DecisionReply) Reset()         { *m = DecisionReply{} }
+func (m *DecisionReply) String() string { return proto.CompactTextString(m) }
+func (*DecisionReply) ProtoMessage()    {}
+func (*DecisionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{4}
+}
+
+func (m *DecisionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DecisionReply.Unmarshal(m, b)
+}
+func (m *DecisionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DecisionReply.Marshal(b, m, deterministic)
+}
+func (m *DecisionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DecisionReply.Merge(m, src)
+}
+func (m *DecisionReply) XXX_Size() int {
+	return xxx_messageInfo_DecisionReply.Size(m)
+}
+func (m *DecisionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_DecisionReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DecisionReply proto.InternalMessageInfo
+
+func (m *DecisionReply) GetDecisions() []*Decision {
+	if m != nil {
+		return m.Decisions
+	}
+	return nil
+}
+
+func (m *DecisionReply) GetChanged() bool {
+	if m != nil {
+		return m.Changed
+	}
+	return false
+}
+
+func (m *DecisionReply) GetError() string {
+	if m != nil {
+		return m.Error
+	}
+	return ""
+}
+
+// Decision is the resource allocation decision for a single container.
+type Decision struct {
+	// Cache ID of the container.
+	CacheId string `protobuf:"bytes,1,opt,name=cache_id,json=cacheId,proto3" json:"cache_id,omitempty"`
+	// Resources to set, unset (zero) values are left intact.
+	Resources *Resources `protobuf:"bytes,2,opt,name=resources,proto3" json:"resources,omitempty"`
+	// RDT class to assign, left intact if empty.
+	RdtClass string `protobuf:"bytes,3,opt,name=rdt_class,json=rdtClass,proto3" json:"rdt_class,omitempty"`
+	// Block I/O class to assign, left intact if empty.
+	BlockioClass string `protobuf:"bytes,4,opt,name=blockio_class,json=blockioClass,proto3" json:"blockio_class,omitempty"`
+	// Resource data to export to the container.
+	Export               map[string]string `protobuf:"bytes,5,rep,name=export,proto3" json:"export,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Decision) Reset()         { *m = Decision{} }
+func (m *Decision) String() string { return proto.CompactTextString(m) }
+func (*Decision) ProtoMessage()    {}
+func (*Decision) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{5}
+}
+
+func (m *Decision) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Decision.Unmarshal(m, b)
+}
+func (m *Decision) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Decision.Marshal(b, m, deterministic)
+}
+func (m *Decision) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Decision.Merge(m, src)
+}
+func (m *Decision) XXX_Size() int {
+	return xxx_messageInfo_Decision.Size(m)
+}
+func (m *Decision) XXX_DiscardUnknown() {
+	xxx_messageInfo_Decision.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Decision proto.InternalMessageInfo
+
+func (m *Decision) GetCacheId() string {
+	if m != nil {
+		return m.CacheId
+	}
+	return ""
+}
+
+func (m *Decision) GetResources() *Resources {
+	if m != nil {
+		return m.Resources
+	}
+	return nil
+}
+
+func (m *Decision) GetRdtClass() string {
+	if m != nil {
+		return m.RdtClass
+	}
+	return ""
+}
+
+func (m *Decision) GetBlockioClass() string {
+	if m != nil {
+		return m.BlockioClass
+	}
+	return ""
+}
+
+func (m *Decision) GetExport() map[string]string {
+	if m != nil {
+		return m.Export
+	}
+	return nil
+}
+
+// Topology describes the hardware topology of the node.
+type Topology struct {
+	Packages             []*Package  `protobuf:"bytes,1,rep,name=packages,proto3" json:"packages,omitempty"`
+	Nodes                []*NumaNode `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Cpus                 []*Cpu      `protobuf:"bytes,3,rep,name=cpus,proto3" json:"cpus,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *Topology) Reset()         { *m = Topology{} }
+func (m *Topology) String() string { return proto.CompactTextString(m) }
+func (*Topology) ProtoMessage()    {}
+func (*Topology) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{6}
+}
+
+func (m *Topology) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Topology.Unmarshal(m, b)
+}
+func (m *Topology) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Topology.Marshal(b, m, deterministic)
+}
+func (m *Topology) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Topology.Merge(m, src)
+}
+func (m *Topology) XXX_Size() int {
+	return xxx_messageInfo_Topology.Size(m)
+}
+func (m *Topology) XXX_DiscardUnknown() {
+	xxx_messageInfo_Topology.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Topology proto.InternalMessageInfo
+
+func (m *Topology) GetPackages() []*Package {
+	if m != nil {
+		return m.Packages
+	}
+	return nil
+}
+
+func (m *Topology) GetNodes() []*NumaNode {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *Topology) GetCpus() []*Cpu {
+	if m != nil {
+		return m.Cpus
+	}
+	return nil
+}
+
+// Package is a physical CPU package (socket).
+type Package struct {
+	Id                   int32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Cpus                 string   `protobuf:"bytes,2,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	Nodes                []int32  `protobuf:"varint,3,rep,packed,name=nodes,proto3" json:"nodes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Package) Reset()         { *m = Package{} }
+func (m *Package) String() string { return proto.CompactTextString(m) }
+func (*Package) ProtoMessage()    {}
+func (*Package) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{7}
+}
+
+func (m *Package) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Package.Unmarshal(m, b)
+}
+func (m *Package) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Package.Marshal(b, m, deterministic)
+}
+func (m *Package) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Package.Merge(m, src)
+}
+func (m *Package) XXX_Size() int {
+	return xxx_messageInfo_Package.Size(m)
+}
+func (m *Package) XXX_DiscardUnknown() {
+	xxx_messageInfo_Package.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Package proto.InternalMessageInfo
+
+func (m *Package) GetId() int32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Package) GetCpus() string {
+	if m != nil {
+		return m.Cpus
+	}
+	return ""
+}
+
+func (m *Package) GetNodes() []int32 {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+// NumaNode is a NUMA node.
+type NumaNode struct {
+	Id      int32  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Package int32  `protobuf:"varint,2,opt,name=package,proto3" json:"package,omitempty"`
+	Cpus    string `protobuf:"bytes,3,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	// Total memory in bytes.
+	Memory uint64 `protobuf:"varint,4,opt,name=memory,proto3" json:"memory,omitempty"`
+	// Distance to other NUMA nodes, indexed by node id.
+	Distance             []int32  `protobuf:"varint,5,rep,packed,name=distance,proto3" json:"distance,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NumaNode) Reset()         { *m = NumaNode{} }
+func (m *NumaNode) String() string { return proto.CompactTextString(m) }
+func (*NumaNode) ProtoMessage()    {}
+func (*NumaNode) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{8}
+}
+
+func (m *NumaNode) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NumaNode.Unmarshal(m, b)
+}
+func (m *NumaNode) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NumaNode.Marshal(b, m, deterministic)
+}
+func (m *NumaNode) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NumaNode.Merge(m, src)
+}
+func (m *NumaNode) XXX_Size() int {
+	return xxx_messageInfo_NumaNode.Size(m)
+}
+func (m *NumaNode) XXX_DiscardUnknown() {
+	xxx_messageInfo_NumaNode.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NumaNode proto.InternalMessageInfo
+
+func (m *NumaNode) GetId() int32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *NumaNode) GetPackage() int32 {
+	if m != nil {
+		return m.Package
+	}
+	return 0
+}
+
+func (m *NumaNode) GetCpus() string {
+	if m != nil {
+		return m.Cpus
+	}
+	return ""
+}
+
+func (m *NumaNode) GetMemory() uint64 {
+	if m != nil {
+		return m.Memory
+	}
+	return 0
+}
+
+func (m *NumaNode) GetDistance() []int32 {
+	if m != nil {
+		return m.Distance
+	}
+	return nil
+}
+
+// Cpu is a single CPU (hardware thread).
+type Cpu struct {
+	Id      int32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Package int32 `protobuf:"varint,2,opt,name=package,proto3" json:"package,omitempty"`
+	Node    int32 `protobuf:"varint,3,opt,name=node,proto3" json:"node,omitempty"`
+	Core    int32 `protobuf:"varint,4,opt,name=core,proto3" json:"core,omitempty"`
+	// Hyperthreads of the same core, as a cpuset string.
+	Threads              string   `protobuf:"bytes,5,opt,name=threads,proto3" json:"threads,omitempty"`
+	Online               bool     `protobuf:"varint,6,opt,name=online,proto3" json:"online,omitempty"`
+	Isolated             bool     `protobuf:"varint,7,opt,name=isolated,proto3" json:"isolated,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Cpu) Reset()         { *m = Cpu{} }
+func (m *Cpu) String() string { return proto.CompactTextString(m) }
+func (*Cpu) ProtoMessage()    {}
+func (*Cpu) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{9}
+}
+
+func (m *Cpu) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Cpu.Unmarshal(m, b)
+}
+func (m *Cpu) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Cpu.Marshal(b, m, deterministic)
+}
+func (m *Cpu) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Cpu.Merge(m, src)
+}
+func (m *Cpu) XXX_Size() int {
+	return xxx_messageInfo_Cpu.Size(m)
+}
+func (m *Cpu) XXX_DiscardUnknown() {
+	xxx_messageInfo_Cpu.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Cpu proto.InternalMessageInfo
+
+func (m *Cpu) GetId() int32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *Cpu) GetPackage() int32 {
+	if m != nil {
+		return m.Package
+	}
+	return 0
+}
+
+func (m *Cpu) GetNode() int32 {
+	if m != nil {
+		return m.Node
+	}
+	return 0
+}
+
+func (m *Cpu) GetCore() int32 {
+	if m != nil {
+		return m.Core
+	}
+	return 0
+}
+
+func (m *Cpu) GetThreads() string {
+	if m != nil {
+		return m.Threads
+	}
+	return ""
+}
+
+func (m *Cpu) GetOnline() bool {
+	if m != nil {
+		return m.Online
+	}
+	return false
+}
+
+func (m *Cpu) GetIsolated() bool {
+	if m != nil {
+		return m.Isolated
+	}
+	return false
+}
+
+// Pod describes a pod.
+type Pod struct {
+	Id                   string            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Uid                  string            `protobuf:"bytes,2,opt,name=uid,proto3" json:"uid,omitempty"`
+	Name                 string            `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Namespace            string            `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	QosClass             string            `protobuf:"bytes,5,opt,name=qos_class,json=qosClass,proto3" json:"qos_class,omitempty"`
+	Labels               map[string]string `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Annotations          map[string]string `protobuf:"bytes,7,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	CgroupParent         string            `protobuf:"bytes,8,opt,name=cgroup_parent,json=cgroupParent,proto3" json:"cgroup_parent,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Pod) Reset()         { *m = Pod{} }
+func (m *Pod) String() string { return proto.CompactTextString(m) }
+func (*Pod) ProtoMessage()    {}
+func (*Pod) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{10}
+}
+
+func (m *Pod) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Pod.Unmarshal(m, b)
+}
+func (m *Pod) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Pod.Marshal(b, m, deterministic)
+}
+func (m *Pod) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Pod.Merge(m, src)
+}
+func (m *Pod) XXX_Size() int {
+	return xxx_messageInfo_Pod.Size(m)
+}
+func (m *Pod) XXX_DiscardUnknown() {
+	xxx_messageInfo_Pod.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Pod proto.InternalMessageInfo
+
+func (m *Pod) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Pod) GetUid() string {
+	if m != nil {
+		return m.Uid
+	}
+	return ""
+}
+
+func (m *Pod) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Pod) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *Pod) GetQosClass() string {
+	if m != nil {
+		return m.QosClass
+	}
+	return ""
+}
+
+func (m *Pod) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *Pod) GetAnnotations() map[string]string {
+	if m != nil {
+		return m.Annotations
+	}
+	return nil
+}
+
+func (m *Pod) GetCgroupParent() string {
+	if m != nil {
+		return m.CgroupParent
+	}
+	return ""
+}
+
+// Container describes a container.
+type Container struct {
+	// Cache ID of the container, used to identify it in decisions.
+	CacheId string `protobuf:"bytes,1,opt,name=cache_id,json=cacheId,proto3" json:"cache_id,omitempty"`
+	// Runtime ID of the container.
+	Id          string            `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Name        string            `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Pod         *Pod              `protobuf:"bytes,4,opt,name=pod,proto3" json:"pod,omitempty"`
+	State       string            `protobuf:"bytes,5,opt,name=state,proto3" json:"state,omitempty"`
+	Labels      map[string]string `protobuf:"bytes,6,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Annotations map[string]string `protobuf:"bytes,7,rep,name=annotations,proto3" json:"annotations,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Resource requests, as resource quantity strings.
+	Requests map[string]string `protobuf:"bytes,8,rep,name=requests,proto3" json:"requests,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Resource limits, as resource quantity strings.
+	Limits map[string]string `protobuf:"bytes,9,rep,name=limits,proto3" json:"limits,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Currently assigned resources.
+	Resources *Resources `protobuf:"bytes,10,opt,name=resources,proto3" json:"resources,omitempty"`
+	// Currently assigned RDT class.
+	RdtClass string `protobuf:"bytes,11,opt,name=rdt_class,json=rdtClass,proto3" json:"rdt_class,omitempty"`
+	// Currently assigned block I/O class.
+	BlockioClass         string   `protobuf:"bytes,12,opt,name=blockio_class,json=blockioClass,proto3" json:"blockio_class,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Container) Reset()         { *m = Container{} }
+func (m *Container) String() string { return proto.CompactTextString(m) }
+func (*Container) ProtoMessage()    {}
+func (*Container) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{11}
+}
+
+func (m *Container) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Container.Unmarshal(m, b)
+}
+func (m *Container) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Container.Marshal(b, m, deterministic)
+}
+func (m *Container) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Container.Merge(m, src)
+}
+func (m *Container) XXX_Size() int {
+	return xxx_messageInfo_Container.Size(m)
+}
+func (m *Container) XXX_DiscardUnknown() {
+	xxx_messageInfo_Container.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Container proto.InternalMessageInfo
+
+func (m *Container) GetCacheId() string {
+	if m != nil {
+		return m.CacheId
+	}
+	return ""
+}
+
+func (m *Container) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Container) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Container) GetPod() *Pod {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+func (m *Container) GetState() string {
+	if m != nil {
+		return m.State
+	}
+	return ""
+}
+
+func (m *Container) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *Container) GetAnnotations() map[string]string {
+	if m != nil {
+		return m.Annotations
+	}
+	return nil
+}
+
+func (m *Container) GetRequests() map[string]string {
+	if m != nil {
+		return m.Requests
+	}
+	return nil
+}
+
+func (m *Container) GetLimits() map[string]string {
+	if m != nil {
+		return m.Limits
+	}
+	return nil
+}
+
+func (m *Container) GetResources() *Resources {
+	if m != nil {
+		return m.Resources
+	}
+	return nil
+}
+
+func (m *Container) GetRdtClass() string {
+	if m != nil {
+		return m.RdtClass
+	}
+	return ""
+}
+
+func (m *Container) GetBlockioClass() string {
+	if m != nil {
+		return m.BlockioClass
+	}
+	return ""
+}
+
+// Resources are the Linux resources of a container.
+type Resources struct {
+	CpuPeriod            int64    `protobuf:"varint,1,opt,name=cpu_period,json=cpuPeriod,proto3" json:"cpu_period,omitempty"`
+	CpuQuota             int64    `protobuf:"varint,2,opt,name=cpu_quota,json=cpuQuota,proto3" json:"cpu_quota,omitempty"`
+	CpuShares            int64    `protobuf:"varint,3,opt,name=cpu_shares,json=cpuShares,proto3" json:"cpu_shares,omitempty"`
+	MemoryLimit          int64    `protobuf:"varint,4,opt,name=memory_limit,json=memoryLimit,proto3" json:"memory_limit,omitempty"`
+	CpusetCpus           string   `protobuf:"bytes,5,opt,name=cpuset_cpus,json=cpusetCpus,proto3" json:"cpuset_cpus,omitempty"`
+	CpusetMems           string   `protobuf:"bytes,6,opt,name=cpuset_mems,json=cpusetMems,proto3" json:"cpuset_mems,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Resources) Reset()         { *m = Resources{} }
+func (m *Resources) String() string { return proto.CompactTextString(m) }
+func (*Resources) ProtoMessage()    {}
+func (*Resources) Descriptor() ([]byte, []int) {
+	return fileDescriptor_a6f043b889e52623, []int{12}
+}
+
+func (m *Resources) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Resources.Unmarshal(m, b)
+}
+func (m *Resources) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Resources.Marshal(b, m, deterministic)
+}
+func (m *Resources) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Resources.Merge(m, src)
+}
+func (m *Resources) XXX_Size() int {
+	return xxx_messageInfo_Resources.Size(m)
+}
+func (m *Resources) XXX_DiscardUnknown() {
+	xxx_messageInfo_Resources.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Resources proto.InternalMessageInfo
+
+func (m *Resources) GetCpuPeriod() int64 {
+	if m != nil {
+		return m.CpuPeriod
+	}
+	return 0
+}
+
+func (m *Resources) GetCpuQuota() int64 {
+	if m != nil {
+		return m.CpuQuota
+	}
+	return 0
+}
+
+func (m *Resources) GetCpuShares() int64 {
+	if m != nil {
+		return m.CpuShares
+	}
+	return 0
+}
+
+func (m *Resources) GetMemoryLimit() int64 {
+	if m != nil {
+		return m.MemoryLimit
+	}
+	return 0
+}
+
+func (m *Resources) GetCpusetCpus() string {
+	if m != nil {
+		return m.CpusetCpus
+	}
+	return ""
+}
+
+func (m *Resources) GetCpusetMems() string {
+	if m != nil {
+		return m.CpusetMems
+	}
+	return ""
+}
+
+func init() {
+	proto.RegisterType((*StartRequest)(nil), "v1.StartRequest")
+	proto.RegisterType((*SyncRequest)(nil), "v1.SyncRequest")
+	proto.RegisterType((*ContainerRequest)(nil), "v1.ContainerRequest")
+	proto.RegisterType((*RebalanceRequest)(nil), "v1.RebalanceRequest")
+	proto.RegisterType((*DecisionReply)(nil), "v1.DecisionReply")
+	proto.RegisterType((*Decision)(nil), "v1.Decision")
+	proto.RegisterMapType((map[string]string)(nil), "v1.Decision.ExportEntry")
+	proto.RegisterType((*Topology)(nil), "v1.Topology")
+	proto.RegisterType((*Package)(nil), "v1.Package")
+	proto.RegisterType((*NumaNode)(nil), "v1.NumaNode")
+	proto.RegisterType((*Cpu)(nil), "v1.Cpu")
+	proto.RegisterType((*Pod)(nil), "v1.Pod")
+	proto.RegisterMapType((map[string]string)(nil), "v1.Pod.AnnotationsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "v1.Pod.LabelsEntry")
+	proto.RegisterType((*Container)(nil), "v1.Container")
+	proto.RegisterMapType((map[string]string)(nil), "v1.Container.AnnotationsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "v1.Container.LabelsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "v1.Container.LimitsEntry")
+	proto.RegisterMapType((map[string]string)(nil), "v1.Container.RequestsEntry")
+	proto.RegisterType((*Resources)(nil), "v1.Resources")
+}
+
+func init() {
+	proto.RegisterFile("pkg/cri/resource-manager/policy/builtin/external/api/v1/api.proto", fileDescriptor_a6f043b889e52623)
+}
+
+var fileDescriptor_a6f043b889e52623 = []byte{
+	// 1134 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x56, 0xdd, 0x8e, 0xdb, 0xc4,
+	0x17, 0xff, 0x27, 0x5e, 0x27, 0xf6, 0xc9, 0xa6, 0x4d, 0xe7, 0x5f, 0x21, 0x77, 0x0b, 0x74, 0x31,
+	0x42, 0xac, 0x28, 0x4d, 0xd8, 0x22, 0xf1, 0x51, 0xa0, 0xb0, 0x2c, 0xbd, 0x40, 0x82, 0x12, 0xa6,
+	0xc0, 0x05, 0x37, 0xd1, 0xc4, 0x1e, 0x65, 0xad, 0x75, 0x3c, 0xde, 0x99, 0x71, 0xd4, 0x48, 0xdc,
+	0xf0, 0x30, 0xbc, 0x06, 0x2f, 0xc0, 0x23, 0xf0, 0x00, 0x3c, 0x06, 0x68, 0xce, 0x8c, 0x9d, 0x64,
+	0x37, 0x6c, 0x95, 0xde, 0x70, 0x15, 0x9f, 0xdf, 0xf9, 0x98, 0x33, 0xe7, 0xfc, 0xe6, 0xe4, 0xc0,
+	0x49, 0x79, 0x3e, 0x1b, 0x25, 0x32, 0x1b, 0x49, 0xae, 0x44, 0x25, 0x13, 0xfe, 0x60, 0xce, 0x0a,
+	0x36, 0xe3, 0x72, 0x54, 0x8a, 0x3c, 0x4b, 0x96, 0xa3, 0x69, 0x95, 0xe5, 0x3a, 0x2b, 0x46, 0xfc,
+	0xb9, 0xe6, 0xb2, 0x60, 0xf9, 0x88, 0x95, 0xd9, 0x68, 0x71, 0x6c, 0x7e, 0x86, 0xa5, 0x14, 0x5a,
+	0x90, 0xf6, 0xe2, 0x38, 0xfe, 0xab, 0x05, 0xfb, 0xcf, 0x34, 0x93, 0x9a, 0xf2, 0x8b, 0x8a, 0x2b,
+	0x4d, 0xee, 0x41, 0x8f, 0x95, 0xd9, 0x64, 0xc1, 0xa5, 0xca, 0x44, 0x11, 0xb5, 0x0e, 0x5b, 0x47,
+	0x7d, 0x0a, 0xac, 0xcc, 0x7e, 0xb2, 0x08, 0x39, 0x82, 0x40, 0x8b, 0x52, 0xe4, 0x62, 0xb6, 0x8c,
+	0xda, 0x87, 0xad, 0xa3, 0xde, 0xc3, 0xfd, 0xe1, 0xe2, 0x78, 0xf8, 0x83, 0xc3, 0x68, 0xa3, 0x25,
+	0x6f, 0xc1, 0x0d, 0xb6, 0x60, 0x59, 0xce, 0xa6, 0x39, 0x9f, 0x24, 0x65, 0xa5, 0x22, 0xef, 0xb0,
+	0x75, 0x14, 0xd2, 0x7e, 0x83, 0x9e, 0x96, 0x95, 0x22, 0x6f, 0x42, 0x5f, 0x72, 0xc5, 0xe5, 0x82,
+	0xa7, 0xd6, 0x6a, 0x0f, 0xad, 0xf6, 0x6b, 0x10, 0x8d, 0xee, 0x81, 0xc7, 0xd2, 0x34, 0xf2, 0x0f,
+	0xbd, 0xa3, 0xde, 0xc3, 0xbe, 0x39, 0xf0, 0x54, 0x14, 0x9a, 0x65, 0x05, 0x97, 0xd4, 0x68, 0x8c,
+	0x41, 0xca, 0xf3, 0xa8, 0xb3, 0xd5, 0x20, 0xe5, 0x79, 0xfc, 0x1d, 0xf4, 0x9e, 0x2d, 0x8b, 0x64,
+	0x75, 0x4f, 0x0c, 0xd8, 0x7a, 0x51, 0xc0, 0xf6, 0xbf, 0x06, 0xfc, 0x1c, 0x06, 0x2b, 0xc4, 0x45,
+	0xbd, 0x0f, 0x61, 0x52, 0x63, 0x58, 0xbb, 0x2b, 0xae, 0x2b, 0x7d, 0x7c, 0x02, 0x03, 0xca, 0xa7,
+	0x2c, 0x67, 0x45, 0xc2, 0xeb, 0x00, 0x0f, 0x00, 0x1a, 0x03, 0xb5, 0x3d, 0xbb, 0x35, 0x83, 0xf8,
+	0x1c, 0xfa, 0x5f, 0xf1, 0x24, 0x33, 0x8d, 0xa1, 0xbc, 0xcc, 0x97, 0xe4, 0x1d, 0x08, 0x53, 0x07,
+	0xd4, 0xee, 0xd8, 0x9e, 0xc6, 0x6a, 0xa5, 0x26, 0x11, 0x74, 0x93, 0x33, 0x56, 0xcc, 0x78, 0x8a,
+	0x8d, 0x0c, 0x68, 0x2d, 0x92, 0xdb, 0xe0, 0x73, 0x29, 0x85, 0x74, 0x0d, 0xb3, 0x42, 0xfc, 0x6b,
+	0x1b, 0x82, 0x3a, 0x0e, 0xb9, 0x03, 0x41, 0xc2, 0x92, 0x33, 0x3e, 0xc9, 0x52, 0xbc, 0x68, 0x48,
+	0xbb, 0x28, 0x7f, 0x9d, 0x9a, 0x22, 0xd4, 0xa4, 0x54, 0x8e, 0x22, 0x78, 0x05, 0x5a, 0x83, 0x74,
+	0xa5, 0x27, 0x77, 0x21, 0x94, 0xa9, 0x9e, 0x24, 0x39, 0x53, 0x35, 0x3f, 0x02, 0x99, 0xea, 0x53,
+	0x23, 0x1b, 0x6a, 0x4c, 0x73, 0x91, 0x9c, 0x67, 0xc2, 0x19, 0x38, 0x6a, 0x38, 0xd0, 0x1a, 0xbd,
+	0x07, 0x1d, 0xfe, 0xbc, 0x14, 0x52, 0x3b, 0x76, 0x44, 0xeb, 0xf7, 0x1d, 0x3e, 0x41, 0xd5, 0x93,
+	0x42, 0xcb, 0x25, 0x75, 0x76, 0x07, 0x1f, 0x43, 0x6f, 0x0d, 0x26, 0x03, 0xf0, 0xce, 0xf9, 0xd2,
+	0xdd, 0xc2, 0x7c, 0x9a, 0xfb, 0x2f, 0x58, 0x5e, 0x71, 0xcc, 0x3e, 0xa4, 0x56, 0x78, 0xd4, 0xfe,
+	0xa8, 0x15, 0x6b, 0x08, 0x6a, 0xa6, 0x93, 0xb7, 0x21, 0x28, 0x59, 0x72, 0xce, 0x66, 0xbc, 0x2e,
+	0x75, 0xcf, 0x1c, 0x3d, 0xb6, 0x18, 0x6d, 0x94, 0x24, 0x06, 0xbf, 0x10, 0x29, 0x16, 0xa3, 0x69,
+	0xc8, 0xd3, 0x6a, 0xce, 0x9e, 0x8a, 0x94, 0x53, 0xab, 0x22, 0x77, 0x61, 0xcf, 0x3d, 0x11, 0x63,
+	0xd2, 0xc5, 0x96, 0x97, 0x15, 0x45, 0x30, 0x3e, 0x85, 0xae, 0x8b, 0x4a, 0x6e, 0x40, 0xdb, 0x55,
+	0xdc, 0xa7, 0xed, 0x2c, 0x25, 0xc4, 0xf9, 0xd9, 0x4c, 0xf1, 0xdb, 0xa4, 0x6f, 0xcf, 0x33, 0xc1,
+	0x7c, 0x77, 0x42, 0xfc, 0x0b, 0x04, 0xf5, 0xa1, 0x57, 0xa2, 0x44, 0xd0, 0x75, 0xd9, 0x62, 0x20,
+	0x9f, 0xd6, 0x62, 0x13, 0xdf, 0x5b, 0x8b, 0xff, 0x0a, 0x74, 0xe6, 0x7c, 0x2e, 0xe4, 0x12, 0xfb,
+	0xb1, 0x47, 0x9d, 0x44, 0x0e, 0x20, 0x48, 0x33, 0xa5, 0x0d, 0x9f, 0xb1, 0x17, 0x3e, 0x6d, 0xe4,
+	0xf8, 0xb7, 0x16, 0x78, 0xa7, 0x65, 0xb5, 0xdb, 0xc9, 0x26, 0x71, 0x3c, 0xd9, 0xa7, 0xf8, 0x8d,
+	0xd9, 0x08, 0xc9, 0xf1, 0x5c, 0x9f, 0xe2, 0xb7, 0x89, 0xa0, 0xcf, 0x24, 0x67, 0xa9, 0x8a, 0x7c,
+	0x4b, 0x44, 0x27, 0x9a, 0x3c, 0x45, 0x91, 0x67, 0x05, 0x8f, 0x3a, 0xc8, 0x6f, 0x27, 0x99, 0x3c,
+	0x33, 0x25, 0x72, 0xa6, 0x79, 0x1a, 0x75, 0x51, 0xd3, 0xc8, 0xf1, 0xdf, 0x6d, 0xf0, 0xc6, 0x22,
+	0x5d, 0xcb, 0x33, 0xc4, 0x3c, 0x07, 0xe0, 0x55, 0x59, 0xea, 0xca, 0x6c, 0x3e, 0x31, 0x3f, 0x36,
+	0xe7, 0x75, 0x65, 0xcc, 0x37, 0x79, 0x15, 0x42, 0xf3, 0xab, 0x4a, 0x96, 0x70, 0x47, 0xd6, 0x15,
+	0x60, 0xb8, 0x7e, 0x21, 0x94, 0xa3, 0xb2, 0xcd, 0x35, 0xb8, 0x10, 0xca, 0xd2, 0xf8, 0x3e, 0x74,
+	0x72, 0x36, 0xe5, 0xb9, 0x72, 0x33, 0xec, 0xff, 0xc8, 0x25, 0x91, 0x0e, 0xbf, 0x41, 0xd4, 0x31,
+	0xd8, 0x9a, 0x90, 0x47, 0xd0, 0x63, 0x45, 0x21, 0x34, 0xd3, 0xf8, 0xd0, 0xbb, 0x2b, 0xe2, 0x1b,
+	0x8f, 0x93, 0x95, 0xca, 0xba, 0xad, 0x1b, 0x9b, 0x47, 0x95, 0xcc, 0xa4, 0xa8, 0xca, 0x49, 0xc9,
+	0x24, 0x2f, 0x74, 0x14, 0xd8, 0x47, 0x65, 0xc1, 0x31, 0x62, 0xe6, 0x89, 0xac, 0x9d, 0xbb, 0xcb,
+	0x13, 0x39, 0x78, 0x0c, 0x83, 0xcb, 0x09, 0xec, 0xf4, 0xc4, 0x7e, 0xf7, 0x21, 0x6c, 0xa6, 0xdd,
+	0x75, 0x73, 0xc6, 0xb6, 0xa8, 0xdd, 0xb4, 0x68, 0x5b, 0x43, 0xee, 0x80, 0x57, 0x8a, 0x14, 0x5b,
+	0xe1, 0x5e, 0xd5, 0x58, 0xa4, 0xd4, 0x60, 0x26, 0x03, 0xa5, 0x99, 0xe6, 0xae, 0x13, 0x56, 0x20,
+	0xc7, 0x97, 0xda, 0x70, 0x67, 0x63, 0xf8, 0x6e, 0x6d, 0xc6, 0x17, 0xdb, 0x9a, 0xf1, 0xfa, 0xa6,
+	0xdf, 0xf5, 0x2d, 0xf9, 0x10, 0x02, 0x69, 0xff, 0x00, 0x54, 0x14, 0xa0, 0xfb, 0xdd, 0x4d, 0x77,
+	0xf7, 0xf7, 0xe0, 0x7c, 0x1b, 0x63, 0xcc, 0x36, 0x9b, 0x67, 0x5a, 0x45, 0xe1, 0xd6, 0x6c, 0x51,
+	0x57, 0x67, 0x8b, 0xc2, 0xe6, 0x74, 0x86, 0x5d, 0xa6, 0x73, 0xef, 0x45, 0xd3, 0x79, 0xff, 0xea,
+	0x74, 0xfe, 0x0f, 0x89, 0x74, 0xf0, 0x09, 0xf4, 0x37, 0xea, 0xb6, 0x93, 0xb3, 0xc9, 0x7b, 0x55,
+	0xbd, 0x9d, 0x08, 0xfc, 0x47, 0x0b, 0xc2, 0xa6, 0x9a, 0xe4, 0x35, 0x80, 0xa4, 0xac, 0x26, 0x25,
+	0x97, 0x99, 0xb0, 0x14, 0xf6, 0x68, 0x98, 0x94, 0xd5, 0x18, 0x01, 0x53, 0x61, 0xa3, 0xbe, 0xa8,
+	0x84, 0x66, 0x18, 0xca, 0xa3, 0x41, 0x52, 0x56, 0xdf, 0x1b, 0xb9, 0xf6, 0x55, 0x67, 0x4c, 0x72,
+	0x3b, 0x82, 0xad, 0xef, 0x33, 0x04, 0xc8, 0x1b, 0xb0, 0x6f, 0x27, 0xef, 0x04, 0x7b, 0x8b, 0x2c,
+	0xf7, 0x68, 0xcf, 0x62, 0x98, 0xbd, 0x59, 0xe7, 0xcc, 0xc8, 0xe6, 0xda, 0xae, 0x56, 0x96, 0xea,
+	0x60, 0x21, 0xb7, 0x58, 0xd5, 0x06, 0x73, 0x3e, 0x57, 0x51, 0x67, 0xdd, 0xe0, 0x5b, 0x3e, 0x57,
+	0x0f, 0xff, 0x6c, 0x43, 0x67, 0x8c, 0x2b, 0x25, 0x19, 0x82, 0x8f, 0xbb, 0x22, 0x19, 0x18, 0xc2,
+	0xac, 0xaf, 0x8d, 0x07, 0xb7, 0x36, 0x96, 0x0c, 0xb3, 0x8a, 0xc4, 0xff, 0x23, 0xef, 0xc2, 0x9e,
+	0x59, 0xb9, 0xc8, 0x4d, 0x34, 0x5f, 0x2d, 0x5f, 0xdb, 0xad, 0x1f, 0xc3, 0xad, 0x93, 0x3c, 0x17,
+	0x09, 0xd3, 0x7c, 0x55, 0xbd, 0xdb, 0x9b, 0xbb, 0xcf, 0x75, 0xfe, 0x9f, 0x99, 0x75, 0x2a, 0xe7,
+	0x4c, 0xbd, 0x9c, 0xfb, 0xa7, 0x70, 0xf3, 0xc7, 0x32, 0x7d, 0xd9, 0xc3, 0x3f, 0x80, 0xb0, 0xd9,
+	0xe5, 0xac, 0xdf, 0xe5, 0xd5, 0x6e, 0xab, 0xdf, 0x97, 0x7b, 0x3f, 0xb7, 0x17, 0xc7, 0xd3, 0x0e,
+	0x2e, 0xe4, 0xef, 0xff, 0x33, 0x00, 0xc9, 0xe2, 0x70, 0x97, 0xd5, 0x0b, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// PolicyClient is the client API for Policy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PolicyClient interface {
+	// Start starts up the policy, passing it the node topology and the initial containers.
+	Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*DecisionReply, error)
+	// Sync synchronizes the policy, allocating/releasing the given containers.
+	Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*DecisionReply, error)
+	// AllocateResources allocates resources for a container.
+	AllocateResources(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*DecisionReply, error)
+	// ReleaseResources releases the resources of a container.
+	ReleaseResources(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*DecisionReply, error)
+	// UpdateResources updates the resource allocations of a container.
+	UpdateResources(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*DecisionReply, error)
+	// Rebalance tries to find a more optimal allocation for the current containers.
+	Rebalance(ctx context.Context, in *RebalanceRequest, opts ...grpc.CallOption) (*DecisionReply, error)
+}
+
+type policyClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPolicyClient(cc *grpc.ClientConn) PolicyClient {
+	return &policyClient{cc}
+}
+
+func (c *policyClient) Start(ctx context.Context, in *StartRequest, opts ...grpc.CallOption) (*DecisionReply, error) {
+	out := new(DecisionReply)
+	err := c.cc.Invoke(ctx, "/v1.Policy/Start", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *policyClient) Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (*DecisionReply, error) {
+	out := new(DecisionReply)
+	err := c.cc.Invoke(ctx, "/v1.Policy/Sync", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *policyClient) AllocateResources(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*DecisionReply, error) {
+	out := new(DecisionReply)
+	err := c.cc.Invoke(ctx, "/v1.Policy/AllocateResources", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *policyClient) ReleaseResources(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*DecisionReply, error) {
+	out := new(DecisionReply)
+	err := c.cc.Invoke(ctx, "/v1.Policy/ReleaseResources", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *policyClient) UpdateResources(ctx context.Context, in *ContainerRequest, opts ...grpc.CallOption) (*DecisionReply, error) {
+	out := new(DecisionReply)
+	err := c.cc.Invoke(ctx, "/v1.Policy/UpdateResources", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *policyClient) Rebalance(ctx context.Context, in *RebalanceRequest, opts ...grpc.CallOption) (*DecisionReply, error) {
+	out := new(DecisionReply)
+	err := c.cc.Invoke(ctx, "/v1.Policy/Rebalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PolicyServer is the server API for Policy service.
+type PolicyServer interface {
+	// Start starts up the policy, passing it the node topology and the initial containers.
+	Start(context.Context, *StartRequest) (*DecisionReply, error)
+	// Sync synchronizes the policy, allocating/releasing the given containers.
+	Sync(context.Context, *SyncRequest) (*DecisionReply, error)
+	// AllocateResources allocates resources for a container.
+	AllocateResources(context.Context, *ContainerRequest) (*DecisionReply, error)
+	// ReleaseResources releases the resources of a container.
+	ReleaseResources(context.Context, *ContainerRequest) (*DecisionReply, error)
+	// UpdateResources updates the resource allocations of a container.
+	UpdateResources(context.Context, *ContainerRequest) (*DecisionReply, error)
+	// Rebalance tries to find a more optimal allocation for the current containers.
+	Rebalance(context.Context, *RebalanceRequest) (*DecisionReply, error)
+}
+
+// UnimplementedPolicyServer can be embedded to have forward compatible implementations.
+type UnimplementedPolicyServer struct {
+}
+
+func (*UnimplementedPolicyServer) Start(ctx context.Context, req *StartRequest) (*DecisionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Start not implemented")
+}
+func (*UnimplementedPolicyServer) Sync(ctx context.Context, req *SyncRequest) (*DecisionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
+func (*UnimplementedPolicyServer) AllocateResources(ctx context.Context, req *ContainerRequest) (*DecisionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AllocateResources not implemented")
+}
+func (*UnimplementedPolicyServer) ReleaseResources(ctx context.Context, req *ContainerRequest) (*DecisionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseResources not implemented")
+}
+func (*UnimplementedPolicyServer) UpdateResources(ctx context.Context, req *ContainerRequest) (*DecisionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateResources not implemented")
+}
+func (*UnimplementedPolicyServer) Rebalance(ctx context.Context, req *RebalanceRequest) (*DecisionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rebalance not implemented")
+}
+
+func RegisterPolicyServer(s *grpc.Server, srv PolicyServer) {
+	s.RegisterService(&_Policy_serviceDesc, srv)
+}
+
+func _Policy_Start_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyServer).Start(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.Policy/Start",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyServer).Start(ctx, req.(*StartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Policy_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyServer).Sync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.Policy/Sync",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyServer).Sync(ctx, req.(*SyncRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Policy_AllocateResources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyServer).AllocateResources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.Policy/AllocateResources",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyServer).AllocateResources(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Policy_ReleaseResources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyServer).ReleaseResources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.Policy/ReleaseResources",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyServer).ReleaseResources(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Policy_UpdateResources_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ContainerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyServer).UpdateResources(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.Policy/UpdateResources",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyServer).UpdateResources(ctx, req.(*ContainerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Policy_Rebalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RebalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PolicyServer).Rebalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/v1.Policy/Rebalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PolicyServer).Rebalance(ctx, req.(*RebalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Policy_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "v1.Policy",
+	HandlerType: (*PolicyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Start",
+			Handler:    _Policy_Start_Handler,
+		},
+		{
+			MethodName: "Sync",
+			Handler:    _Policy_Sync_Handler,
+		},
+		{
+			MethodName: "AllocateResources",
+			Handler:    _Policy_AllocateResources_Handler,
+		},
+		{
+			MethodName: "ReleaseResources",
+			Handler:    _Policy_ReleaseResources_Handler,
+		},
+		{
+			MethodName: "UpdateResources",
+			Handler:    _Policy_UpdateResources_Handler,
+		},
+		{
+			MethodName: "Rebalance",
+			Handler:    _Policy_Rebalance_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "pkg/cri/resource-manager/policy/builtin/external/api/v1/api.proto",
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	api "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/external/api/v1"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+)
+
+// containerStates are the names of container states passed to the external policy.
+var containerStates = map[cache.ContainerState]string{
+	cache.ContainerStateCreating: "creating",
+	cache.ContainerStateCreated:  "created",
+	cache.ContainerStateRunning:  "running",
+	cache.ContainerStateExited:   "exited",
+	cache.ContainerStateUnknown:  "unknown",
+	cache.ContainerStateStale:    "stale",
+}
+
+// containerList converts a list of containers for the external policy.
+func containerList(containers []cache.Container) []*api.Container {
+	list := make([]*api.Container, 0, len(containers))
+	for _, c := range containers {
+		list = append(list, containerMessage(c))
+	}
+	return list
+}
+
+// containerMessage converts a container for the external policy.
+func containerMessage(c cache.Container) *api.Container {
+	msg := &api.Container{
+		CacheId:     c.GetCacheID(),
+		Id:          c.GetID(),
+		Name:        c.GetName(),
+		State:       containerStates[c.GetState()],
+		Labels:      c.GetLabels(),
+		Annotations: c.GetAnnotations(),
+		Requests:    map[string]string{},
+		Limits:      map[string]string{},
+		Resources: &api.Resources{
+			CpuPeriod:   c.GetCPUPeriod(),
+			CpuQuota:    c.GetCPUQuota(),
+			CpuShares:   c.GetCPUShares(),
+			MemoryLimit: c.GetMemoryLimit(),
+			CpusetCpus:  c.GetCpusetCpus(),
+			CpusetMems:  c.GetCpusetMems(),
+		},
+		RdtClass:     c.GetRDTClass(),
+		BlockioClass: c.GetBlockIOClass(),
+	}
+
+	resources := c.GetResourceRequirements()
+	for name, qty := range resources.Requests {
+		msg.Requests[string(name)] = qty.String()
+	}
+	for name, qty := range resources.Limits {
+		msg.Limits[string(name)] = qty.String()
+	}
+
+	if pod, ok := c.GetPod(); ok {
+		msg.Pod = podMessage(pod)
+	}
+
+	return msg
+}
+
+// podMessage converts a pod for the external policy.
+func podMessage(p cache.Pod) *api.Pod {
+	msg := &api.Pod{
+		Id:           p.GetID(),
+		Uid:          p.GetUID(),
+		Name:         p.GetName(),
+		Namespace:    p.GetNamespace(),
+		QosClass:     string(p.GetQOSClass()),
+		Labels:       map[string]string{},
+		Annotations:  map[string]string{},
+		CgroupParent: p.GetCgroupParentDir(),
+	}
+	for _, key := range p.GetLabelKeys() {
+		msg.Labels[key], _ = p.GetLabel(key)
+	}
+	for _, key := range p.GetAnnotationKeys() {
+		msg.Annotations[key], _ = p.GetAnnotation(key)
+	}
+	return msg
+}
+
+// topologyMessage converts the system topology for the external policy.
+func topologyMessage(sys *system.System) *api.Topology {
+	msg := &api.Topology{}
+
+	for _, id := range sys.PackageIDs() {
+		pkg := sys.Package(id)
+		p := &api.Package{Id: int32(id), Cpus: pkg.CPUSet().String()}
+		for _, node := range pkg.NodeIDs() {
+			p.Nodes = append(p.Nodes, int32(node))
+		}
+		msg.Packages = append(msg.Packages, p)
+	}
+
+	for _, id := range sys.NodeIDs() {
+		node := sys.Node(id)
+		n := &api.NumaNode{
+			Id:      int32(id),
+			Package: int32(node.PackageID()),
+			Cpus:    node.CPUSet().String(),
+		}
+		if info, err := node.MemoryInfo(); err == nil {
+			n.Memory = info.MemTotal
+		}
+		for _, d := range node.Distance() {
+			n.Distance = append(n.Distance, int32(d))
+		}
+		msg.Nodes = append(msg.Nodes, n)
+	}
+
+	for _, id := range sys.CPUIDs() {
+		cpu := sys.CPU(id)
+		msg.Cpus = append(msg.Cpus, &api.Cpu{
+			Id:       int32(id),
+			Package:  int32(cpu.PackageID()),
+			Node:     int32(cpu.NodeID()),
+			Core:     int32(cpu.CoreID()),
+			Threads:  cpu.ThreadCPUSet().String(),
+			Online:   cpu.Online(),
+			Isolated: cpu.Isolated(),
+		})
+	}
+
+	return msg
+}
+
+// cpuConstraint converts a CPU constraint for the external policy.
+func cpuConstraint(constraints policy.ConstraintSet) string {
+	switch c := constraints[policy.DomainCPU].(type) {
+	case cpuset.CPUSet:
+		return c.String()
+	case resapi.Quantity:
+		return c.String()
+	}
+	return ""
+}
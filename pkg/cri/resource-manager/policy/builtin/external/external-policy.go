@@ -0,0 +1,349 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	api "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/external/api/v1"
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+)
+
+const (
+	// PolicyName is the symbol used to pull us in as a builtin policy.
+	PolicyName = "external"
+	// PolicyDescription is a short description of this policy.
+	PolicyDescription = "A proxy for a policy running as an external process."
+	// PolicyPath is the path of this policy in the configuration hierarchy.
+	PolicyPath = "policy." + PolicyName
+)
+
+//
+// The external policy forwards all policy requests over gRPC to a policy
+// implemented by an external process. The external policy replies with its
+// decisions, which we record in the cache. Decisions are then enforced the
+// same way as for builtin policies, by the resource manager using CRI
+// requests and by the controllers using their hooks.
+//
+
+type external struct {
+	logger.Logger
+	cache     cache.Cache                  // resource manager cache
+	sys       *system.System               // system/topology information
+	available policy.ConstraintSet         // resource availability constraints
+	reserved  policy.ConstraintSet         // system/kube-reservation constraints
+	conn      *grpc.ClientConn             // connection to the external policy
+	cli       api.PolicyClient             // external policy client
+	exported  map[string]map[string]string // exported resource data by container
+}
+
+// placement is the CPU and memory placement of a container.
+type placement struct {
+	cpus string
+	mems string
+}
+
+// placementOf returns the current placement of the container.
+func placementOf(c cache.Container) placement {
+	return placement{cpus: c.GetCpusetCpus(), mems: c.GetCpusetMems()}
+}
+
+// Make sure external implements the policy backend interface.
+var _ policy.Backend = &external{}
+
+// CreateExternalPolicy creates a new policy instance.
+func CreateExternalPolicy(opts *policy.BackendOptions) policy.Backend {
+	e := &external{
+		Logger:    logger.NewLogger(PolicyName),
+		cache:     opts.Cache,
+		sys:       opts.System,
+		available: opts.Available,
+		reserved:  opts.Reserved,
+		exported:  make(map[string]map[string]string),
+	}
+
+	e.Info("creating policy, using external policy at %s...", opt.Socket)
+
+	return e
+}
+
+// Name returns the name of this policy.
+func (e *external) Name() string {
+	return PolicyName
+}
+
+// Description returns the description for this policy.
+func (e *external) Description() string {
+	return PolicyDescription
+}
+
+// Start prepares this policy for accepting allocation/release requests.
+func (e *external) Start(add []cache.Container, del []cache.Container) error {
+	e.Debug("starting external policy...")
+
+	if err := e.connect(); err != nil {
+		return err
+	}
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	req := &api.StartRequest{
+		ApiVersion:    api.APIVersion,
+		Topology:      topologyMessage(e.sys),
+		AvailableCpus: cpuConstraint(e.available),
+		ReservedCpus:  cpuConstraint(e.reserved),
+		Add:           containerList(add),
+		Del:           containerList(del),
+	}
+	_, err := e.apply("Start", e.forget(del), func() (*api.DecisionReply, error) {
+		return e.cli.Start(ctx, req)
+	})
+	return err
+}
+
+// Stop deactivates this policy, closing our connection to the external policy.
+func (e *external) Stop() {
+	e.Debug("stopping external policy...")
+
+	if e.conn == nil {
+		return
+	}
+	if err := e.conn.Close(); err != nil {
+		e.Warn("failed to close connection to external policy: %v", err)
+	}
+}
+
+// Sync synchronizes the active policy state.
+func (e *external) Sync(add []cache.Container, del []cache.Container) error {
+	e.Debug("synchronizing external policy...")
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	req := &api.SyncRequest{
+		Add: containerList(add),
+		Del: containerList(del),
+	}
+	_, err := e.apply("Sync", e.forget(del), func() (*api.DecisionReply, error) {
+		return e.cli.Sync(ctx, req)
+	})
+	return err
+}
+
+// AllocateResources is a resource allocation request for this policy.
+func (e *external) AllocateResources(c cache.Container) error {
+	e.Debug("allocating resources for %s...", c.PrettyName())
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	req := &api.ContainerRequest{Container: containerMessage(c)}
+	_, err := e.apply("AllocateResources", nil, func() (*api.DecisionReply, error) {
+		return e.cli.AllocateResources(ctx, req)
+	})
+	return err
+}
+
+// ReleaseResources is a resource release request for this policy.
+func (e *external) ReleaseResources(c cache.Container) error {
+	e.Debug("releasing resources of %s...", c.PrettyName())
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	req := &api.ContainerRequest{Container: containerMessage(c)}
+	_, err := e.apply("ReleaseResources", e.forget([]cache.Container{c}), func() (*api.DecisionReply, error) {
+		return e.cli.ReleaseResources(ctx, req)
+	})
+	return err
+}
+
+// UpdateResources is a resource allocation update request for this policy.
+func (e *external) UpdateResources(c cache.Container) error {
+	e.Debug("updating resources of %s...", c.PrettyName())
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	req := &api.ContainerRequest{Container: containerMessage(c)}
+	_, err := e.apply("UpdateResources", nil, func() (*api.DecisionReply, error) {
+		return e.cli.UpdateResources(ctx, req)
+	})
+	return err
+}
+
+// Rebalance tries to find an optimal allocation of resources for the current containers.
+func (e *external) Rebalance() (int, error) {
+	e.Debug("rebalancing containers...")
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	containers := e.cache.GetContainers()
+	before := make(map[string]placement, len(containers))
+	for _, c := range containers {
+		before[c.GetCacheID()] = placementOf(c)
+	}
+
+	req := &api.RebalanceRequest{Containers: containerList(containers)}
+	reply, err := e.apply("Rebalance", nil, func() (*api.DecisionReply, error) {
+		return e.cli.Rebalance(ctx, req)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// decisions might repeat the current placement, count only real moves
+	moves := 0
+	for _, c := range containers {
+		if placementOf(c) != before[c.GetCacheID()] {
+			moves++
+		}
+	}
+	if moves == 0 && reply.Changed {
+		e.Debug("external policy reported changes, but no container was moved")
+	}
+
+	return moves, nil
+}
+
+// ExportResourceData provides resource data to export for the container.
+func (e *external) ExportResourceData(c cache.Container) map[string]string {
+	return e.exported[c.GetCacheID()]
+}
+
+// connect creates our connection to the external policy, if we don't have one yet.
+func (e *external) connect() error {
+	if e.conn != nil {
+		return nil
+	}
+
+	e.Info("connecting to external policy at %s...", opt.Socket)
+
+	ctx, cancel := e.context()
+	defer cancel()
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.FailOnNonTempDialError(true),
+		grpc.WithDialer(func(socket string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", socket, timeout)
+		}),
+	}
+	conn, err := grpc.DialContext(ctx, opt.Socket, dialOpts...)
+	if err != nil {
+		return policyError("failed to connect to external policy at %s: %v", opt.Socket, err)
+	}
+	e.conn = conn
+	e.cli = api.NewPolicyClient(conn)
+
+	return nil
+}
+
+// context returns a context for a request to the external policy.
+func (e *external) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(opt.Timeout))
+}
+
+// forget returns a function to forget the exported data of released containers.
+func (e *external) forget(containers []cache.Container) func() {
+	return func() {
+		for _, c := range containers {
+			delete(e.exported, c.GetCacheID())
+		}
+	}
+}
+
+// apply sends a request to the external policy and applies the decisions in the reply.
+func (e *external) apply(method string, onSuccess func(), send func() (*api.DecisionReply, error)) (*api.DecisionReply, error) {
+	reply, err := send()
+	if err != nil {
+		return nil, policyError("%s request failed: %v", method, err)
+	}
+	if reply.Error != "" {
+		return nil, policyError("%s request failed: external policy error: %s",
+			method, reply.Error)
+	}
+
+	for _, d := range reply.Decisions {
+		c, ok := e.cache.LookupContainer(d.CacheId)
+		if !ok {
+			e.Warn("%s: ignoring decision for unknown container %s", method, d.CacheId)
+			continue
+		}
+		e.applyDecision(c, d)
+	}
+
+	if onSuccess != nil {
+		onSuccess()
+	}
+
+	return reply, nil
+}
+
+// applyDecision records a decision of the external policy for a container.
+func (e *external) applyDecision(c cache.Container, d *api.Decision) {
+	e.Debug("applying decision %s for %s", d.String(), c.PrettyName())
+
+	if r := d.Resources; r != nil {
+		if r.CpusetCpus != "" {
+			c.SetCpusetCpus(r.CpusetCpus)
+		}
+		if r.CpusetMems != "" {
+			c.SetCpusetMems(r.CpusetMems)
+		}
+		if r.CpuShares != 0 {
+			c.SetCPUShares(r.CpuShares)
+		}
+		if r.CpuQuota != 0 {
+			c.SetCPUQuota(r.CpuQuota)
+		}
+		if r.CpuPeriod != 0 {
+			c.SetCPUPeriod(r.CpuPeriod)
+		}
+		if r.MemoryLimit != 0 {
+			c.SetMemoryLimit(r.MemoryLimit)
+		}
+	}
+	if d.RdtClass != "" {
+		c.SetRDTClass(d.RdtClass)
+	}
+	if d.BlockioClass != "" {
+		c.SetBlockIOClass(d.BlockioClass)
+	}
+	if d.Export != nil {
+		e.exported[c.GetCacheID()] = d.Export
+	}
+}
+
+// policyError creates a formatted policy-specific error.
+func policyError(format string, args ...interface{}) error {
+	return fmt.Errorf(PolicyName+": "+format, args...)
+}
+
+// Register us as a policy implementation.
+func init() {
+	policy.Register(PolicyName, PolicyDescription, CreateExternalPolicy)
+}
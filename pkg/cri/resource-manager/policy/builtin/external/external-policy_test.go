@@ -0,0 +1,241 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	api "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/external/api/v1"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+)
+
+// mockContainer implements the parts of cache.Container used by the policy.
+type mockContainer struct {
+	cache.Container
+	id       string
+	cpus     string
+	mems     string
+	shares   int64
+	rdtClass string
+	blkClass string
+}
+
+func (m *mockContainer) PrettyName() string             { return m.id }
+func (m *mockContainer) GetCacheID() string             { return m.id }
+func (m *mockContainer) GetID() string                  { return m.id }
+func (m *mockContainer) GetName() string                { return m.id }
+func (m *mockContainer) GetPod() (cache.Pod, bool)      { return nil, false }
+func (m *mockContainer) GetState() cache.ContainerState { return cache.ContainerStateCreating }
+func (m *mockContainer) GetLabels() map[string]string   { return nil }
+func (m *mockContainer) GetAnnotations() map[string]string {
+	return nil
+}
+func (m *mockContainer) GetResourceRequirements() v1.ResourceRequirements {
+	return v1.ResourceRequirements{}
+}
+func (m *mockContainer) GetCPUPeriod() int64        { return 0 }
+func (m *mockContainer) GetCPUQuota() int64         { return 0 }
+func (m *mockContainer) GetCPUShares() int64        { return m.shares }
+func (m *mockContainer) GetMemoryLimit() int64      { return 0 }
+func (m *mockContainer) GetCpusetCpus() string      { return m.cpus }
+func (m *mockContainer) GetCpusetMems() string      { return m.mems }
+func (m *mockContainer) GetRDTClass() string        { return m.rdtClass }
+func (m *mockContainer) GetBlockIOClass() string    { return m.blkClass }
+func (m *mockContainer) SetCpusetCpus(cpus string)  { m.cpus = cpus }
+func (m *mockContainer) SetCpusetMems(mems string)  { m.mems = mems }
+func (m *mockContainer) SetCPUShares(shares int64)  { m.shares = shares }
+func (m *mockContainer) SetRDTClass(class string)   { m.rdtClass = class }
+func (m *mockContainer) SetBlockIOClass(cls string) { m.blkClass = cls }
+
+// mockCache implements the parts of cache.Cache used by the policy.
+type mockCache struct {
+	cache.Cache
+	containers map[string]*mockContainer
+}
+
+func (m *mockCache) LookupContainer(id string) (cache.Container, bool) {
+	c, ok := m.containers[id]
+	return c, ok
+}
+
+func (m *mockCache) GetContainers() []cache.Container {
+	containers := []cache.Container{}
+	for _, c := range m.containers {
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+// mockPolicy is an external policy which pins every container to CPU #1.
+type mockPolicy struct {
+	api.UnimplementedPolicyServer
+}
+
+func (m *mockPolicy) Start(ctx context.Context, req *api.StartRequest) (*api.DecisionReply, error) {
+	return &api.DecisionReply{}, nil
+}
+
+func (m *mockPolicy) AllocateResources(ctx context.Context, req *api.ContainerRequest) (*api.DecisionReply, error) {
+	if req.Container.Name == "fail" {
+		return &api.DecisionReply{Error: "no resources left"}, nil
+	}
+	return &api.DecisionReply{
+		Decisions: []*api.Decision{
+			{
+				CacheId: req.Container.CacheId,
+				Resources: &api.Resources{
+					CpusetCpus: "1",
+					CpusetMems: "0",
+					CpuShares:  1024,
+				},
+				RdtClass: "Guaranteed",
+				Export:   map[string]string{"SHARED_CPUS": "1"},
+			},
+			{
+				CacheId:  "unknown",
+				RdtClass: "Burstable",
+			},
+		},
+	}, nil
+}
+
+func (m *mockPolicy) ReleaseResources(ctx context.Context, req *api.ContainerRequest) (*api.DecisionReply, error) {
+	return &api.DecisionReply{}, nil
+}
+
+func (m *mockPolicy) Rebalance(ctx context.Context, req *api.RebalanceRequest) (*api.DecisionReply, error) {
+	reply := &api.DecisionReply{Changed: true}
+	for _, c := range req.Containers {
+		reply.Decisions = append(reply.Decisions, &api.Decision{
+			CacheId:   c.CacheId,
+			Resources: &api.Resources{CpusetCpus: "1"},
+		})
+	}
+	return reply, nil
+}
+
+// startMockPolicy starts a mock external policy on a temporary socket.
+func startMockPolicy(t *testing.T) (*grpc.Server, string) {
+	dir, err := ioutil.TempDir("", "external-policy-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	socket := filepath.Join(dir, "policy.sock")
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("failed to listen on %s: %v", socket, err)
+	}
+	srv := grpc.NewServer()
+	api.RegisterPolicyServer(srv, &mockPolicy{})
+	go srv.Serve(lis)
+	return srv, dir
+}
+
+func TestExternalPolicy(t *testing.T) {
+	srv, dir := startMockPolicy(t)
+	defer os.RemoveAll(dir)
+	defer srv.Stop()
+
+	opt.Socket = filepath.Join(dir, "policy.sock")
+	opt.Timeout = Duration(5 * time.Second)
+
+	c0 := &mockContainer{id: "c0"}
+	c1 := &mockContainer{id: "c1", cpus: "1"}
+	cch := &mockCache{containers: map[string]*mockContainer{"c0": c0, "c1": c1}}
+	sys, err := system.DiscoverSystemAt(dir)
+	if err != nil {
+		t.Fatalf("failed to discover empty system: %v", err)
+	}
+	p := CreateExternalPolicy(&policy.BackendOptions{Cache: cch, System: sys}).(*external)
+	if err := p.Start(nil, nil); err != nil {
+		t.Fatalf("failed to start policy: %v", err)
+	}
+
+	if err := p.AllocateResources(c0); err != nil {
+		t.Fatalf("unexpected allocation error: %v", err)
+	}
+	if c0.cpus != "1" || c0.mems != "0" || c0.shares != 1024 || c0.rdtClass != "Guaranteed" {
+		t.Errorf("decision not applied, got cpus %q, mems %q, shares %d, RDT class %q",
+			c0.cpus, c0.mems, c0.shares, c0.rdtClass)
+	}
+	if c0.blkClass != "" {
+		t.Errorf("unexpected block I/O class %q", c0.blkClass)
+	}
+	if data := p.ExportResourceData(c0); data["SHARED_CPUS"] != "1" {
+		t.Errorf("unexpected exported data %v", data)
+	}
+
+	if err := p.AllocateResources(&mockContainer{id: "fail"}); err == nil {
+		t.Errorf("expected allocation error, got none")
+	}
+
+	c0.cpus = "0"
+	moved, err := p.Rebalance()
+	if err != nil {
+		t.Fatalf("unexpected rebalancing error: %v", err)
+	}
+	if moved != 1 || c0.cpus != "1" || c1.cpus != "1" {
+		t.Errorf("expected rebalancing to move only c0 to CPU #1, got %d moves, %q, %q",
+			moved, c0.cpus, c1.cpus)
+	}
+	if moved, _ = p.Rebalance(); moved != 0 {
+		t.Errorf("expected no changes on second rebalancing, got %d moves", moved)
+	}
+
+	if err := p.ReleaseResources(c0); err != nil {
+		t.Fatalf("unexpected release error: %v", err)
+	}
+	if data := p.ExportResourceData(c0); data != nil {
+		t.Errorf("expected no exported data after release, got %v", data)
+	}
+
+	if err := p.UpdateResources(c0); err == nil {
+		t.Errorf("expected error for unimplemented request, got none")
+	}
+	p.Stop()
+	if state := p.conn.GetState(); state != connectivity.Shutdown {
+		t.Errorf("expected connection to be shut down after stopping, got %s", state)
+	}
+}
+
+func TestExternalPolicyConnectionFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "external-policy-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt.Socket = filepath.Join(dir, "no-policy.sock")
+	opt.Timeout = Duration(time.Second)
+
+	p := CreateExternalPolicy(&policy.BackendOptions{Cache: &mockCache{}}).(*external)
+	if err := p.Start(nil, nil); err == nil {
+		t.Errorf("expected start to fail without an external policy, got no error")
+	}
+	p.Stop()
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package external
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/sockets"
+)
+
+// Options captures our configurable policy parameters.
+type options struct {
+	// Socket is the socket the external policy listens on.
+	Socket string
+	// Timeout is the timeout for requests to the external policy.
+	Timeout Duration
+}
+
+// Duration is a time.Duration which (un)marshals as a string, like "5s".
+type Duration time.Duration
+
+// Our runtime configuration.
+var opt = defaultOptions().(*options)
+
+// UnmarshalJSON implements the unmarshaller function for "encoding/json"
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return policyError("invalid duration '%s': %v", string(data), err)
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return policyError("invalid duration '%s': %v", value, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON implements the marshaller function for "encoding/json"
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ConfigSchema describes Duration for config schema generation.
+func (d *Duration) ConfigSchema() *config.Schema {
+	return &config.Schema{Type: "string", Description: "Duration, for instance 5s."}
+}
+
+// defaultOptions returns a new options instance, all initialized to defaults.
+func defaultOptions() interface{} {
+	return &options{
+		Socket:  sockets.ExternalPolicy,
+		Timeout: Duration(5 * time.Second),
+	}
+}
+
+// Register us for configuration handling.
+func init() {
+	config.Register(PolicyPath, PolicyDescription, opt, defaultOptions)
+}
@@ -82,9 +82,9 @@ func (n *none) UpdateResources(c cache.Container) error {
 }
 
 // Rebalance tries to find an optimal allocation of resources for the current containers.
-func (n *none) Rebalance() (bool, error) {
+func (n *none) Rebalance() (int, error) {
 	n.Debug("(not) rebalancing containers...")
-	return false, nil
+	return 0, nil
 }
 
 // ExportResourceData provides resource data to export for the container.
@@ -161,14 +161,45 @@ func (p *staticplus) ReleaseResources(c cache.Container) error {
 
 // UpdateResources is a resource allocation update request for this policy.
 func (p *staticplus) UpdateResources(c cache.Container) error {
-	p.Debug("(not) updating container %s...", c.PrettyName())
+	id := c.GetCacheID()
+
+	p.Debug("updating container %s...", id)
+
+	a, ok := p.allocations[id]
+	if !ok {
+		return nil
+	}
+
+	full, part := p.requestedCpus(c)
+	if c.GetNamespace() == metav1.NamespaceSystem {
+		full, part = 0, 1000*full+part
+	}
+	if a.exclusive.Size() == full && a.shared == part {
+		return nil
+	}
+
+	if err := p.ReleaseResources(c); err != nil {
+		return err
+	}
+
+	if err := p.AllocateResources(c); err != nil {
+		// put the container back to its old CPUs, nobody could have taken them since
+		p.Warn("failed to update container %s, restoring old assignment", id)
+		p.isolated = p.isolated.Difference(a.exclusive)
+		p.shared = p.shared.Difference(a.exclusive)
+		if rerr := p.addAssignment(c, a); rerr != nil {
+			p.Error("failed to restore assignment of container %s: %v", id, rerr)
+		}
+		return err
+	}
+
 	return nil
 }
 
 // Rebalance tries to find an optimal allocation of resources for the current containers.
-func (p *staticplus) Rebalance() (bool, error) {
+func (p *staticplus) Rebalance() (int, error) {
 	p.Debug("(not) rebalancing containers...")
-	return false, nil
+	return 0, nil
 }
 
 // ExportResourceData provides resource data to export for the container.
@@ -358,17 +389,16 @@ func (p *staticplus) assignCpus(c cache.Container) (*Assignment, error) {
 
 	// if there is capacity in the isolated pool, slice cpus off from it
 	if p.isolated.Size() >= full && !p.optOutFromIsolation(c) {
-		cpus, err := takeCPUs(&p.isolated, nil, full)
-		if err != nil {
-			return nil, policyError("failed to allocate %d isolated CPUs: %v",
-				full, err)
+		cpus, err := policy.AllocateCPUsByHints(p.sys, &p.isolated, full, c)
+		if err == nil {
+			return &Assignment{exclusive: cpus, shared: part}, nil
 		}
-		return &Assignment{exclusive: cpus, shared: part}, nil
+		p.Info("%v, trying shared pool", err)
 	}
 
-	// otherwise, try to slice off cpus from the shared pool
+	// otherwise, try to slice off cpus from the shared pool, with the same hint strictness
 	if p.shared.Size() >= full {
-		cpus, err := takeCPUs(&p.shared, nil, full)
+		cpus, err := policy.AllocateCPUsByHints(p.sys, &p.shared, full, c)
 		if err != nil {
 			return nil, policyError("failed to allocate %d exclusive CPUs: %v",
 				full, err)
@@ -22,6 +22,8 @@ import (
 	"strconv"
 	"strings"
 
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
 	"github.com/intel/cri-resource-manager/pkg/config"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/agent"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
@@ -55,9 +57,11 @@ const (
 type stp struct {
 	logger.Logger
 
-	conf  *conf           // STP policy configuration
-	state cache.Cache     // state cache
-	agent agent.Interface // client connection to cri-resmgr agent gRPC server
+	conf      *conf           // STP policy configuration
+	state     cache.Cache     // state cache
+	agent     agent.Interface // client connection to cri-resmgr agent gRPC server
+	available cpuset.CPUSet   // CPUs available to us, empty if not restricted
+	detach    func()          // detaches our configuration callbacks
 }
 
 var _ policy.Backend = &stp{}
@@ -75,6 +79,10 @@ func CreateStpPolicy(opts *policy.BackendOptions) policy.Backend {
 		state:  opts.Cache,
 	}
 
+	if cset, ok := opts.Available[policy.DomainCPU].(cpuset.CPUSet); ok {
+		stp.available = cset
+	}
+
 	stp.Info("creating policy...")
 
 	// Read STP configuration
@@ -94,7 +102,12 @@ func CreateStpPolicy(opts *policy.BackendOptions) policy.Backend {
 		}
 	}
 
-	config.GetModule(PolicyPath).AddNotify(stp.configNotify)
+	detachNotify := config.GetModule(PolicyPath).AttachNotify(stp.configNotify)
+	detachCheck := config.GetModule(PolicyPath).AttachCheck(stp.configCheck)
+	stp.detach = func() {
+		detachNotify()
+		detachCheck()
+	}
 
 	stp.DebugBlock("  configuration ", "%s", utils.DumpJSON(stp.conf))
 
@@ -138,6 +151,11 @@ func (stp *stp) Start(add []cache.Container, del []cache.Container) error {
 	return nil
 }
 
+// Stop deactivates this policy.
+func (stp *stp) Stop() {
+	stp.detach()
+}
+
 // Sync synchronizes the state of this policy.
 func (stp *stp) Sync(add []cache.Container, del []cache.Container) error {
 	stp.Debug("synchronizing state...")
@@ -238,9 +256,9 @@ func (stp *stp) UpdateResources(c cache.Container) error {
 }
 
 // Rebalance tries to find an optimal allocation of resources for the current containers.
-func (stp *stp) Rebalance() (bool, error) {
+func (stp *stp) Rebalance() (int, error) {
 	stp.Debug("(not) rebalancing containers...")
-	return false, nil
+	return 0, nil
 }
 
 // ExportResourceData provides resource data to export for the container.
@@ -248,6 +266,11 @@ func (stp *stp) ExportResourceData(c cache.Container) map[string]string {
 	return nil
 }
 
+// configCheck checks a candidate configuration without taking it into use.
+func (stp *stp) configCheck(candidate interface{}, source config.Source) error {
+	return stp.verifyConfig(candidate.(*conf))
+}
+
 func (stp *stp) configNotify(event config.Event, source config.Source) error {
 	stp.Info("configuration %s", event)
 
@@ -293,6 +316,23 @@ func (stp *stp) verifyConfig(cfg *conf) error {
 		return stpError("invalid config, no pools configured")
 	}
 
+	// Check that all pools stay within the available CPUs
+	if !stp.available.IsEmpty() {
+		for name, pool := range cfg.Pools {
+			for _, clist := range pool.CPULists {
+				cset, err := cpuset.Parse(clist.Cpuset)
+				if err != nil {
+					return stpError("invalid stp configuration: pool %q: invalid cpu list %q: %v",
+						name, clist.Cpuset, err)
+				}
+				if !cset.IsSubsetOf(stp.available) {
+					return stpError("invalid stp configuration: pool %q: cpu list %q outside available CPUs %s",
+						name, clist.Cpuset, stp.available)
+				}
+			}
+		}
+	}
+
 	// Loop through all existing containers
 	ccr := stp.getContainerRegistry()
 	for id, cs := range *ccr {
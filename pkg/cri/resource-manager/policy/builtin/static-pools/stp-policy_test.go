@@ -21,8 +21,11 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
 
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 	logger "github.com/intel/cri-resource-manager/pkg/log"
+	"github.com/intel/cri-resource-manager/pkg/testutils"
 )
 
 func TestParseContainerCmdline(t *testing.T) {
@@ -85,3 +88,42 @@ func TestCachableData(t *testing.T) {
 		t.Errorf("Exptected %v but got %v", *ccr, *ccr2)
 	}
 }
+
+func TestVerifyConfigAvailable(t *testing.T) {
+	dir, cleanup := testutils.TempDir(t, "stp-test")
+	defer cleanup()
+
+	cch, err := cache.NewCache(cache.Options{CacheDir: dir})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+
+	cfg := &conf{Pools: map[string]poolConfig{
+		"shared": {CPULists: []*cpuList{{Socket: 0, Cpuset: "2-3"}}},
+	}}
+
+	tcs := []struct {
+		name      string
+		available string
+		invalid   bool
+	}{
+		{name: "unrestricted"},
+		{name: "within available CPUs", available: "1-7"},
+		{name: "outside available CPUs", available: "3-7", invalid: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			stp := &stp{Logger: logger.NewLogger(PolicyName), state: cch}
+			if tc.available != "" {
+				stp.available = cpuset.MustParse(tc.available)
+			}
+			err := stp.verifyConfig(cfg)
+			if tc.invalid && err == nil {
+				t.Errorf("expected an error for available CPUs %s", tc.available)
+			}
+			if !tc.invalid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
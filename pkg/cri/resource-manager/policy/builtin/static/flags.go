@@ -76,6 +76,11 @@ func (t Tristate) MarshalJSON() ([]byte, error) {
 	return nil, policyError("invalid tristate value %v", t)
 }
 
+// ConfigSchema describes Tristate for config schema generation.
+func (t *Tristate) ConfigSchema() *config.Schema {
+	return config.EnumSchema("Boolean or auto for automatically determined.", false, true, "auto")
+}
+
 // String returns the value of Tristate as a string
 func (t *Tristate) String() string {
 	switch *t {
@@ -52,6 +52,7 @@ type static struct {
 	sys           *sysfs.System        // system/topology information
 	numHT         int                  // number of hyperthreads per core
 	state         cache.Cache          // policy/state cache
+	detach        func()               // detaches our configuration notifier
 }
 
 // Make sure static implements the policy backend interface.
@@ -80,7 +81,7 @@ func NewStaticPolicy(opts *policy.BackendOptions) policy.Backend {
 		s.Fatal("cannot start with given constraints: %v", err)
 	}
 
-	config.GetModule(PolicyPath).AddNotify(s.configNotify)
+	s.detach = config.GetModule(PolicyPath).AttachNotify(s.configNotify)
 
 	return s
 }
@@ -115,6 +116,11 @@ func (s *static) Start(add []cache.Container, del []cache.Container) error {
 	return s.Sync(add, del)
 }
 
+// Stop deactivates this policy.
+func (s *static) Stop() {
+	s.detach()
+}
+
 // Sync synchronizes the active policy state.
 func (s *static) Sync(add []cache.Container, del []cache.Container) error {
 	s.Debug("synchronizing state...")
@@ -156,14 +162,41 @@ func (s *static) ReleaseResources(c cache.Container) error {
 
 // UpdateResources is a resource allocation update request for this policy.
 func (s *static) UpdateResources(c cache.Container) error {
-	s.Debug("(not) updating container %s...", c.PrettyName())
+	s.Info("updating resources of container %s...", c.PrettyName())
+
+	containerID := c.GetCacheID()
+	pod, found := c.GetPod()
+	if !found {
+		return policyError("can't find pod for container %s", containerID)
+	}
+
+	cset, _ := s.GetCPUSet(containerID)
+	if cset.Size() == s.guaranteedCPUs(pod, c) {
+		return nil
+	}
+
+	if err := s.RemoveContainer(containerID); err != nil {
+		return err
+	}
+
+	if err := s.AddContainer(pod, c, containerID); err != nil {
+		// put the container back to its old CPUs, nobody could have taken them since
+		if !cset.IsEmpty() {
+			s.Warn("failed to update %s, restoring CPUs %s", c.PrettyName(), cset)
+			s.SetDefaultCPUSet(s.GetDefaultCPUSet().Difference(cset))
+			s.isolatedCpus = s.isolatedCpus.Difference(cset)
+			s.SetCPUSet(containerID, cset)
+		}
+		return err
+	}
+
 	return nil
 }
 
 // Rebalance tries to find an optimal allocation of resources for the current containers.
-func (s *static) Rebalance() (bool, error) {
+func (s *static) Rebalance() (int, error) {
 	s.Debug("(not) rebalancing containers...")
-	return false, nil
+	return 0, nil
 }
 
 // ExportResourceData provides resource data to export for the container.
@@ -307,9 +340,9 @@ func (s *static) cpuPreference(containerID string, numCPUs int) (bool, bool) {
 }
 
 // allocateOrdinaryCPUs tries to take a number of non-isolated CPUs.
-func (s *static) allocateOrdinaryCPUs(numCPUs int) (cpuset.CPUSet, error) {
+func (s *static) allocateOrdinaryCPUs(numCPUs int, c cache.Container) (cpuset.CPUSet, error) {
 	assignable := s.assignableCPUs(numCPUs)
-	result, err := takeByTopology(assignable, numCPUs)
+	result, err := policy.AllocateCPUsByHints(s.sys, &assignable, numCPUs, c)
 
 	if err != nil {
 		return cpuset.NewCPUSet(), err
@@ -321,13 +354,14 @@ func (s *static) allocateOrdinaryCPUs(numCPUs int) (cpuset.CPUSet, error) {
 }
 
 // allocateIsolatedCPUs tries to take a number of isolated CPUs, falling back to ordinary ones.
-func (s *static) allocateIsolatedCPUs(numCPUs int, prefer bool) (cpuset.CPUSet, error) {
-	result, err := takeByTopology(s.isolatedCpus, numCPUs)
+func (s *static) allocateIsolatedCPUs(numCPUs int, prefer bool, c cache.Container) (cpuset.CPUSet, error) {
+	isolated := s.isolatedCpus.Clone()
+	result, err := policy.AllocateCPUsByHints(s.sys, &isolated, numCPUs, c)
 
 	switch {
 	case err != nil:
 		s.Info("falling back to %d ordinary CPUs", numCPUs)
-		return s.allocateOrdinaryCPUs(numCPUs)
+		return s.allocateOrdinaryCPUs(numCPUs, c)
 	case numCPUs == 1 || prefer:
 		s.Info("allocated %d isolated CPUs: %s", numCPUs, result.String())
 		return result, nil
@@ -336,7 +370,7 @@ func (s *static) allocateIsolatedCPUs(numCPUs int, prefer bool) (cpuset.CPUSet,
 		return result, nil
 	default:
 		s.Info("falling back to %d ordinary CPUs", numCPUs)
-		return s.allocateOrdinaryCPUs(numCPUs)
+		return s.allocateOrdinaryCPUs(numCPUs, c)
 	}
 }
 
@@ -366,10 +400,16 @@ func (s *static) allocateCPUs(numCPUs int, containerID string) (cpuset.CPUSet, e
 
 	s.Info("[cpumanager] allocateCpus: (numCPUs: %d)", numCPUs)
 
+	// the container, if known, provides device topology hints for the allocation
+	c, ok := s.state.LookupContainer(containerID)
+	if !ok {
+		c = nil
+	}
+
 	if try, prefer := s.cpuPreference(containerID, numCPUs); !try {
-		result, err = s.allocateOrdinaryCPUs(numCPUs)
+		result, err = s.allocateOrdinaryCPUs(numCPUs, c)
 	} else {
-		result, err = s.allocateIsolatedCPUs(numCPUs, prefer)
+		result, err = s.allocateIsolatedCPUs(numCPUs, prefer, c)
 	}
 
 	if err != nil {
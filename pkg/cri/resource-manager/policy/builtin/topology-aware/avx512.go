@@ -0,0 +1,91 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+//
+// Heavy AVX512 usage lowers the clock frequency of the cores running the
+// AVX512 instructions, which hurts other, especially latency-sensitive,
+// workloads sharing those cores. If AVX512Pools is configured, we try to
+// co-locate containers tagged as AVX512 users in the given pools (and their
+// children), and to keep all other containers out of them. The resource
+// manager tags containers according to their filtered AVX512 usage and asks
+// us to reallocate a container whenever its tag changes. The CPUs of AVX512
+// pools are left out of the shared and sliceable CPUs of their ancestors, so
+// containers in the ancestors never end up running on them.
+//
+
+// isAvx512User checks if the container is tagged as an AVX512 user.
+func isAvx512User(c cache.Container) bool {
+	_, ok := c.GetTag(cache.TagAVX512)
+	return ok
+}
+
+// isAvx512Pool checks if a pool is dedicated to AVX512 users.
+func isAvx512Pool(n Node) bool {
+	for ; !n.IsNil(); n = n.Parent() {
+		for _, name := range opt.AVX512Pools {
+			if n.Name() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// avx512CPUs returns the CPUs of the AVX512 pools below the given other pool.
+func (p *policy) avx512CPUs(n Node) cpuset.CPUSet {
+	cpus := cpuset.NewCPUSet()
+	if len(opt.AVX512Pools) == 0 || isAvx512Pool(n) {
+		return cpus
+	}
+	for _, name := range opt.AVX512Pools {
+		pool, ok := p.nodes[name]
+		if !ok {
+			continue
+		}
+		for a := pool.Parent(); !a.IsNil(); a = a.Parent() {
+			if a.IsSameNode(n) {
+				supply := pool.GetCPU()
+				cpus = cpus.Union(supply.SharableCPUs()).Union(supply.IsolatedCPUs())
+				break
+			}
+		}
+	}
+	return cpus
+}
+
+// avx512Fits checks if placing the container in the pool matches its AVX512 usage.
+func avx512Fits(c cache.Container, n Node) bool {
+	if len(opt.AVX512Pools) == 0 {
+		return true
+	}
+	return isAvx512User(c) == isAvx512Pool(n)
+}
+
+// checkAvx512Pools checks that all configured AVX512 pools exist.
+func (p *policy) checkAvx512Pools(o *options) error {
+	for _, name := range o.AVX512Pools {
+		if _, ok := p.nodes[name]; !ok {
+			return policyError("invalid AVX512 pool %q, no such pool", name)
+		}
+	}
+	return nil
+}
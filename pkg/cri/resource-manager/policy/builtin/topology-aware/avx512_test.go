@@ -0,0 +1,119 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+const (
+	// the AVX512 pool used in tests and its CPUs
+	testAvx512Pool = "numa node #3"
+	testAvx512CPUs = "6-7"
+)
+
+// setAvx512Pools sets the AVX512 pools, returning a function to restore them.
+func setAvx512Pools(pools ...string) func() {
+	saved := opt.AVX512Pools
+	opt.AVX512Pools = pools
+	return func() {
+		opt.AVX512Pools = saved
+	}
+}
+
+func TestAvx512Placement(t *testing.T) {
+	defer setAvx512Pools(testAvx512Pool)()
+
+	p, containers, cleanup := createRebalanceTest(t, 1)
+	defer cleanup()
+
+	avx512 := cpuset.MustParse(testAvx512CPUs)
+	checkShared := func(c cache.Container) {
+		cpus := cpuset.MustParse(c.GetCpusetCpus())
+		if cpus.IsEmpty() || !cpus.Intersection(avx512).IsEmpty() {
+			t.Errorf("expected %s to stay off AVX512 CPUs %s, got %s",
+				c.PrettyName(), avx512, cpus)
+		}
+	}
+
+	// the shared container in the root pool stays off the AVX512 CPUs
+	checkShared(containers[0])
+
+	// exclusive CPUs sliced off the root pool are never AVX512 ones
+	for i := 0; i < 3; i++ {
+		c := createGuaranteedContainer(t, p.cache, fmt.Sprintf("guaranteed%d", i), 1)
+		grant, err := p.allocateFromPool(p.root, newCPURequest(c))
+		if err != nil {
+			t.Fatalf("failed to allocate %s from %s: %v", c.PrettyName(), p.root.Name(), err)
+		}
+		if exclusive := grant.ExclusiveCPUs(); exclusive.Size() != 1 || !exclusive.Intersection(avx512).IsEmpty() {
+			t.Errorf("expected a single exclusive non-AVX512 CPU, got %s", grant)
+		}
+		if err := p.applyGrant(grant); err != nil {
+			t.Fatalf("failed to apply grant %s: %v", grant, err)
+		}
+		if err := p.updateSharedAllocations(grant); err != nil {
+			t.Fatalf("failed to update shared allocations: %v", err)
+		}
+		checkShared(containers[0])
+	}
+}
+
+func TestAvx512Reallocation(t *testing.T) {
+	defer setAvx512Pools(testAvx512Pool)()
+
+	p, containers, cleanup := createRebalanceTest(t, 1)
+	defer cleanup()
+
+	c := containers[0]
+	tcs := []struct {
+		name    string
+		tag     bool
+		changed bool
+		pool    string
+	}{
+		{"AVX512 user moves to AVX512 pool", true, true, testAvx512Pool},
+		{"AVX512 user stays in AVX512 pool", true, false, testAvx512Pool},
+		{"other container moves out of AVX512 pool", false, true, ""},
+	}
+	for _, tc := range tcs {
+		if tc.tag {
+			c.SetTag(cache.TagAVX512, "true")
+		} else {
+			c.DeleteTag(cache.TagAVX512)
+		}
+
+		changed, err := p.ReallocateResources(c)
+		if err != nil {
+			t.Fatalf("%s: reallocation failed: %v", tc.name, err)
+		}
+		if changed != tc.changed {
+			t.Errorf("%s: expected change %v, got %v", tc.name, tc.changed, changed)
+		}
+
+		node := p.allocations.CPU[c.GetCacheID()].GetNode()
+		if tc.pool != "" && node.Name() != tc.pool {
+			t.Errorf("%s: expected pool %s, got %s", tc.name, tc.pool, node.Name())
+		}
+		if tc.pool == "" && isAvx512Pool(node) {
+			t.Errorf("%s: expected a non-AVX512 pool, got %s", tc.name, node.Name())
+		}
+	}
+}
@@ -17,6 +17,7 @@ package topologyaware
 import (
 	"encoding/json"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
 )
 
@@ -52,10 +53,13 @@ func (p *policy) restoreConfig() bool {
 }
 
 type cachedGrant struct {
-	Exclusive string
-	Part      int
-	Container string
-	Pool      string
+	Exclusive  string
+	Part       int
+	Container  string
+	Pool       string
+	Memory     uint64            `json:",omitempty"`
+	HugePages  map[uint64]uint64 `json:",omitempty"`
+	MemoryPool string            `json:",omitempty"`
 }
 
 func newCachedGrant(cg CPUGrant) *cachedGrant {
@@ -86,6 +90,19 @@ func (ccg *cachedGrant) ToCPUGrant(policy *policy) (CPUGrant, error) {
 	), nil
 }
 
+func (ccg *cachedGrant) ToMemoryGrant(policy *policy) (MemoryGrant, error) {
+	node, ok := policy.nodes[ccg.MemoryPool]
+	if !ok {
+		return nil, policyError("cache error: failed to restore %v, unknown memory pool/node", *ccg)
+	}
+	container, ok := policy.cache.LookupContainer(ccg.Container)
+	if !ok {
+		return nil, policyError("cache error: failed to restore %v, unknown container", *ccg)
+	}
+
+	return newMemoryGrant(node, container, ccg.Memory, ccg.HugePages), nil
+}
+
 func (cg *cpuGrant) MarshalJSON() ([]byte, error) {
 	return json.Marshal(newCachedGrant(cg))
 }
@@ -106,6 +123,13 @@ func (a *allocations) MarshalJSON() ([]byte, error) {
 	cgrants := make(map[string]*cachedGrant)
 	for id, cg := range a.CPU {
 		cgrants[id] = newCachedGrant(cg)
+		if mg, ok := a.Memory[id]; ok {
+			cgrants[id].Memory = mg.MemoryLimit()
+			if len(mg.HugePages()) > 0 {
+				cgrants[id].HugePages = mg.HugePages()
+			}
+			cgrants[id].MemoryPool = mg.GetNode().Name()
+		}
 	}
 
 	return json.Marshal(cgrants)
@@ -120,13 +144,25 @@ func (a *allocations) UnmarshalJSON(data []byte) error {
 	}
 
 	a.CPU = make(map[string]CPUGrant, 32)
+	a.Memory = make(map[string]MemoryGrant, 32)
 	for id, ccg := range cgrants {
 		a.CPU[id], err = ccg.ToCPUGrant(a.policy)
 		if err != nil {
 			log.Error("removing unresolvable cached grant %v: %v", *ccg, err)
 			delete(a.CPU, id)
+			continue
+		}
+		log.Debug("resolved cache grant: %v", a.CPU[id].String())
+
+		if ccg.MemoryPool == "" {
+			continue
+		}
+		a.Memory[id], err = ccg.ToMemoryGrant(a.policy)
+		if err != nil {
+			log.Error("removing unresolvable cached memory grant %v: %v", *ccg, err)
+			delete(a.Memory, id)
 		} else {
-			log.Debug("resolved cache grant: %v", a.CPU[id].String())
+			log.Debug("resolved cache grant: %v", a.Memory[id].String())
 		}
 	}
 
@@ -152,12 +188,19 @@ func (a *allocations) Set(value interface{}) {
 	for id, cg := range from.CPU {
 		a.CPU[id] = cg
 	}
+	a.Memory = make(map[string]MemoryGrant, 32)
+	for id, mg := range from.Memory {
+		a.Memory[id] = mg
+	}
 }
 
 func (a *allocations) Dump(logfn func(format string, args ...interface{}), prefix string) {
 	for _, cg := range a.CPU {
 		logfn(prefix+"%s", cg)
 	}
+	for _, mg := range a.Memory {
+		logfn(prefix+"%s", mg)
+	}
 }
 
 type cachedOptions struct {
@@ -176,3 +219,53 @@ func (o *cachedOptions) Set(value interface{}) {
 		o.Options = value.(*cachedOptions).Options
 	}
 }
+
+// upgradeMemoryGrants upgrades version 1 cached allocations, which lack memory grants.
+// Memory was then always taken from the pool of the CPU grant, so we grant containers
+// the memory they request from that pool.
+func upgradeMemoryGrants(s *cache.RawSnapshot) error {
+	entry, ok := s.PolicyJSON[keyAllocations]
+	if !ok || s.PolicyName != PolicyName {
+		return nil
+	}
+
+	cgrants := make(map[string]*cachedGrant)
+	if err := json.Unmarshal([]byte(entry), &cgrants); err != nil {
+		return policyError("failed to upgrade cached allocations: %v", err)
+	}
+
+	for id, ccg := range cgrants {
+		if ccg.MemoryPool != "" {
+			continue
+		}
+		resources := v1.ResourceRequirements{}
+		if c, ok := s.Containers[id]; ok && c["Resources"] != nil {
+			data, err := json.Marshal(c["Resources"])
+			if err == nil {
+				err = json.Unmarshal(data, &resources)
+			}
+			if err != nil {
+				return policyError("failed to upgrade cached grant of %s: %v", id, err)
+			}
+		}
+		ccg.Memory = memoryOf(resources)
+		if hugepages := hugePagesOf(resources, id); len(hugepages) > 0 {
+			ccg.HugePages = hugepages
+		}
+		ccg.MemoryPool = ccg.Pool
+	}
+
+	data, err := json.Marshal(cgrants)
+	if err != nil {
+		return policyError("failed to upgrade cached allocations: %v", err)
+	}
+	s.PolicyJSON[keyAllocations] = string(data)
+
+	return nil
+}
+
+func init() {
+	if err := cache.RegisterMigration("1", "2", upgradeMemoryGrants); err != nil {
+		log.Error("failed to register cache migration: %v", err)
+	}
+}
@@ -16,9 +16,12 @@ package topologyaware
 
 import (
 	"bytes"
+	"encoding/json"
 	"testing"
 
 	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 )
 
 func TestToCPUGrant(t *testing.T) {
@@ -94,6 +97,14 @@ func TestAllocationMarshalling(t *testing.T) {
 			name: "zero Exclusive",
 			data: []byte(`{"key1":{"Exclusive":"","Part":1,"Container":"1","Pool":"testnode"}}`),
 		},
+		{
+			name: "memory grant",
+			data: []byte(`{"key1":{"Exclusive":"1","Part":1,"Container":"1","Pool":"testnode","Memory":1024,"MemoryPool":"testnode"}}`),
+		},
+		{
+			name: "huge page grant",
+			data: []byte(`{"key1":{"Exclusive":"","Part":0,"Container":"1","Pool":"testnode","HugePages":{"2097152":4194304},"MemoryPool":"testnode"}}`),
+		},
 	}
 	for _, tc := range tcases {
 		t.Run(tc.name, func(t *testing.T) {
@@ -139,3 +150,101 @@ func TestAllocationMarshalling(t *testing.T) {
 		})
 	}
 }
+
+func TestUpgradeMemoryGrants(t *testing.T) {
+	// resources of the containers in the version 1 test snapshot
+	containers := `{
+	  "uid:limit": {"CacheID": "uid:limit", "Resources": {
+	    "requests": {"memory": "512Mi"}, "limits": {"memory": "1Gi"}}},
+	  "uid:request": {"CacheID": "uid:request", "Resources": {"requests": {"memory": "256Mi"}}},
+	  "uid:hugepages": {"CacheID": "uid:hugepages", "Resources": {
+	    "limits": {"memory": "1Gi", "hugepages-2Mi": "4Mi"}}},
+	  "uid:none": {"CacheID": "uid:none"}
+	}`
+
+	tcases := []struct {
+		name     string
+		policy   string
+		grants   string
+		expected map[string]cachedGrant
+	}{
+		{
+			name:   "memory limit",
+			policy: PolicyName,
+			grants: `{"uid:limit":{"Exclusive":"1","Part":0,"Container":"uid:limit","Pool":"NUMA node #0"}}`,
+			expected: map[string]cachedGrant{
+				"uid:limit": {Exclusive: "1", Container: "uid:limit", Pool: "NUMA node #0",
+					Memory: 1 << 30, MemoryPool: "NUMA node #0"},
+			},
+		},
+		{
+			name:   "memory request",
+			policy: PolicyName,
+			grants: `{"uid:request":{"Exclusive":"","Part":200,"Container":"uid:request","Pool":"socket #1"}}`,
+			expected: map[string]cachedGrant{
+				"uid:request": {Part: 200, Container: "uid:request", Pool: "socket #1",
+					Memory: 256 << 20, MemoryPool: "socket #1"},
+			},
+		},
+		{
+			name:   "huge pages",
+			policy: PolicyName,
+			grants: `{"uid:hugepages":{"Exclusive":"","Part":100,"Container":"uid:hugepages","Pool":"root"}}`,
+			expected: map[string]cachedGrant{
+				"uid:hugepages": {Part: 100, Container: "uid:hugepages", Pool: "root",
+					Memory: 1 << 30, HugePages: map[uint64]uint64{2 << 20: 4 << 20}, MemoryPool: "root"},
+			},
+		},
+		{
+			name:   "no resources",
+			policy: PolicyName,
+			grants: `{"uid:none":{"Exclusive":"","Part":2,"Container":"uid:none","Pool":"root"}}`,
+			expected: map[string]cachedGrant{
+				"uid:none": {Part: 2, Container: "uid:none", Pool: "root", MemoryPool: "root"},
+			},
+		},
+		{
+			name:   "other policy",
+			policy: "static-plus",
+			grants: `{"uid:limit":{"Exclusive":"1","Part":0,"Container":"uid:limit","Pool":"NUMA node #0"}}`,
+			expected: map[string]cachedGrant{
+				"uid:limit": {Exclusive: "1", Container: "uid:limit", Pool: "NUMA node #0"},
+			},
+		},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			grants, _ := json.Marshal(tc.grants)
+			data := `{"Version": "1", "Containers": ` + containers + `, "PolicyName": "` + tc.policy +
+				`", "PolicyJSON": {"allocations": ` + string(grants) + `}}`
+			s := &cache.RawSnapshot{}
+			if err := json.Unmarshal([]byte(data), s); err != nil {
+				t.Fatalf("failed to unmarshal test snapshot: %v", err)
+			}
+
+			if err := upgradeMemoryGrants(s); err != nil {
+				t.Fatalf("failed to upgrade snapshot: %v", err)
+			}
+
+			upgraded := map[string]cachedGrant{}
+			if err := json.Unmarshal([]byte(s.PolicyJSON[keyAllocations]), &upgraded); err != nil {
+				t.Fatalf("failed to unmarshal upgraded allocations: %v", err)
+			}
+			for id, expected := range tc.expected {
+				got := upgraded[id]
+				if got.Exclusive != expected.Exclusive || got.Part != expected.Part ||
+					got.Container != expected.Container || got.Pool != expected.Pool ||
+					got.Memory != expected.Memory || got.MemoryPool != expected.MemoryPool ||
+					len(got.HugePages) != len(expected.HugePages) {
+					t.Errorf("expected grant %+v, got %+v", expected, got)
+				}
+				for size, amount := range expected.HugePages {
+					if got.HugePages[size] != amount {
+						t.Errorf("expected %d bytes of huge pages of size %d, got %d",
+							amount, size, got.HugePages[size])
+					}
+				}
+			}
+		})
+	}
+}
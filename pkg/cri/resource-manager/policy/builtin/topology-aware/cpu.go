@@ -46,6 +46,8 @@ type CPUSupply interface {
 	Allocate(CPURequest) (CPUGrant, error)
 	// Release releases a previously allocated grant.
 	Release(CPUGrant)
+	// Reserve accounts for an existing grant, for instance one restored from a snapshot.
+	Reserve(CPUGrant)
 	// String returns a printable representation of this supply.
 	String() string
 }
@@ -96,6 +98,8 @@ type CPUScore interface {
 
 	IsolatedCapacity() int
 	SharedCapacity() int
+	MemoryCapacity() int64
+	HugePageCapacity() int64
 	Colocated() int
 	HintScores() map[string]float64
 
@@ -144,6 +148,8 @@ type cpuScore struct {
 	request   CPURequest         // CPU request (container)
 	isolated  int                // remaining isolated CPUs
 	shared    int                // remaining shared capacity
+	memory    int64              // remaining memory capacity
+	hugepages int64              // least remaining huge page capacity
 	colocated int                // number of colocated containers
 	hints     map[string]float64 // hint scores
 }
@@ -237,13 +243,16 @@ func (cs *cpuSupply) Allocate(r CPURequest) (CPUGrant, error) {
 				cr.full, cs.isolated, cs.node.Name())
 		}
 
-	case cr.full > 0 && (1000*cs.sharable.Size()-cs.granted)/1000 > cr.full:
-		exclusive, err = takeCPUs(&cs.sharable, nil, cr.full)
+	case cr.full > 0 && 1000*cs.sharable.Size()-cs.granted > 1000*cr.full &&
+		cs.sliceable().Size() >= cr.full:
+		sliceable := cs.sliceable()
+		exclusive, err = takeCPUs(&sliceable, nil, cr.full)
 		if err != nil {
 			return nil, policyError("internal error: "+
 				"can't slice %d exclusive CPUs from %s(-%d) of %s",
 				cr.full, cs.sharable, cs.granted, cs.node.Name())
 		}
+		cs.sharable = cs.sharable.Difference(exclusive)
 	}
 
 	// allocate requested portion of the sharable set
@@ -266,6 +275,13 @@ func (cs *cpuSupply) Allocate(r CPURequest) (CPUGrant, error) {
 	return grant, nil
 }
 
+// sliceable returns the sharable CPUs which can be sliced off as exclusive ones.
+// Reserved CPUs and CPUs of AVX512 pools below us are never handed out exclusively.
+func (cs *cpuSupply) sliceable() cpuset.CPUSet {
+	p := cs.node.Policy()
+	return cs.sharable.Difference(p.reserved).Difference(p.avx512CPUs(cs.node))
+}
+
 // Release returns CPU from the given grant to the supply.
 func (cs *cpuSupply) Release(g CPUGrant) {
 	isolated := g.ExclusiveCPUs().Intersection(cs.node.GetCPU().IsolatedCPUs())
@@ -281,6 +297,20 @@ func (cs *cpuSupply) Release(g CPUGrant) {
 	})
 }
 
+// Reserve takes the CPU of the given existing grant from the supply.
+func (cs *cpuSupply) Reserve(g CPUGrant) {
+	exclusive := g.ExclusiveCPUs()
+
+	cs.isolated = cs.isolated.Difference(exclusive)
+	cs.sharable = cs.sharable.Difference(exclusive)
+	cs.granted += g.SharedPortion()
+
+	cs.node.DepthFirst(func(n Node) error {
+		n.FreeCPU().AccountAllocate(g)
+		return nil
+	})
+}
+
 // String returns the CPU supply as a string.
 func (cs *cpuSupply) String() string {
 	none, isolated, sharable, sep := "-", "", "", ""
@@ -388,6 +418,21 @@ func (cs *cpuSupply) GetScore(request CPURequest) CPUScore {
 	// calculate fractional capacity
 	score.shared -= part
 
+	// calculate remaining memory capacity, unknown capacity never constrains
+	if mem := memoryPool(cs.node).FreeMemory(); mem.Capacity() > 0 {
+		score.memory = int64(mem.Free()) - int64(memoryRequirement(cr.container))
+	}
+
+	// calculate least remaining huge page capacity for any requested page size
+	first := true
+	for size, amount := range hugePageRequirements(cr.container) {
+		remaining := int64(memoryPool(cs.node).FreeMemory().FreeHugePages(size)) - int64(amount)
+		if first || remaining < score.hugepages {
+			score.hugepages = remaining
+			first = false
+		}
+	}
+
 	// calculate colocation score
 	for _, grant := range cs.node.Policy().allocations.CPU {
 		if grant.GetNode().NodeID() == cs.node.NodeID() {
@@ -444,6 +489,14 @@ func (score *cpuScore) SharedCapacity() int {
 	return score.shared
 }
 
+func (score *cpuScore) MemoryCapacity() int64 {
+	return score.memory
+}
+
+func (score *cpuScore) HugePageCapacity() int64 {
+	return score.hugepages
+}
+
 func (score *cpuScore) Colocated() int {
 	return score.colocated
 }
@@ -453,8 +506,9 @@ func (score *cpuScore) HintScores() map[string]float64 {
 }
 
 func (score *cpuScore) String() string {
-	return fmt.Sprintf("<CPU score: node %s, isolated:%d, shared:%d, colocated:%d, hints: %v>",
-		score.supply.GetNode().Name(), score.isolated, score.shared, score.colocated, score.hints)
+	return fmt.Sprintf("<CPU score: node %s, isolated:%d, shared:%d, memory:%d, hugepages:%d, colocated:%d, hints: %v>",
+		score.supply.GetNode().Name(), score.isolated, score.shared, score.memory, score.hugepages,
+		score.colocated, score.hints)
 }
 
 // newCPUGrant creates a CPU grant from the given node for the container.
@@ -484,7 +538,7 @@ func (cg *cpuGrant) ExclusiveCPUs() cpuset.CPUSet {
 
 // SharedCPUs returns the shared CPUSet in this grant.
 func (cg *cpuGrant) SharedCPUs() cpuset.CPUSet {
-	return cg.node.GetCPU().SharableCPUs()
+	return cg.node.GetCPU().SharableCPUs().Difference(cg.node.Policy().avx512CPUs(cg.node))
 }
 
 // SharedPortion returns the milli-CPU allocation for the shared CPUSet in this grant.
@@ -521,7 +575,12 @@ func (cg *cpuGrant) String() string {
 
 // takeCPUs takes up to cnt CPUs from a given CPU set to another.
 func takeCPUs(from, to *cpuset.CPUSet, cnt int) (cpuset.CPUSet, error) {
-	cset, err := cpuallocator.AllocateCpus(from, cnt)
+	flags := cpuallocator.AllocDefault
+	if opt.PreferIdleCacheDomains {
+		flags |= cpuallocator.AllocIdleCaches
+	}
+
+	cset, err := cpuallocator.AllocateCpusWithFlags(from, cnt, flags)
 	if err != nil {
 		return cset, err
 	}
@@ -29,6 +29,16 @@ type options struct {
 	PreferIsolated bool `json:"PreferIsolatedCPUs"`
 	// PreferShared controls whether shared CPU allocation is always preferred by default.
 	PreferShared bool `json:"PreferSharedCPUs"`
+	// RebalanceThreshold is the minimum placement cost improvement for moving a container.
+	RebalanceThreshold float64
+	// RebalanceMaxMoves is the maximum number of containers moved in a rebalancing round.
+	RebalanceMaxMoves int
+	// PreferIdleCacheDomains controls whether exclusive CPUs are taken from idle cache domains first.
+	PreferIdleCacheDomains bool
+	// CacheDomainPools controls whether L3 cache domains are added as a level of pools.
+	CacheDomainPools bool
+	// AVX512Pools are the pools dedicated to containers heavily using AVX512 instructions.
+	AVX512Pools []string `json:",omitempty"`
 	// FakeHints are the set of fake TopologyHints to use for testing purposes.
 	FakeHints fakehints `json:",omitempty"`
 }
@@ -59,6 +69,9 @@ func defaultOptions() interface{} {
 		PreferIsolated: true,
 		PreferShared:   false,
 		FakeHints:      make(fakehints),
+
+		RebalanceThreshold: 0.25,
+		RebalanceMaxMoves:  2,
 	}
 }
 
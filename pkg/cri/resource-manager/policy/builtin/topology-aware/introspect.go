@@ -0,0 +1,63 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/introspect"
+)
+
+// Introspect fills in our pool tree and the resources granted to containers.
+func (p *policy) Introspect(b *introspect.Backend) {
+	b.Pools = make(map[string]*introspect.Pool, len(p.pools))
+	for _, n := range p.pools {
+		b.Pools[n.Name()] = introspectPool(n)
+	}
+
+	b.Grants = make(map[string]*introspect.Grant, len(p.allocations.CPU))
+	for id, cg := range p.allocations.CPU {
+		g := &introspect.Grant{
+			Pool:          cg.GetNode().Name(),
+			ExclusiveCPUs: cg.ExclusiveCPUs().String(),
+			SharedCPUs:    cg.SharedCPUs().String(),
+			SharedPortion: cg.SharedPortion(),
+		}
+		if mg, ok := p.allocations.Memory[id]; ok {
+			g.Memset = mg.Memset().String()
+			g.MemoryLimit = mg.MemoryLimit()
+		}
+		b.Grants[id] = g
+	}
+}
+
+// introspectPool returns the introspected state of a pool.
+func introspectPool(n Node) *introspect.Pool {
+	cpu := n.GetCPU()
+	free := n.FreeCPU()
+	pool := &introspect.Pool{
+		Name:          n.Name(),
+		CPUs:          cpu.IsolatedCPUs().Union(cpu.SharableCPUs()).String(),
+		Memory:        n.GetMemset().String(),
+		IsolatedCPUs:  free.IsolatedCPUs().String(),
+		SharableCPUs:  free.SharableCPUs().String(),
+		GrantedShares: free.Granted(),
+	}
+	if parent := n.Parent(); !parent.IsNil() {
+		pool.Parent = parent.Name()
+	}
+	for _, c := range n.Children() {
+		pool.Children = append(pool.Children, c.Name())
+	}
+	return pool
+}
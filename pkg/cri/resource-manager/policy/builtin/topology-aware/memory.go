@@ -0,0 +1,403 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+)
+
+// MemorySupply represents available memory capacity of a node.
+type MemorySupply interface {
+	// GetNode returns the node supplying this capacity.
+	GetNode() Node
+	// Clone creates a copy of this MemorySupply.
+	Clone() MemorySupply
+	// Capacity returns the total memory capacity (in bytes) of this supply, 0 if unknown.
+	Capacity() uint64
+	// Allocated returns the amount of memory (in bytes) accounted as allocated.
+	Allocated() uint64
+	// Free returns the amount of unallocated memory (in bytes) in this supply.
+	Free() uint64
+	// Fits checks if the given amount of memory fits into this supply.
+	Fits(uint64) bool
+	// HugePageSizes returns the sizes of huge pages in this supply, in increasing order.
+	HugePageSizes() []uint64
+	// HugePageCapacity returns the capacity (in bytes) of huge pages of the given size.
+	HugePageCapacity(uint64) uint64
+	// FreeHugePages returns the amount of unallocated huge pages (in bytes) of the given size.
+	FreeHugePages(uint64) uint64
+	// FitsHugePages checks if the given amounts of huge pages (by page size) fit into this supply.
+	FitsHugePages(map[uint64]uint64) bool
+	// Cumulate cumulates the given supply into this one.
+	Cumulate(MemorySupply)
+	// AccountAllocate accounts for (removes) the share of a grant from the supply.
+	AccountAllocate(MemoryGrant)
+	// AccountRelease accounts for (reinserts) the share of a grant into the supply.
+	AccountRelease(MemoryGrant)
+	// Allocate allocates memory and huge pages from this supply and returns it as a grant.
+	Allocate(cache.Container, uint64, map[uint64]uint64) MemoryGrant
+	// Reserve accounts for an existing grant, for instance one restored from the cache.
+	Reserve(MemoryGrant)
+	// Release releases a previously allocated grant.
+	Release(MemoryGrant)
+	// String returns a printable representation of this supply.
+	String() string
+}
+
+// MemoryGrant represents memory capacity allocated to a container from a node.
+type MemoryGrant interface {
+	// GetContainer returns the container memory is granted to.
+	GetContainer() cache.Container
+	// GetNode returns the node that granted memory to the container.
+	GetNode() Node
+	// MemoryLimit returns the amount of memory (in bytes) granted.
+	MemoryLimit() uint64
+	// HugePages returns the amount of huge pages (in bytes) granted by page size.
+	HugePages() map[uint64]uint64
+	// Memset returns the set of memory nodes the grant is allocated from.
+	Memset() system.IDSet
+	// String returns a printable representation of this grant.
+	String() string
+}
+
+// memSupply implements our MemorySupply interface.
+type memSupply struct {
+	node      Node              // node supplying memory
+	capacity  uint64            // total memory at this node
+	allocated uint64            // memory accounted as allocated at this node
+	hugecap   map[uint64]uint64 // total huge pages by page size at this node
+	hugealloc map[uint64]uint64 // huge pages accounted as allocated by page size
+}
+
+var _ MemorySupply = &memSupply{}
+
+// memGrant implements our MemoryGrant interface.
+type memGrant struct {
+	container cache.Container   // container memory is granted to
+	node      Node              // node memory is supplied from
+	amount    uint64            // amount of memory granted
+	hugepages map[uint64]uint64 // amount of huge pages granted by page size
+}
+
+var _ MemoryGrant = &memGrant{}
+
+// newMemorySupply creates a memory supply for the given node, capacity and allocation.
+func newMemorySupply(n Node, capacity, allocated uint64, hugecap, hugealloc map[uint64]uint64) MemorySupply {
+	ms := &memSupply{
+		node:      n,
+		capacity:  capacity,
+		allocated: allocated,
+		hugecap:   make(map[uint64]uint64),
+		hugealloc: make(map[uint64]uint64),
+	}
+	for size, amount := range hugecap {
+		ms.hugecap[size] = amount
+	}
+	for size, amount := range hugealloc {
+		ms.hugealloc[size] = amount
+	}
+	return ms
+}
+
+// GetNode returns the node supplying memory.
+func (ms *memSupply) GetNode() Node {
+	return ms.node
+}
+
+// Clone clones the given memory supply.
+func (ms *memSupply) Clone() MemorySupply {
+	return newMemorySupply(ms.node, ms.capacity, ms.allocated, ms.hugecap, ms.hugealloc)
+}
+
+// Capacity returns the total memory capacity of this supply.
+func (ms *memSupply) Capacity() uint64 {
+	return ms.capacity
+}
+
+// Allocated returns the amount of memory accounted as allocated.
+func (ms *memSupply) Allocated() uint64 {
+	return ms.allocated
+}
+
+// Free returns the amount of unallocated memory in this supply.
+func (ms *memSupply) Free() uint64 {
+	if ms.allocated >= ms.capacity {
+		return 0
+	}
+	return ms.capacity - ms.allocated
+}
+
+// Fits checks if the given amount of memory fits into this supply.
+func (ms *memSupply) Fits(amount uint64) bool {
+	// unknown capacity (failed discovery) never constrains placement
+	return ms.capacity == 0 || ms.Free() >= amount
+}
+
+// HugePageSizes returns the sizes of huge pages in this supply, in increasing order.
+func (ms *memSupply) HugePageSizes() []uint64 {
+	return sortedSizes(ms.hugecap)
+}
+
+// HugePageCapacity returns the capacity of huge pages of the given size.
+func (ms *memSupply) HugePageCapacity(size uint64) uint64 {
+	return ms.hugecap[size]
+}
+
+// FreeHugePages returns the amount of unallocated huge pages of the given size.
+func (ms *memSupply) FreeHugePages(size uint64) uint64 {
+	if ms.hugealloc[size] >= ms.hugecap[size] {
+		return 0
+	}
+	return ms.hugecap[size] - ms.hugealloc[size]
+}
+
+// FitsHugePages checks if the given amounts of huge pages fit into this supply.
+func (ms *memSupply) FitsHugePages(hugepages map[uint64]uint64) bool {
+	for size, amount := range hugepages {
+		if ms.FreeHugePages(size) < amount {
+			return false
+		}
+	}
+	return true
+}
+
+// Cumulate more memory to supply.
+func (ms *memSupply) Cumulate(more MemorySupply) {
+	mms := more.(*memSupply)
+
+	ms.capacity += mms.capacity
+	ms.allocated += mms.allocated
+	for size, amount := range mms.hugecap {
+		ms.hugecap[size] += amount
+	}
+	for size, amount := range mms.hugealloc {
+		ms.hugealloc[size] += amount
+	}
+}
+
+// AccountAllocate accounts for (removes) the share of a grant from the supply.
+func (ms *memSupply) AccountAllocate(g MemoryGrant) {
+	gmem := g.GetNode().GetMemory()
+	ms.allocated += ms.share(g, g.MemoryLimit(), ms.capacity, gmem.Capacity())
+	for size, amount := range g.HugePages() {
+		ms.hugealloc[size] += ms.share(g, amount, ms.hugecap[size], gmem.HugePageCapacity(size))
+	}
+}
+
+// AccountRelease accounts for (reinserts) the share of a grant into the supply.
+func (ms *memSupply) AccountRelease(g MemoryGrant) {
+	gmem := g.GetNode().GetMemory()
+	ms.allocated = subtractShare(ms.allocated,
+		ms.share(g, g.MemoryLimit(), ms.capacity, gmem.Capacity()))
+	for size, amount := range g.HugePages() {
+		ms.hugealloc[size] = subtractShare(ms.hugealloc[size],
+			ms.share(g, amount, ms.hugecap[size], gmem.HugePageCapacity(size)))
+	}
+}
+
+// share calculates how much of an amount in a grant is accounted to this supply.
+func (ms *memSupply) share(g MemoryGrant, amount, capacity, gcapacity uint64) uint64 {
+	gnode := g.GetNode()
+	if ms.node.IsSameNode(gnode) {
+		return amount
+	}
+
+	// A grant from a node below us is fully taken from our memory. A grant
+	// from a node above us might end up in any of its memory nodes, so we
+	// account for a portion of it proportional to our share of the capacity.
+	if !ms.node.GetMemset().Has(gnode.GetMemset().Members()...) {
+		if gcapacity == 0 {
+			return 0
+		}
+		return uint64(float64(amount) * float64(capacity) / float64(gcapacity))
+	}
+
+	return amount
+}
+
+// subtractShare subtracts a released share from an allocated amount.
+func subtractShare(allocated, share uint64) uint64 {
+	if share > allocated {
+		return 0
+	}
+	return allocated - share
+}
+
+// Allocate allocates a grant from the supply.
+func (ms *memSupply) Allocate(c cache.Container, amount uint64, hugepages map[uint64]uint64) MemoryGrant {
+	grant := newMemoryGrant(ms.node, c, amount, hugepages)
+	ms.Reserve(grant)
+	return grant
+}
+
+// Reserve accounts for the given grant in this supply, its parents and its children.
+func (ms *memSupply) Reserve(g MemoryGrant) {
+	ms.node.DepthFirst(func(n Node) error {
+		n.FreeMemory().AccountAllocate(g)
+		return nil
+	})
+	for n := ms.node.Parent(); !n.IsNil(); n = n.Parent() {
+		n.FreeMemory().AccountAllocate(g)
+	}
+}
+
+// Release returns memory from the given grant to the supply.
+func (ms *memSupply) Release(g MemoryGrant) {
+	ms.node.DepthFirst(func(n Node) error {
+		n.FreeMemory().AccountRelease(g)
+		return nil
+	})
+	for n := ms.node.Parent(); !n.IsNil(); n = n.Parent() {
+		n.FreeMemory().AccountRelease(g)
+	}
+}
+
+// String returns the memory supply as a string.
+func (ms *memSupply) String() string {
+	if ms.capacity == 0 && len(ms.hugecap) == 0 {
+		return "<" + ms.node.Name() + " memory: ->"
+	}
+	huge := ""
+	for _, size := range sortedSizes(ms.hugecap) {
+		huge += fmt.Sprintf(", hugepages-%s: %s/%s", prettyMem(size),
+			prettyMem(ms.FreeHugePages(size)), prettyMem(ms.hugecap[size]))
+	}
+	return fmt.Sprintf("<%s memory: capacity: %s, allocated: %s, free: %s%s>", ms.node.Name(),
+		prettyMem(ms.capacity), prettyMem(ms.allocated), prettyMem(ms.Free()), huge)
+}
+
+// newMemoryGrant creates a memory grant from the given node for the container.
+func newMemoryGrant(n Node, c cache.Container, amount uint64, hugepages map[uint64]uint64) MemoryGrant {
+	mg := &memGrant{
+		node:      n,
+		container: c,
+		amount:    amount,
+		hugepages: make(map[uint64]uint64),
+	}
+	for size, amount := range hugepages {
+		mg.hugepages[size] = amount
+	}
+	return mg
+}
+
+// GetContainer returns the container this grant is valid for.
+func (mg *memGrant) GetContainer() cache.Container {
+	return mg.container
+}
+
+// GetNode returns the Node this grant is allocated from.
+func (mg *memGrant) GetNode() Node {
+	return mg.node
+}
+
+// MemoryLimit returns the amount of memory granted.
+func (mg *memGrant) MemoryLimit() uint64 {
+	return mg.amount
+}
+
+// HugePages returns the amount of huge pages granted by page size.
+func (mg *memGrant) HugePages() map[uint64]uint64 {
+	return mg.hugepages
+}
+
+// Memset returns the set of memory nodes this grant is allocated from.
+func (mg *memGrant) Memset() system.IDSet {
+	return mg.node.GetMemset()
+}
+
+// String returns a printable representation of the memory grant.
+func (mg *memGrant) String() string {
+	huge := ""
+	for _, size := range sortedSizes(mg.hugepages) {
+		huge += fmt.Sprintf(", hugepages-%s: %s", prettyMem(size), prettyMem(mg.hugepages[size]))
+	}
+	return fmt.Sprintf("<memory grant for %s from %s: %s%s (memset %s)>",
+		mg.container.PrettyName(), mg.node.Name(), prettyMem(mg.amount), huge, mg.Memset())
+}
+
+// memoryRequirement returns the amount of memory (in bytes) to allocate for a container.
+func memoryRequirement(container cache.Container) uint64 {
+	return memoryOf(container.GetResourceRequirements())
+}
+
+// memoryOf returns the memory limit, or the request if there is no limit, in the given resources.
+func memoryOf(resources v1.ResourceRequirements) uint64 {
+	if qty, ok := resources.Limits[v1.ResourceMemory]; ok && qty.Value() > 0 {
+		return uint64(qty.Value())
+	}
+	if qty, ok := resources.Requests[v1.ResourceMemory]; ok && qty.Value() > 0 {
+		return uint64(qty.Value())
+	}
+	return 0
+}
+
+// hugePageRequirements returns the amount of huge pages (in bytes) to allocate by page size.
+func hugePageRequirements(container cache.Container) map[uint64]uint64 {
+	return hugePagesOf(container.GetResourceRequirements(), container.PrettyName())
+}
+
+// hugePagesOf returns the amount of huge pages by page size in the given resources of a container.
+func hugePagesOf(resources v1.ResourceRequirements, name string) map[uint64]uint64 {
+	hugepages := make(map[uint64]uint64)
+	for _, list := range []v1.ResourceList{resources.Requests, resources.Limits} {
+		for resource, qty := range list {
+			if !strings.HasPrefix(string(resource), v1.ResourceHugePagesPrefix) || qty.Value() <= 0 {
+				continue
+			}
+			size, err := resapi.ParseQuantity(strings.TrimPrefix(string(resource), v1.ResourceHugePagesPrefix))
+			if err != nil || size.Value() <= 0 {
+				log.Warn("%s: ignoring invalid huge page resource %s", name, resource)
+				continue
+			}
+			// huge pages can't be overcommitted, limits equal requests if both are given
+			hugepages[uint64(size.Value())] = uint64(qty.Value())
+		}
+	}
+	return hugepages
+}
+
+// sortedSizes returns the huge page sizes in the given map in increasing order.
+func sortedSizes(hugepages map[uint64]uint64) []uint64 {
+	sizes := make([]uint64, 0, len(hugepages))
+	for size := range hugepages {
+		sizes = append(sizes, size)
+	}
+	sort.Slice(sizes, func(i, j int) bool { return sizes[i] < sizes[j] })
+	return sizes
+}
+
+// prettyMem formats an amount of memory in a human-readable form.
+func prettyMem(value uint64) string {
+	units := []string{"k", "M", "G", "T"}
+	unit, scaled := "", float64(value)
+	for _, u := range units {
+		if scaled < 1024 {
+			break
+		}
+		unit, scaled = u, scaled/1024
+	}
+	if unit == "" {
+		return fmt.Sprintf("%d", value)
+	}
+	return fmt.Sprintf("%.2f%s", scaled, unit)
+}
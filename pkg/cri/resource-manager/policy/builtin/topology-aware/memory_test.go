@@ -0,0 +1,231 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cpuallocator"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	system "github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/testutils"
+)
+
+const (
+	// memory of each NUMA node in the test system
+	testNodeMemory = 2 * 1024 * 1024 * 1024
+	// size of the huge pages in the test system
+	testHugePageSize = 2 * 1024 * 1024
+	// huge page memory of NUMA nodes #1 and #2, included in their memory
+	testHugePages = 1024 * 1024 * 1024
+)
+
+// createTestSystem creates a fake system of 2 sockets with 2 NUMA nodes each.
+// Every NUMA node has 2 CPUs and 2G of memory, NUMA nodes #1 and #2 also have
+// 1G of 2M huge pages. The system is also set up for the CPU allocator.
+func createTestSystem(t *testing.T) (*system.System, func()) {
+	dir, cleanup := testutils.TempDir(t, "topology-aware-test")
+
+	files := map[string]string{
+		"devices/system/cpu/isolated": "\n",
+	}
+	for cpu := 0; cpu < 8; cpu++ {
+		path := fmt.Sprintf("devices/system/cpu/cpu%d/", cpu)
+		files[path+"online"] = "1\n"
+		files[path+"topology/physical_package_id"] = fmt.Sprintf("%d\n", cpu/4)
+		files[path+"topology/thread_siblings_list"] = fmt.Sprintf("%d\n", cpu)
+		files[path+fmt.Sprintf("node%d", cpu/2)] = ""
+	}
+	for node := 0; node < 4; node++ {
+		path := fmt.Sprintf("devices/system/node/node%d/", node)
+		files[path+"cpulist"] = fmt.Sprintf("%d-%d\n", 2*node, 2*node+1)
+		files[path+"distance"] = "10 11 21 21\n"
+		files[path+"meminfo"] = fmt.Sprintf("Node %d MemTotal: %d kB\nNode %d MemFree: %d kB",
+			node, testNodeMemory/1024, node, testNodeMemory/1024)
+		if node == 1 || node == 2 {
+			files[path+"hugepages/hugepages-2048kB/nr_hugepages"] = "512\n"
+			files[path+"hugepages/hugepages-2048kB/free_hugepages"] = "512\n"
+		}
+	}
+	testutils.CreateFiles(t, dir, files)
+
+	sys, err := system.DiscoverSystemAt(dir)
+	if err != nil {
+		cleanup()
+		t.Fatalf("failed to discover test system: %v", err)
+	}
+	cpuallocator.SetSystem(sys)
+
+	return sys, cleanup
+}
+
+// createTestPolicy creates a policy for the test system, with CPU #0 reserved.
+// If no cache is given, a mock one is used.
+func createTestPolicy(t *testing.T, cch cache.Cache) (*policy, func()) {
+	sys, cleanup := createTestSystem(t)
+
+	if cch == nil {
+		cch = &mockCache{}
+	}
+	p := CreateTopologyAwarePolicy(&policyapi.BackendOptions{
+		System: sys,
+		Cache:  cch,
+		Reserved: policyapi.ConstraintSet{
+			policyapi.DomainCPU: cpuset.NewCPUSet(0),
+		},
+	}).(*policy)
+
+	return p, cleanup
+}
+
+// testContainer creates a container with the given memory and huge page limits.
+func testContainer(name string, memory, hugepages string) *mockContainer {
+	limits := v1.ResourceList{}
+	if memory != "" {
+		limits[v1.ResourceMemory] = resapi.MustParse(memory)
+	}
+	if hugepages != "" {
+		limits[v1.ResourceHugePagesPrefix+"2Mi"] = resapi.MustParse(hugepages)
+	}
+	return &mockContainer{
+		name:                                  name,
+		returnValueForGetCacheID:              name,
+		returnValueForGetResourceRequirements: v1.ResourceRequirements{Limits: limits},
+	}
+}
+
+func TestMemoryFit(t *testing.T) {
+	p, cleanup := createTestPolicy(t, nil)
+	defer cleanup()
+
+	numa0 := p.nodes["numa node #0"]
+	grant := p.allocateMemory(numa0, testContainer("ctr0", "1536Mi", ""))
+	if grant.GetNode().Name() != numa0.Name() || grant.Memset().String() != "0" {
+		t.Fatalf("expected grant from %s, got %s", numa0.Name(), grant)
+	}
+
+	for name, free := range map[string]uint64{
+		"numa node #0": testNodeMemory - 1536*1024*1024,
+		"numa node #1": testNodeMemory - testHugePages,
+		"socket #0":    2*testNodeMemory - testHugePages - 1536*1024*1024,
+		"root":         4*testNodeMemory - 2*testHugePages - 1536*1024*1024,
+	} {
+		if mem := p.nodes[name].FreeMemory(); mem.Free() != free {
+			t.Errorf("expected %d free memory in %s, got %s", free, name, mem)
+		}
+	}
+
+	request := newCPURequest(testContainer("ctr1", "1Gi", ""))
+	scores, pools := p.sortPoolsByScore(request, nil)
+	if scores[numa0.NodeID()].MemoryCapacity() >= 0 {
+		t.Errorf("expected insufficient memory in %s, got %d",
+			numa0.Name(), scores[numa0.NodeID()].MemoryCapacity())
+	}
+	if pools[0] == numa0 || scores[pools[0].NodeID()].MemoryCapacity() < 0 {
+		t.Errorf("expected a pool fitting the request, got %s", pools[0].Name())
+	}
+
+	numa0.FreeMemory().Release(grant)
+	if mem := p.root.FreeMemory(); mem.Free() != 4*testNodeMemory-2*testHugePages {
+		t.Errorf("expected all memory free after release, got %s", mem)
+	}
+}
+
+func TestMemoryWidening(t *testing.T) {
+	p, cleanup := createTestPolicy(t, nil)
+	defer cleanup()
+
+	// Notes:
+	//   Grants from a parent are partly accounted to its children, so
+	//   once we've widened to the root, nothing fits numa node #0 any more.
+	numa0 := p.nodes["numa node #0"]
+	tcs := []struct {
+		container string
+		memory    string
+		pool      string
+		memset    string
+	}{
+		{"ctr0", "1536Mi", "numa node #0", "0"},
+		{"ctr1", "1Gi", "socket #0", "0,1"},
+		{"ctr2", "3Gi", "root", "0,1,2,3"},
+		{"ctr3", "256Mi", "root", "0,1,2,3"},
+	}
+	for _, tc := range tcs {
+		grant := p.allocateMemory(numa0, testContainer(tc.container, tc.memory, ""))
+		if grant.GetNode().Name() != tc.pool || grant.Memset().String() != tc.memset {
+			t.Errorf("%s: expected grant from %s (memset %s), got %s",
+				tc.container, tc.pool, tc.memset, grant)
+		}
+	}
+}
+
+func TestHugePagePlacement(t *testing.T) {
+	p, cleanup := createTestPolicy(t, nil)
+	defer cleanup()
+
+	tcs := []struct {
+		container string
+		hugepages string
+		pool      string
+		free      uint64
+	}{
+		{"ctr0", "512Mi", "numa node #1", 512 * 1024 * 1024},
+		{"ctr1", "768Mi", "numa node #2", 256 * 1024 * 1024},
+		{"ctr2", "256Mi", "numa node #1", 256 * 1024 * 1024},
+	}
+	for _, tc := range tcs {
+		container := testContainer(tc.container, "", tc.hugepages)
+		_, pools := p.sortPoolsByScore(newCPURequest(container), nil)
+		if pools[0].Name() != tc.pool {
+			t.Errorf("%s: expected pool %s, got %s", tc.container, tc.pool, pools[0].Name())
+			continue
+		}
+		grant := p.allocateMemory(pools[0], container)
+		if grant.GetNode().Name() != tc.pool {
+			t.Errorf("%s: expected grant from %s, got %s", tc.container, tc.pool, grant)
+		}
+		if free := pools[0].FreeMemory().FreeHugePages(testHugePageSize); free != tc.free {
+			t.Errorf("%s: expected %d free huge pages in %s, got %d",
+				tc.container, tc.free, tc.pool, free)
+		}
+	}
+}
+
+func TestHugePageAccounting(t *testing.T) {
+	p, cleanup := createTestPolicy(t, nil)
+	defer cleanup()
+
+	// huge pages are not counted as normal memory
+	for name, expected := range map[string]struct{ free, hugepages uint64 }{
+		"numa node #0": {testNodeMemory, 0},
+		"numa node #1": {testNodeMemory - testHugePages, testHugePages},
+		"socket #1":    {2*testNodeMemory - testHugePages, testHugePages},
+		"root":         {4*testNodeMemory - 2*testHugePages, 2 * testHugePages},
+	} {
+		mem := p.nodes[name].FreeMemory()
+		if mem.Free() != expected.free {
+			t.Errorf("expected %d free memory in %s, got %d", expected.free, name, mem.Free())
+		}
+		if free := mem.FreeHugePages(testHugePageSize); free != expected.hugepages {
+			t.Errorf("expected %d free huge pages in %s, got %d", expected.hugepages, name, free)
+		}
+	}
+}
@@ -61,6 +61,9 @@ func (fake *mockSystem) PackageIDs() []system.ID {
 func (fake *mockSystem) NodeIDs() []system.ID {
 	return []system.ID{0, 1}
 }
+func (fake *mockSystem) CacheDomains(uint8) []*system.Cache {
+	return []*system.Cache{}
+}
 
 type mockContainer struct {
 	name                                  string
@@ -254,6 +257,9 @@ func (m *mockContainer) SetCpusetCpus(string) {
 func (m *mockContainer) SetCpusetMems(string) {
 	panic("unimplemented")
 }
+func (m *mockContainer) SetResourceUpdates(*cri.LinuxContainerResources) (bool, func()) {
+	panic("unimplemented")
+}
 func (m *mockContainer) UpdateCriCreateRequest(*cri.CreateContainerRequest) error {
 	panic("unimplemented")
 }
@@ -302,11 +308,14 @@ func (m *mockContainer) HasPending(string) bool {
 func (m *mockContainer) ClearPending(string) {
 	panic("unimplemented")
 }
+func (m *mockContainer) GetTags() map[string]string {
+	panic("unimplemented")
+}
 func (m *mockContainer) GetTag(string) (string, bool) {
 	panic("unimplemented")
 }
 func (m *mockContainer) SetTag(string, string) (string, bool) {
-	panic("unimplemented")
+	return "", false
 }
 func (m *mockContainer) DeleteTag(string) (string, bool) {
 	panic("unimplemented")
@@ -443,7 +452,7 @@ func (m *mockCache) EvaluateAffinity(*cache.Affinity) map[string]int32 {
 	}
 }
 func (m *mockCache) AddImplicitAffinities(map[string]*cache.ImplicitAffinity) error {
-	panic("unimplemented")
+	return nil
 }
 func (m *mockCache) GetActivePolicy() string {
 	panic("unimplemented")
@@ -451,6 +460,9 @@ func (m *mockCache) GetActivePolicy() string {
 func (m *mockCache) SetActivePolicy(string) error {
 	panic("unimplemented")
 }
+func (m *mockCache) ResetActivePolicy() (func() error, error) {
+	panic("unimplemented")
+}
 func (m *mockCache) SetPolicyEntry(string, interface{}) {
 }
 func (m *mockCache) GetPolicyEntry(string, interface{}) bool {
@@ -30,6 +30,8 @@ import (
 // socket system, the virtual root is replaced with the single socket. In a single
 // NUMA node case, the single node is omitted. Also, CPU cores are not modelled as
 // nodes, instead they are properties of the nodes (as capacity and free CPU).
+// Optionally, L3 cache domains are modelled as CPU-only nodes below NUMA nodes
+// (or sockets). Memory for these is allocated from their parent nodes.
 //
 
 // NodeKind represents a unique node type.
@@ -46,6 +48,8 @@ const (
 	NumaNode NodeKind = "numa node"
 	// VirtualNode represents a virtual node, currently the root multi-socket setups.
 	VirtualNode NodeKind = "virtual node"
+	// CacheNode represents an L3 cache domain in the system.
+	CacheNode NodeKind = "cache domain"
 )
 
 const (
@@ -93,6 +97,12 @@ type Node interface {
 	FreeCPU() CPUSupply
 	// GrantedCPU returns the amount of granted shared CPU capacity of this node.
 	GrantedCPU() int
+	// DiscoverMemory discovers the memory capacity of this node.
+	DiscoverMemory() MemorySupply
+	// GetMemory returns the full memory supply of this node.
+	GetMemory() MemorySupply
+	// FreeMemory returns the available memory supply of this node.
+	FreeMemory() MemorySupply
 	// GetMemset
 	GetMemset() system.IDSet
 	// DiscoverMemset
@@ -122,6 +132,8 @@ type node struct {
 	children []Node       // child nodes
 	nodecpu  CPUSupply    // CPU available at this node
 	freecpu  CPUSupply    // CPU allocatable at this node
+	nodemem  MemorySupply // memory available at this node
+	freemem  MemorySupply // memory allocatable at this node
 	mem      system.IDSet // memory attached to this node
 }
 
@@ -144,6 +156,13 @@ type numanode struct {
 	sysnode *system.Node // corresponding system.Node
 }
 
+// cachenode represents an L3 cache domain in the system.
+type cachenode struct {
+	node                   // common node data
+	id       int           // enumerated cache domain id
+	syscache *system.Cache // corresponding system.Cache
+}
+
 // virtualnode represents a virtual node (ATM only the root in a multi-socket system).
 type virtualnode struct {
 	node // common node data
@@ -274,11 +293,18 @@ func (n *node) Dump(prefix string, level ...int) {
 	log.Debug("%s  - node CPU: %v", idt, n.nodecpu)
 	log.Debug("%s  - free CPU: %v", idt, n.freecpu)
 	log.Debug("%s  - memory: %v", idt, n.mem)
+	log.Debug("%s  - node memory: %v", idt, n.nodemem)
+	log.Debug("%s  - free memory: %v", idt, n.freemem)
 	for _, grant := range n.policy.allocations.CPU {
 		if grant.GetNode().NodeID() == n.id {
 			log.Debug("%s    + %s", idt, grant)
 		}
 	}
+	for _, grant := range n.policy.allocations.Memory {
+		if grant.GetNode().NodeID() == n.id {
+			log.Debug("%s    + %s", idt, grant)
+		}
+	}
 	if !n.Parent().IsNil() {
 		log.Debug("%s  - parent: <%s>", idt, n.Parent().Name())
 	}
@@ -344,6 +370,21 @@ func (n *node) FreeCPU() CPUSupply {
 	return n.freecpu
 }
 
+// GetMemory returns the full memory supply of this node.
+func (n *node) GetMemory() MemorySupply {
+	return n.self.node.GetMemory()
+}
+
+// DiscoverMemory discovers the memory capacity of this node.
+func (n *node) DiscoverMemory() MemorySupply {
+	return n.self.node.DiscoverMemory()
+}
+
+// FreeMemory returns the available memory supply of this node.
+func (n *node) FreeMemory() MemorySupply {
+	return n.freemem
+}
+
 // Get the set of memory attached to this node.
 func (n *node) GetMemset() system.IDSet {
 	return n.self.node.GetMemset()
@@ -399,7 +440,7 @@ func (n *numanode) GetCPU() CPUSupply {
 func (n *numanode) DiscoverCPU() CPUSupply {
 	log.Debug("discovering CPU available at node %s...", n.Name())
 
-	nodecpus := n.sysnode.CPUSet()
+	nodecpus := n.sysnode.CPUSet().Intersection(n.policy.allowed)
 	isolated := nodecpus.Intersection(n.policy.isolated)
 	sharable := nodecpus.Difference(isolated)
 	n.nodecpu = newCPUSupply(n, isolated, sharable, 0)
@@ -408,6 +449,20 @@ func (n *numanode) DiscoverCPU() CPUSupply {
 	return n.nodecpu.Clone()
 }
 
+// GetMemory returns the memory supply of this node.
+func (n *numanode) GetMemory() MemorySupply {
+	return n.nodemem.Clone()
+}
+
+// DiscoverMemory discovers the memory capacity of this node.
+func (n *numanode) DiscoverMemory() MemorySupply {
+	log.Debug("discovering memory available at node %s...", n.Name())
+
+	n.nodemem = discoverNodeMemory(n, n.sysnode)
+	n.freemem = n.nodemem.Clone()
+	return n.nodemem.Clone()
+}
+
 // GetMemset() returns the set of memory attached to this node.
 func (n *numanode) GetMemset() system.IDSet {
 	return n.mem.Clone()
@@ -467,7 +522,7 @@ func (n *socketnode) DiscoverCPU() CPUSupply {
 	log.Debug("discovering CPU available at node %s...", n.Name())
 
 	if n.IsLeafNode() {
-		sockcpus := n.syspkg.CPUSet()
+		sockcpus := n.syspkg.CPUSet().Intersection(n.policy.allowed)
 		isolated := sockcpus.Intersection(n.policy.isolated)
 		sharable := sockcpus.Difference(isolated)
 		n.nodecpu = newCPUSupply(n, isolated, sharable, 0)
@@ -482,6 +537,30 @@ func (n *socketnode) DiscoverCPU() CPUSupply {
 	return n.nodecpu.Clone()
 }
 
+// GetMemory returns the memory supply of this socket.
+func (n *socketnode) GetMemory() MemorySupply {
+	return n.nodemem.Clone()
+}
+
+// DiscoverMemory discovers the memory capacity of this socket.
+func (n *socketnode) DiscoverMemory() MemorySupply {
+	log.Debug("discovering memory available at node %s...", n.Name())
+
+	n.nodemem = newMemorySupply(n, 0, 0, nil, nil)
+	if n.IsLeafNode() || n.children[0].Kind() == CacheNode {
+		for _, id := range n.syspkg.NodeIDs() {
+			n.nodemem.Cumulate(discoverNodeMemory(n, n.System().Node(id)))
+		}
+	} else {
+		for _, c := range n.children {
+			n.nodemem.Cumulate(c.DiscoverMemory())
+		}
+	}
+
+	n.freemem = n.nodemem.Clone()
+	return n.nodemem.Clone()
+}
+
 // GetMemset() returns the set of memory attached to this socket.
 func (n *socketnode) GetMemset() system.IDSet {
 	return n.mem.Clone()
@@ -490,6 +569,9 @@ func (n *socketnode) GetMemset() system.IDSet {
 // DiscoverMemset discovers the set of memory attached to this socket.
 func (n *socketnode) DiscoverMemset() system.IDSet {
 	n.mem = system.NewIDSet()
+	if n.IsLeafNode() {
+		n.mem.Add(n.syspkg.NodeIDs()...)
+	}
 	for _, c := range n.children {
 		n.mem.Add(c.GetMemset().Members()...)
 	}
@@ -513,6 +595,81 @@ func (n *socketnode) HintScore(hint topology.Hint) float64 {
 	return 0.0
 }
 
+// NewCacheNode creates a node for an L3 cache domain.
+func (p *policy) NewCacheNode(id int, c *system.Cache, parent Node) Node {
+	n := &cachenode{}
+	n.self.node = n
+	n.node.init(p, fmt.Sprintf("L%d cache #%v", c.Level(), id), CacheNode, parent)
+	n.id = id
+	n.syscache = c
+
+	return n
+}
+
+// Dump (the cache-specific parts of) this node.
+func (n *cachenode) dump(prefix string, level ...int) {
+	log.Debug("%s<L%d cache #%v>", indent(prefix, level...), n.syscache.Level(), n.id)
+}
+
+// Get CPU supply available at this node.
+func (n *cachenode) GetCPU() CPUSupply {
+	return n.nodecpu.Clone()
+}
+
+// DiscoverCPU discovers the CPU supply available at this node.
+func (n *cachenode) DiscoverCPU() CPUSupply {
+	log.Debug("discovering CPU available at node %s...", n.Name())
+
+	cachecpus := n.syscache.CPUSet().Intersection(n.policy.allowed)
+	isolated := cachecpus.Intersection(n.policy.isolated)
+	sharable := cachecpus.Difference(isolated)
+	n.nodecpu = newCPUSupply(n, isolated, sharable, 0)
+
+	n.freecpu = n.nodecpu.Clone()
+	return n.nodecpu.Clone()
+}
+
+// GetMemory returns the memory supply of this node.
+func (n *cachenode) GetMemory() MemorySupply {
+	return n.nodemem.Clone()
+}
+
+// DiscoverMemory discovers the memory capacity of this node. Cache domains have
+// no memory of their own, memory is allocated from the parent node instead.
+func (n *cachenode) DiscoverMemory() MemorySupply {
+	n.nodemem = newMemorySupply(n, 0, 0, nil, nil)
+	n.freemem = n.nodemem.Clone()
+	return n.nodemem.Clone()
+}
+
+// GetMemset() returns the set of memory attached to this node.
+func (n *cachenode) GetMemset() system.IDSet {
+	return n.mem.Clone()
+}
+
+// DiscoverMemset discovers the set of memory (NUMA nodes) local to this cache domain.
+func (n *cachenode) DiscoverMemset() system.IDSet {
+	cpus := n.syscache.CPUSet()
+	n.mem = system.NewIDSet()
+	for _, id := range n.System().NodeIDs() {
+		if !n.System().Node(id).CPUSet().Intersection(cpus).IsEmpty() {
+			n.mem.Add(id)
+		}
+	}
+	return n.mem.Clone()
+}
+
+// HintScore calculates the (CPU) score of the node for the given topology hint.
+func (n *cachenode) HintScore(hint topology.Hint) float64 {
+	if hint.CPUs != "" {
+		return cpuHintScore(hint, n.syscache.CPUSet())
+	}
+
+	// penalize underfit reciprocally (inverse-proportionally) to the number of siblings
+	parent := n.Parent()
+	return parent.HintScore(hint) / float64(len(parent.Children()))
+}
+
 // NewVirtualNode creates a new virtual node.
 func (p *policy) NewVirtualNode(name string, parent Node) Node {
 	n := &virtualnode{}
@@ -545,6 +702,24 @@ func (n *virtualnode) DiscoverCPU() CPUSupply {
 	return n.nodecpu.Clone()
 }
 
+// GetMemory returns the memory supply of this node.
+func (n *virtualnode) GetMemory() MemorySupply {
+	return n.nodemem.Clone()
+}
+
+// DiscoverMemory discovers the memory capacity of this node.
+func (n *virtualnode) DiscoverMemory() MemorySupply {
+	log.Debug("discovering memory available at node %s...", n.Name())
+
+	n.nodemem = newMemorySupply(n, 0, 0, nil, nil)
+	for _, c := range n.children {
+		n.nodemem.Cumulate(c.DiscoverMemory())
+	}
+
+	n.freemem = n.nodemem.Clone()
+	return n.nodemem.Clone()
+}
+
 // GetMemset() returns the set of memory attached to this socket.
 func (n *virtualnode) GetMemset() system.IDSet {
 	return n.mem.Clone()
@@ -576,6 +751,35 @@ func (n *virtualnode) HintScore(hint topology.Hint) float64 {
 	return 0.0
 }
 
+// discoverNodeMemory discovers the memory and huge pages of a NUMA node for the given node.
+func discoverNodeMemory(n Node, sysnode *system.Node) MemorySupply {
+	capacity := uint64(0)
+	if info, err := sysnode.MemoryInfo(); err != nil {
+		log.Warn("failed to discover memory of NUMA node #%v: %v", sysnode.ID(), err)
+	} else {
+		capacity = info.MemTotal
+	}
+
+	hugecap := make(map[uint64]uint64)
+	if pools, err := sysnode.HugePages(); err != nil {
+		log.Warn("failed to discover huge pages of NUMA node #%v: %v", sysnode.ID(), err)
+	} else {
+		for _, pool := range pools {
+			hugecap[pool.Size] = pool.Total * pool.Size
+		}
+	}
+
+	// MemTotal includes huge pages, which we account for separately
+	for _, amount := range hugecap {
+		if amount > capacity {
+			amount = capacity
+		}
+		capacity -= amount
+	}
+
+	return newMemorySupply(n, capacity, 0, hugecap, nil)
+}
+
 // Finalize the setup of nilnode.
 func init() {
 	nilnode.(*node).self.node = nilnode
@@ -17,6 +17,8 @@ package topologyaware
 import (
 	"sort"
 
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
 	system "github.com/intel/cri-resource-manager/pkg/sysfs"
@@ -26,15 +28,29 @@ import (
 func (p *policy) buildPoolsByTopology() error {
 	var n Node
 
-	socketCnt := p.sys.SocketCount()
-	nodeCnt := p.sys.NUMANodeCount()
+	// leave out sockets and NUMA nodes with none of their CPUs available to us
+	socketIDs := []system.ID{}
+	for _, id := range p.sys.PackageIDs() {
+		if p.usableCPUs(p.sys.Package(id).CPUSet()) {
+			socketIDs = append(socketIDs, id)
+		}
+	}
+	nodeIDs := []system.ID{}
+	for _, id := range p.sys.NodeIDs() {
+		pkg := p.sys.Package(p.sys.Node(id).PackageID())
+		if p.usableCPUs(p.sys.Node(id).CPUSet()) && p.usableCPUs(pkg.CPUSet()) {
+			nodeIDs = append(nodeIDs, id)
+		}
+	}
+
+	socketCnt := len(socketIDs)
+	nodeCnt := len(nodeIDs)
 	if nodeCnt < 2 {
 		nodeCnt = 0
 	}
 	poolCnt := socketCnt + nodeCnt + map[bool]int{false: 0, true: 1}[socketCnt > 1]
 
 	p.nodes = make(map[string]Node, poolCnt)
-	p.pools = make([]Node, poolCnt)
 
 	// create virtual root if necessary
 	if socketCnt > 1 {
@@ -44,7 +60,7 @@ func (p *policy) buildPoolsByTopology() error {
 
 	// create nodes for sockets
 	sockets := make(map[system.ID]Node, socketCnt)
-	for _, id := range p.sys.PackageIDs() {
+	for _, id := range socketIDs {
 		if socketCnt > 1 {
 			n = p.NewSocketNode(id, p.root)
 		} else {
@@ -56,14 +72,31 @@ func (p *policy) buildPoolsByTopology() error {
 	}
 
 	// create nodes for NUMA nodes
+	numas := make(map[system.ID]Node, nodeCnt)
 	if nodeCnt > 0 {
-		for _, id := range p.sys.NodeIDs() {
+		for _, id := range nodeIDs {
 			n = p.NewNumaNode(id, sockets[p.sys.Node(id).PackageID()])
 			p.nodes[n.Name()] = n
+			numas[id] = n
 		}
 	}
 
+	// create nodes for L3 cache domains
+	if opt.CacheDomainPools {
+		if nodeCnt > 0 {
+			p.buildCachePools(numas, func(id system.ID) cpuset.CPUSet {
+				return p.sys.Node(id).CPUSet().Intersection(p.allowed)
+			})
+		} else {
+			p.buildCachePools(sockets, func(id system.ID) cpuset.CPUSet {
+				return p.sys.Package(id).CPUSet().Intersection(p.allowed)
+			})
+		}
+	}
+	p.cachePools = opt.CacheDomainPools
+
 	// enumerate nodes, calculate tree depth, discover node resource capacity
+	p.pools = make([]Node, len(p.nodes))
 	p.root.DepthFirst(func(n Node) error {
 		p.pools[p.nodeCnt] = n
 		n.(*node).id = p.nodeCnt
@@ -75,6 +108,7 @@ func (p *policy) buildPoolsByTopology() error {
 
 		n.DiscoverCPU()
 		n.DiscoverMemset()
+		n.DiscoverMemory()
 
 		return nil
 	})
@@ -82,6 +116,61 @@ func (p *policy) buildPoolsByTopology() error {
 	return nil
 }
 
+// usableCPUs checks if a topology element with the given CPUs should get a pool.
+// Elements with CPUs, none of which are available to us, get no pool.
+func (p *policy) usableCPUs(cpus cpuset.CPUSet) bool {
+	return cpus.IsEmpty() || !cpus.Intersection(p.allowed).IsEmpty()
+}
+
+// buildCachePools creates nodes for L3 cache domains below the given parent nodes.
+// A parent gets cache domain nodes only if it is split into several L3 domains.
+func (p *policy) buildCachePools(parents map[system.ID]Node, cpus func(system.ID) cpuset.CPUSet) {
+	domains := make(map[system.ID][]int)
+	caches := p.sys.CacheDomains(3)
+
+	for idx, c := range caches {
+		if c.CPUSet().Intersection(p.allowed).IsEmpty() {
+			continue
+		}
+		for id := range parents {
+			if c.CPUSet().IsSubsetOf(cpus(id)) {
+				domains[id] = append(domains[id], idx)
+				break
+			}
+		}
+	}
+
+	ids := make([]system.ID, 0, len(parents))
+	for id := range parents {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		parent := parents[id]
+		covered := cpuset.NewCPUSet()
+		for _, idx := range domains[id] {
+			covered = covered.Union(caches[idx].CPUSet().Intersection(p.allowed))
+		}
+		if len(domains[id]) < 2 || !covered.Equals(cpus(id)) {
+			log.Info("not creating cache domain pools for %s", parent.Name())
+			continue
+		}
+		for _, idx := range domains[id] {
+			n := p.NewCacheNode(idx, caches[idx], parent)
+			p.nodes[n.Name()] = n
+		}
+	}
+}
+
+// memoryPool returns the pool memory is allocated from for the given pool.
+func memoryPool(pool Node) Node {
+	for pool.Kind() == CacheNode {
+		pool = pool.Parent()
+	}
+	return pool
+}
+
 // Pick a pool and allocate resource from it to the container.
 func (p *policy) allocatePool(container cache.Container) (CPUGrant, error) {
 	var pool Node
@@ -105,6 +194,12 @@ func (p *policy) allocatePool(container cache.Container) (CPUGrant, error) {
 		pool = pools[0]
 	}
 
+	return p.allocateFromPool(pool, request)
+}
+
+// Allocate resources for the request from the given pool.
+func (p *policy) allocateFromPool(pool Node, request CPURequest) (CPUGrant, error) {
+	container := request.GetContainer()
 	cpus := pool.FreeCPU()
 	grant, err := cpus.Allocate(request)
 	if err != nil {
@@ -112,11 +207,35 @@ func (p *policy) allocatePool(container cache.Container) (CPUGrant, error) {
 	}
 
 	p.allocations.CPU[container.GetCacheID()] = grant
+	p.allocations.Memory[container.GetCacheID()] = p.allocateMemory(pool, container)
 	p.saveAllocations()
 
 	return grant, nil
 }
 
+// Allocate memory for the container from the pool, widening to parent pools if necessary.
+func (p *policy) allocateMemory(pool Node, container cache.Container) MemoryGrant {
+	amount := memoryRequirement(container)
+	hugepages := hugePageRequirements(container)
+	fits := func(n Node) bool {
+		return n.FreeMemory().Fits(amount) && n.FreeMemory().FitsHugePages(hugepages)
+	}
+
+	node := memoryPool(pool)
+	for !node.IsRootNode() && !fits(node) {
+		log.Debug("  => %s can't fit %s memory (hugepages %v), widening to %s",
+			node.FreeMemory(), prettyMem(amount), hugepages, node.Parent().Name())
+		node = node.Parent()
+	}
+
+	if !fits(node) {
+		log.Warn("overcommitting memory: %s can't fit %s (hugepages %v) for %s",
+			node.FreeMemory(), prettyMem(amount), hugepages, container.PrettyName())
+	}
+
+	return node.FreeMemory().Allocate(container, amount, hugepages)
+}
+
 // Apply the result of allocation to the requesting container.
 func (p *policy) applyGrant(grant CPUGrant) error {
 	log.Debug("* applying grant %s", grant)
@@ -141,7 +260,14 @@ func (p *policy) applyGrant(grant CPUGrant) error {
 
 	mems := ""
 	node := grant.GetNode()
-	if !node.IsRootNode() && opt.PinMemory {
+	pinMemory := opt.PinMemory
+	if mem, ok := p.allocations.Memory[container.GetCacheID()]; ok {
+		node = mem.GetNode()
+		// always pin containers with huge pages to the nodes they were granted from
+		pinMemory = pinMemory || len(mem.HugePages()) > 0
+	}
+	// pin to the root too, if it only covers part of the memory (for instance in a partition)
+	if pinMemory && (!node.IsRootNode() || node.GetMemset().Size() < len(p.sys.NodeIDs())) {
 		mems = node.GetMemset().String()
 	}
 
@@ -178,6 +304,8 @@ func (p *policy) applyGrant(grant CPUGrant) error {
 		log.Debug("  => not pinning memory, memory set is empty...")
 	}
 
+	container.SetTag(cache.TagPool, grant.GetNode().Name())
+
 	return nil
 }
 
@@ -198,6 +326,13 @@ func (p *policy) releasePool(container cache.Container) (CPUGrant, bool, error)
 
 	cpus.Release(grant)
 	delete(p.allocations.CPU, container.GetCacheID())
+
+	if mem, ok := p.allocations.Memory[container.GetCacheID()]; ok {
+		log.Debug("  => releasing grant %s...", mem)
+		mem.GetNode().FreeMemory().Release(mem)
+		delete(p.allocations.Memory, container.GetCacheID())
+	}
+
 	p.saveAllocations()
 
 	return grant, true, nil
@@ -214,7 +349,8 @@ func (p *policy) updateSharedAllocations(grant CPUGrant) error {
 		}
 
 		if opt.PinCPU {
-			shared := other.GetNode().FreeCPU().SharableCPUs().String()
+			node := other.GetNode()
+			shared := node.FreeCPU().SharableCPUs().Difference(p.avx512CPUs(node)).String()
 			log.Debug("  => updating %s with shared CPUs of %s: %s...",
 				other, other.GetNode().Name(), shared)
 			other.GetContainer().SetCpusetCpus(shared)
@@ -304,6 +440,8 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 	score1, score2 := scores[id1], scores[id2]
 	isolated1, shared1 := score1.IsolatedCapacity(), score1.SharedCapacity()
 	isolated2, shared2 := score2.IsolatedCapacity(), score2.SharedCapacity()
+	memory1, memory2 := score1.MemoryCapacity(), score2.MemoryCapacity()
+	hugepages1, hugepages2 := score1.HugePageCapacity(), score2.HugePageCapacity()
 	affinity1, affinity2 := affinity[id1], affinity[id2]
 
 	//
@@ -312,18 +450,20 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 	// Our scoring/score sorting algorithm is:
 	//
 	// 1) - insufficient isolated or shared capacity loses
-	// 2) - if we have affinity, the higher affinity wins
-	// 3) - if we have topology hints
+	// 2) - insufficient memory or huge page capacity loses
+	// 3) - a node matching the AVX512 usage of the container wins
+	// 4) - if we have affinity, the higher affinity wins
+	// 5) - if we have topology hints
 	//       * better hint score wins
 	//       * for a tie, prefer the lower node then the smaller id
-	// 4) - if a node is lower in the tree it wins
-	// 5) - for isolated allocations
+	// 6) - if a node is lower in the tree it wins
+	// 7) - for isolated allocations
 	//       * more isolated capacity wins
 	//       * for a tie, prefer the smaller id
-	// 6) - for exclusive allocations
+	// 8) - for exclusive allocations
 	//       * more slicable (shared) capacity wins
 	//       * for a tie, prefer the smaller id
-	// 7) - for shared-only allocations
+	// 9) - for shared-only allocations
 	//       * fewer colocated containers win
 	//       * for a tie prefer more shared capacity then the smaller id
 	//
@@ -336,7 +476,21 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 		return false
 	}
 
-	// 2) higher affinity wins
+	// 2) a node with insufficient memory or huge page capacity loses
+	switch {
+	case (memory2 < 0 || hugepages2 < 0) && memory1 >= 0 && hugepages1 >= 0:
+		return true
+	case (memory1 < 0 || hugepages1 < 0) && memory2 >= 0 && hugepages2 >= 0:
+		return false
+	}
+
+	// 3) a node matching AVX512 usage wins
+	avx1, avx2 := avx512Fits(request.GetContainer(), node1), avx512Fits(request.GetContainer(), node2)
+	if avx1 != avx2 {
+		return avx1
+	}
+
+	// 4) higher affinity wins
 	if affinity1 > affinity2 {
 		return true
 	}
@@ -344,7 +498,7 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 		return false
 	}
 
-	// 3) better topology hint score wins
+	// 5) better topology hint score wins
 	hScores1 := score1.HintScores()
 	if len(hScores1) > 0 {
 		hScores2 := score2.HintScores()
@@ -379,7 +533,7 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 		}
 	}
 
-	// 4) a lower node wins
+	// 6) a lower node wins
 	if depth1 > depth2 {
 		return true
 	}
@@ -387,7 +541,7 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 		return false
 	}
 
-	// 5) more isolated capacity wins
+	// 7) more isolated capacity wins
 	if request.Isolate() {
 		if isolated1 > isolated2 {
 			return true
@@ -398,7 +552,7 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 		return id1 < id2
 	}
 
-	// 6) more slicable shared capacity wins
+	// 8) more slicable shared capacity wins
 	if request.FullCPUs() > 0 {
 		if shared1 > shared2 {
 			return true
@@ -410,7 +564,7 @@ func (p *policy) compareScores(request CPURequest, scores map[int]CPUScore,
 		return id1 < id2
 	}
 
-	// 7) fewer colocated containers win
+	// 9) fewer colocated containers win
 	if score1.Colocated() < score2.Colocated() {
 		return true
 	}
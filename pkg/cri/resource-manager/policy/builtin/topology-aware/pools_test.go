@@ -0,0 +1,93 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache/cachetest"
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+)
+
+// createGuaranteedContainer creates a guaranteed container requesting the given number of CPUs.
+func createGuaranteedContainer(t *testing.T, cch cache.Cache, name string, cpus int) cache.Container {
+	return cachetest.CreateContainer(t, cch, cachetest.Pod{
+		Name:         name,
+		Namespace:    "default",
+		CgroupParent: "/kubepods/pod" + name + "-uid",
+		Resources: &cri.LinuxContainerResources{
+			CpuShares: int64(cpus * 1024),
+			CpuPeriod: 100000,
+			CpuQuota:  int64(cpus * 100000),
+		},
+	})
+}
+
+func TestPartialAvailability(t *testing.T) {
+	sys, cleanup := createTestSystem(t)
+	defer cleanup()
+
+	cch, cleanupCache := cachetest.NewCache(t)
+	defer cleanupCache()
+
+	// only the second socket is available, with its first CPU reserved
+	p := CreateTopologyAwarePolicy(&policyapi.BackendOptions{
+		System: sys,
+		Cache:  cch,
+		Available: policyapi.ConstraintSet{
+			policyapi.DomainCPU: cpuset.MustParse("4-7"),
+		},
+		Reserved: policyapi.ConstraintSet{
+			policyapi.DomainCPU: cpuset.NewCPUSet(4),
+		},
+	}).(*policy)
+
+	names := []string{}
+	for name, n := range p.nodes {
+		names = append(names, name)
+		if cpu := n.GetCPU(); cpu.SharableCPUs().Union(cpu.IsolatedCPUs()).IsEmpty() {
+			t.Errorf("pool %s has no CPUs", name)
+		}
+	}
+	sort.Strings(names)
+	if pools := strings.Join(names, ","); pools != "numa node #2,numa node #3,socket #1" {
+		t.Errorf("expected pools for socket #1 and its NUMA nodes, got %s", pools)
+	}
+	if !p.root.IsSameNode(p.nodes["socket #1"]) {
+		t.Errorf("expected socket #1 as the root pool, got %s", p.root.Name())
+	}
+	if mems := p.root.GetMemset().String(); mems != "2,3" {
+		t.Errorf("expected memset 2,3 for the root pool, got %s", mems)
+	}
+
+	// reserved CPUs are never handed out as exclusive ones
+	for i, pool := range []string{"numa node #2", "socket #1"} {
+		c := createGuaranteedContainer(t, cch, fmt.Sprintf("pod%d", i), 1)
+		grant, err := p.allocateFromPool(p.nodes[pool], newCPURequest(c))
+		if err != nil {
+			t.Fatalf("failed to allocate %s from %s: %v", c.PrettyName(), pool, err)
+		}
+		if exclusive := grant.ExclusiveCPUs(); exclusive.Size() != 1 || exclusive.Contains(4) {
+			t.Errorf("expected a single non-reserved exclusive CPU, got %s", grant)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
+)
+
+//
+// Rebalancing assigns a cost to the placement of each movable (non-guaranteed,
+// non-system) container, and compares it to the cost of the best alternative
+// placement with enough free capacity. The cost of a placement is the weighted
+// sum of
+//
+//   - topology hint mismatch: how badly the node fits the container's hints,
+//   - affinity violation: how much affinity is lost compared to the best node,
+//   - fragmentation: how high up the node is in the tree,
+//   - shared pool load: how oversubscribed the shared CPUs of the node are,
+//   - AVX512 mismatch: whether the node is dedicated to AVX512 users or not.
+//
+// Each round moves the container with the biggest improvement, as long as the
+// improvement exceeds the configured threshold and the configured maximum of
+// moves per round is not reached.
+//
+
+const (
+	// weight of topology hint mismatches in placement cost
+	hintCostWeight = 2.0
+	// weight of affinity violations in placement cost
+	affinityCostWeight = 1.0
+	// weight of fragmentation in placement cost
+	fragmentCostWeight = 0.5
+	// weight of shared pool load in placement cost
+	loadCostWeight = 1.0
+	// weight of AVX512 usage mismatches in placement cost
+	avx512CostWeight = 2.0
+)
+
+// move is a proposed container move from one pool to another.
+type move struct {
+	container cache.Container
+	from      Node
+	to        Node
+	cost      float64 // cost of the current placement
+	newCost   float64 // cost of the proposed placement
+}
+
+// String returns a printable representation of the move.
+func (m *move) String() string {
+	return fmt.Sprintf("%s: %s => %s (cost %.2f => %.2f)", m.container.PrettyName(),
+		m.from.Name(), m.to.Name(), m.cost, m.newCost)
+}
+
+// rebalance moves containers to better pools, returning the moves done.
+func (p *policy) rebalance() ([]*move, error) {
+	moves := []*move{}
+	moved := map[string]struct{}{}
+
+	for len(moves) < opt.RebalanceMaxMoves {
+		var best *move
+
+		for _, c := range p.movableContainers() {
+			if _, ok := moved[c.GetCacheID()]; ok {
+				continue
+			}
+			m := p.proposeMove(c)
+			if m == nil || m.cost-m.newCost <= opt.RebalanceThreshold {
+				continue
+			}
+			if best == nil || m.cost-m.newCost > best.cost-best.newCost {
+				best = m
+			}
+		}
+
+		if best == nil {
+			break
+		}
+
+		// don't retry containers which failed to move either
+		moved[best.container.GetCacheID()] = struct{}{}
+
+		ok, err := p.doMove(best)
+		if err != nil {
+			return moves, err
+		}
+		if ok {
+			moves = append(moves, best)
+		}
+	}
+
+	return moves, nil
+}
+
+// movableContainers returns the containers rebalancing is allowed to move.
+func (p *policy) movableContainers() []cache.Container {
+	movable := []cache.Container{}
+	for _, c := range p.cache.GetContainers() {
+		if c.GetQOSClass() == v1.PodQOSGuaranteed {
+			continue
+		}
+		if c.GetNamespace() == kubernetes.NamespaceSystem {
+			continue
+		}
+		if _, ok := p.allocations.CPU[c.GetCacheID()]; !ok {
+			continue
+		}
+		movable = append(movable, c)
+	}
+	return movable
+}
+
+// proposeMove finds the best alternative placement for a container, if any.
+func (p *policy) proposeMove(container cache.Container) *move {
+	grant := p.allocations.CPU[container.GetCacheID()]
+	current := grant.GetNode()
+	request := newCPURequest(container)
+	affinity := p.calculatePoolAffinities(container)
+
+	m := &move{
+		container: container,
+		from:      current,
+		cost:      p.placementCost(request, current, affinity, 0),
+	}
+
+	for _, n := range p.pools {
+		if n.IsSameNode(current) {
+			continue
+		}
+		score := n.GetScore(request)
+		if score.IsolatedCapacity() < 0 || score.SharedCapacity() < 0 ||
+			score.MemoryCapacity() < 0 || score.HugePageCapacity() < 0 {
+			continue
+		}
+		extra := request.CPUFraction()
+		if isAncestor(n, current) {
+			// already accounted for in the granted capacity of the subtree
+			extra = 0
+		}
+		cost := p.placementCost(request, n, affinity, extra)
+		if m.to == nil || cost < m.newCost {
+			m.to = n
+			m.newCost = cost
+		}
+	}
+
+	if m.to == nil {
+		return nil
+	}
+
+	return m
+}
+
+// placementCost calculates the cost of placing a request to a node with extra shared load.
+func (p *policy) placementCost(request CPURequest, n Node, affinity map[int]int32, extra int) float64 {
+	cost := 0.0
+
+	// topology hint mismatch
+	hints := request.GetContainer().GetTopologyHints()
+	if len(hints) > 0 {
+		scores := make(map[string]float64, len(hints))
+		for provider, hint := range hints {
+			scores[provider] = n.HintScore(hint)
+		}
+		combined, _ := combineHintScores(scores)
+		cost += hintCostWeight * (1.0 - combined)
+	}
+
+	// affinity violation, relative to the best and worst pools
+	if len(affinity) > 0 {
+		min, max := int32(0), int32(0)
+		for _, w := range affinity {
+			if w < min {
+				min = w
+			}
+			if w > max {
+				max = w
+			}
+		}
+		if max > min {
+			cost += affinityCostWeight * float64(max-affinity[n.NodeID()]) / float64(max-min)
+		}
+	}
+
+	// fragmentation
+	if p.depth > 0 {
+		cost += fragmentCostWeight * float64(n.NodeHeight()) / float64(p.depth)
+	}
+
+	// shared pool load
+	if capacity := 1000 * n.GetCPU().SharableCPUs().Size(); capacity > 0 {
+		cost += loadCostWeight * float64(n.GrantedCPU()+extra) / float64(capacity)
+	}
+
+	// AVX512 mismatch
+	if !avx512Fits(request.GetContainer(), n) {
+		cost += avx512CostWeight
+	}
+
+	return cost
+}
+
+// isAncestor checks if a node is an ancestor of another one.
+func isAncestor(ancestor, n Node) bool {
+	for n = n.Parent(); !n.IsNil(); n = n.Parent() {
+		if n.IsSameNode(ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// doMove moves a container to a new pool, restoring the old placement on failure.
+// It returns true if the container was moved.
+func (p *policy) doMove(m *move) (bool, error) {
+	log.Debug("rebalance: moving %s", m)
+
+	if _, _, err := p.releasePool(m.container); err != nil {
+		return false, policyError("failed to move %s: %v", m.container.PrettyName(), err)
+	}
+
+	moved := true
+	request := newCPURequest(m.container)
+	grant, err := p.allocateFromPool(m.to, request)
+	if err != nil {
+		log.Warn("rebalance: failed to move %s to %s: %v", m.container.PrettyName(),
+			m.to.Name(), err)
+		if grant, err = p.allocateFromPool(m.from, request); err != nil {
+			return false, policyError("failed to restore %s to %s: %v",
+				m.container.PrettyName(), m.from.Name(), err)
+		}
+		moved = false
+	}
+
+	if err := p.applyGrant(grant); err != nil {
+		return false, policyError("failed to move %s: %v", m.container.PrettyName(), err)
+	}
+
+	if err := p.updateSharedAllocations(grant); err != nil {
+		log.Warn("failed to update shared allocations affected by %s: %v",
+			m.container.PrettyName(), err)
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"testing"
+
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache/cachetest"
+)
+
+// createRebalanceTest creates a policy with the given number of burstable
+// containers, all requesting 200m CPU and placed in the root pool.
+func createRebalanceTest(t *testing.T, count int) (*policy, []cache.Container, func()) {
+	cch, cleanup := cachetest.NewCache(t)
+	p, cleanupPolicy := createTestPolicy(t, cch)
+
+	containers := []cache.Container{}
+	for i := 0; i < count; i++ {
+		c := cachetest.CreateContainer(t, cch, cachetest.Pod{
+			Name:         fmt.Sprintf("pod%d", i),
+			Namespace:    "default",
+			CgroupParent: fmt.Sprintf("/kubepods/burstable/pod%d-uid", i),
+			Resources:    &cri.LinuxContainerResources{CpuShares: 204},
+		})
+
+		grant, err := p.allocateFromPool(p.root, newCPURequest(c))
+		if err != nil {
+			t.Fatalf("failed to allocate %s from the root pool: %v", c.PrettyName(), err)
+		}
+		if err := p.applyGrant(grant); err != nil {
+			t.Fatalf("failed to apply grant %s: %v", grant, err)
+		}
+		containers = append(containers, c)
+	}
+
+	return p, containers, func() {
+		cleanupPolicy()
+		cleanup()
+	}
+}
+
+// setRebalanceOptions sets the rebalancing options, returning a function to restore them.
+func setRebalanceOptions(threshold float64, maxMoves int) func() {
+	savedThreshold, savedMaxMoves := opt.RebalanceThreshold, opt.RebalanceMaxMoves
+	opt.RebalanceThreshold, opt.RebalanceMaxMoves = threshold, maxMoves
+	return func() {
+		opt.RebalanceThreshold, opt.RebalanceMaxMoves = savedThreshold, savedMaxMoves
+	}
+}
+
+func TestRebalanceThreshold(t *testing.T) {
+	tcs := []struct {
+		name      string
+		threshold float64
+		moves     int
+	}{
+		{"below threshold", 1.0, 0},
+		{"above threshold", 0.25, 1},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setRebalanceOptions(tc.threshold, 2)()
+
+			p, containers, cleanup := createRebalanceTest(t, 1)
+			defer cleanup()
+
+			moves, err := p.rebalance()
+			if err != nil {
+				t.Fatalf("rebalancing failed: %v", err)
+			}
+			if len(moves) != tc.moves {
+				t.Fatalf("expected %d moves, got %v", tc.moves, moves)
+			}
+
+			node := p.allocations.CPU[containers[0].GetCacheID()].GetNode()
+			if tc.moves == 0 && !node.IsSameNode(p.root) {
+				t.Errorf("expected %s to stay in %s, moved to %s",
+					containers[0].PrettyName(), p.root.Name(), node.Name())
+			}
+			if tc.moves > 0 && !node.IsLeafNode() {
+				t.Errorf("expected %s to move to a leaf pool, got %s",
+					containers[0].PrettyName(), node.Name())
+			}
+		})
+	}
+}
+
+func TestRebalanceMaxMoves(t *testing.T) {
+	defer setRebalanceOptions(0.25, 2)()
+
+	p, containers, cleanup := createRebalanceTest(t, 3)
+	defer cleanup()
+
+	inRoot := func() int {
+		cnt := 0
+		for _, c := range containers {
+			if p.allocations.CPU[c.GetCacheID()].GetNode().IsSameNode(p.root) {
+				cnt++
+			}
+		}
+		return cnt
+	}
+
+	// at most 2 containers are moved per round, nothing is left for the last one
+	for round, expected := range []struct{ moves, left int }{{2, 1}, {1, 0}, {0, 0}} {
+		moved, err := p.Rebalance()
+		if err != nil {
+			t.Fatalf("round #%d: rebalancing failed: %v", round, err)
+		}
+		if moved != expected.moves {
+			t.Errorf("round #%d: expected %d moves, got %d", round, expected.moves, moved)
+		}
+		if left := inRoot(); left != expected.left {
+			t.Errorf("round #%d: expected %d containers left in %s, got %d",
+				round, expected.left, p.root.Name(), left)
+		}
+	}
+}
+
+func TestFailedMove(t *testing.T) {
+	p, containers, cleanup := createRebalanceTest(t, 1)
+	defer cleanup()
+
+	// take almost all shared CPU of numa node #1, leaving too little for a move there
+	numa1 := p.nodes["numa node #1"]
+	hog := cachetest.CreateContainer(t, p.cache, cachetest.Pod{
+		Name:         "hog",
+		Namespace:    "default",
+		CgroupParent: "/kubepods/burstable/podhog-uid",
+		Resources:    &cri.LinuxContainerResources{CpuShares: 1946},
+	})
+	if _, err := p.allocateFromPool(numa1, newCPURequest(hog)); err != nil {
+		t.Fatalf("failed to allocate %s from %s: %v", hog.PrettyName(), numa1.Name(), err)
+	}
+
+	c := containers[0]
+	moved, err := p.doMove(&move{container: c, from: p.root, to: numa1})
+	if err != nil {
+		t.Fatalf("failed to restore %s: %v", c.PrettyName(), err)
+	}
+	if moved {
+		t.Errorf("expected %s not to move to %s", c.PrettyName(), numa1.Name())
+	}
+	if node := p.allocations.CPU[c.GetCacheID()].GetNode(); !node.IsSameNode(p.root) {
+		t.Errorf("expected %s to stay in %s, got %s", c.PrettyName(), p.root.Name(), node.Name())
+	}
+}
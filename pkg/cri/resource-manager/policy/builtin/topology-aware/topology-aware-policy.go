@@ -15,7 +15,6 @@
 package topologyaware
 
 import (
-	v1 "k8s.io/api/core/v1"
 	resapi "k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
 
@@ -39,6 +38,7 @@ const (
 type allocations struct {
 	policy *policy
 	CPU    map[string]CPUGrant
+	Memory map[string]MemoryGrant
 }
 
 // TODO(rojkov): this is the interface of system.System we consume in this Go package. Should be moved to the package which is supposed to provide the interface.
@@ -52,6 +52,7 @@ type discoveredSystem interface {
 	NUMANodeCount() int
 	PackageIDs() []system.ID
 	NodeIDs() []system.ID
+	CacheDomains(uint8) []*system.Cache
 }
 
 // policy is our runtime state for the topology aware policy.
@@ -69,7 +70,10 @@ type policy struct {
 	nodeCnt     int                      // number of pools
 	depth       int                      // tree depth
 	allocations allocations              // container pool assignments
-
+	detach      func()                   // detaches our configuration callbacks
+	applied     options                  // options our current allocations were made with
+	snapshot    *grantSnapshot           // grants before the last reallocation, for reverting it
+	cachePools  bool                     // whether we have built cache domain pools
 }
 
 // Make sure policy implements the policy.Backend interface.
@@ -84,7 +88,11 @@ func CreateTopologyAwarePolicy(opts *policyapi.BackendOptions) policyapi.Backend
 	}
 
 	p.nodes = make(map[string]Node)
-	p.allocations = allocations{policy: p, CPU: make(map[string]CPUGrant, 32)}
+	p.allocations = allocations{
+		policy: p,
+		CPU:    make(map[string]CPUGrant, 32),
+		Memory: make(map[string]MemoryGrant, 32),
+	}
 
 	if err := p.checkConstraints(); err != nil {
 		log.Fatal("failed to create topology-aware policy: %v", err)
@@ -96,7 +104,16 @@ func CreateTopologyAwarePolicy(opts *policyapi.BackendOptions) policyapi.Backend
 
 	p.addImplicitAffinities()
 
-	config.GetModule(PolicyPath).AddNotify(p.configNotify)
+	if err := p.checkAvx512Pools(opt); err != nil {
+		log.Warn("%v", err)
+	}
+
+	detachNotify := config.GetModule(PolicyPath).AttachNotify(p.configNotify)
+	detachCheck := config.GetModule(PolicyPath).AttachCheck(p.configCheck)
+	p.detach = func() {
+		detachNotify()
+		detachCheck()
+	}
 
 	p.root.Dump("<pre-start>")
 
@@ -120,10 +137,16 @@ func (p *policy) Start(add []cache.Container, del []cache.Container) error {
 	}
 
 	p.root.Dump("<post-start>")
+	p.applied = *opt
 
 	return p.Sync(add, del)
 }
 
+// Stop deactivates this policy.
+func (p *policy) Stop() {
+	p.detach()
+}
+
 // Sync synchronizes the state of this policy.
 func (p *policy) Sync(add []cache.Container, del []cache.Container) error {
 	log.Debug("synchronizing state...")
@@ -187,36 +210,93 @@ func (p *policy) ReleaseResources(container cache.Container) error {
 }
 
 // UpdateResources is a resource allocation update request for this policy.
-func (p *policy) UpdateResources(c cache.Container) error {
-	log.Debug("(not) updating container %s...", c.PrettyName())
+func (p *policy) UpdateResources(container cache.Container) error {
+	log.Debug("updating resources of %s...", container.PrettyName())
+
+	grant, ok := p.allocations.CPU[container.GetCacheID()]
+	if !ok {
+		log.Debug("  => no grant found, nothing to update...")
+		return nil
+	}
+
+	request := newCPURequest(container)
+	memory := memoryRequirement(container)
+	if mem, ok := p.allocations.Memory[container.GetCacheID()]; ok && mem.MemoryLimit() != memory {
+		log.Debug("  => reallocating %s for %s memory...", mem, prettyMem(memory))
+	} else if grant.ExclusiveCPUs().Size() == request.FullCPUs() &&
+		grant.SharedPortion() == request.CPUFraction() {
+		log.Debug("  => %s still satisfies %s, nothing to update...", grant, request)
+		return nil
+	}
+
+	log.Debug("  => reallocating %s for %s...", grant, request)
+
+	if _, err := p.ReallocateResources(container); err != nil {
+		return policyError("failed to update resources of %s: %v", container.PrettyName(), err)
+	}
+
 	return nil
 }
 
-// Rebalance tries to find an optimal allocation of resources for the current containers.
-func (p *policy) Rebalance() (bool, error) {
-	var errors error
+// ReallocateResources moves a container to the best pool for its current state.
+func (p *policy) ReallocateResources(container cache.Container) (bool, error) {
+	old, ok := p.allocations.CPU[container.GetCacheID()]
+	if !ok {
+		return false, nil
+	}
 
-	containers := p.cache.GetContainers()
-	movable := []cache.Container{}
+	log.Debug("reallocating resources of %s...", container.PrettyName())
 
-	for _, c := range containers {
-		if c.GetQOSClass() != v1.PodQOSGuaranteed {
-			p.ReleaseResources(c)
-			movable = append(movable, c)
+	if _, _, err := p.releasePool(container); err != nil {
+		return false, policyError("failed to reallocate %s: %v", container.PrettyName(), err)
+	}
+
+	grant, err := p.allocatePool(container)
+	if err != nil {
+		log.Warn("failed to reallocate %s: %v", container.PrettyName(), err)
+		if grant, err = p.allocateFromPool(old.GetNode(), newCPURequest(container)); err != nil {
+			return false, policyError("failed to restore %s to %s: %v",
+				container.PrettyName(), old.GetNode().Name(), err)
 		}
 	}
 
-	for _, c := range movable {
-		if err := p.AllocateResources(c); err != nil {
-			if errors == nil {
-				errors = err
-			} else {
-				errors = policyError("%v, %v", errors, err)
-			}
+	if err := p.applyGrant(grant); err != nil {
+		return false, policyError("failed to reallocate %s: %v", container.PrettyName(), err)
+	}
+
+	if err := p.updateSharedAllocations(grant); err != nil {
+		log.Warn("failed to update shared allocations affected by %s: %v",
+			container.PrettyName(), err)
+	}
+
+	changed := !grant.GetNode().IsSameNode(old.GetNode())
+	if changed {
+		log.Info("reallocated %s: %s => %s", container.PrettyName(),
+			old.GetNode().Name(), grant.GetNode().Name())
+		p.root.Dump("<post-reallocate>")
+	}
+
+	return changed, nil
+}
+
+// Rebalance tries to find an optimal allocation of resources for the current containers.
+func (p *policy) Rebalance() (int, error) {
+	log.Debug("rebalancing containers...")
+
+	moves, err := p.rebalance()
+
+	if len(moves) == 0 {
+		log.Info("rebalance: no moves improve placement beyond threshold %.2f",
+			opt.RebalanceThreshold)
+	} else {
+		log.Info("rebalance: moved %d containers:", len(moves))
+		for _, m := range moves {
+			log.Info("  - %s", m)
 		}
+		p.root.Dump("<post-rebalance>")
 	}
 
-	return true, errors
+	return len(moves), err
 }
 
 // ExportResourceData provides resource data to export for the container.
@@ -244,23 +324,183 @@ func (p *policy) ExportResourceData(c cache.Container) map[string]string {
 	return data
 }
 
+// configCheck checks a candidate configuration without taking it into use.
+func (p *policy) configCheck(candidate interface{}, source config.Source) error {
+	return p.checkConfig(candidate.(*options))
+}
+
+// checkConfig checks if the given configuration can be taken into use.
+func (p *policy) checkConfig(o *options) error {
+	if err := p.checkAvx512Pools(o); err != nil {
+		return err
+	}
+	if o.CacheDomainPools != p.cachePools {
+		return policyError("changing CacheDomainPools requires a restart")
+	}
+	return nil
+}
+
 func (p *policy) configNotify(event config.Event, source config.Source) error {
 	log.Info("configuration %s:", event)
 	log.Info("  - pin containers to CPUs: %v", opt.PinCPU)
 	log.Info("  - pin containers to memory: %v", opt.PinMemory)
 	log.Info("  - prefer isolated CPUs: %v", opt.PreferIsolated)
 	log.Info("  - prefer shared CPUs: %v", opt.PreferShared)
+	log.Info("  - rebalance threshold: %.2f", opt.RebalanceThreshold)
+	log.Info("  - rebalance max. moves: %d", opt.RebalanceMaxMoves)
+	log.Info("  - AVX512 pools: %v", opt.AVX512Pools)
+	log.Info("  - prefer idle cache domains: %v", opt.PreferIdleCacheDomains)
+	log.Info("  - cache domain pools: %v", opt.CacheDomainPools)
+
+	if err := p.checkConfig(opt); err != nil {
+		return err
+	}
 
-	// TODO: We probably should release and reallocate resources for all containers
-	//   to honor the latest configuration. Depending on the changes that might be
-	//   disruptive to some containers, so whether we do so or not should probably
-	//   be part of the configuration as well.
+	// a rollback of our last reallocation puts back the grants we had before it
+	if event == config.RevertEvent && p.snapshot != nil {
+		log.Info("configuration reverted, restoring allocations...")
+		p.restoreGrants(p.snapshot)
+		p.snapshot = nil
+		p.applied = *opt
+		p.saveConfig()
+		return nil
+	}
+	p.snapshot = nil
+
+	if p.allocationOptionsChanged() {
+		log.Info("allocation options changed, reallocating containers...")
+		snapshot := p.snapshotGrants()
+		if err := p.reallocateContainers(); err != nil {
+			p.restoreGrants(snapshot)
+			return err
+		}
+		p.snapshot = snapshot
+	}
+	p.applied = *opt
 
 	p.saveConfig()
 
 	return nil
 }
 
+// allocationOptionsChanged checks if options affecting existing allocations have changed.
+func (p *policy) allocationOptionsChanged() bool {
+	return p.applied.PinCPU != opt.PinCPU ||
+		p.applied.PinMemory != opt.PinMemory ||
+		p.applied.PreferIsolated != opt.PreferIsolated ||
+		p.applied.PreferShared != opt.PreferShared
+}
+
+// reallocateContainers reallocates all containers using the current options. Containers
+// are reallocated one by one, each falling back to its old pool on failure, so none of
+// them is left without resources.
+func (p *policy) reallocateContainers() error {
+	containers := []cache.Container{}
+	for _, c := range p.cache.GetContainers() {
+		if _, ok := p.allocations.CPU[c.GetCacheID()]; ok {
+			containers = append(containers, c)
+		}
+	}
+
+	for _, c := range containers {
+		// unpin containers if pinning got disabled
+		if p.applied.PinCPU && !opt.PinCPU {
+			c.SetCpusetCpus(p.allowed.String())
+		}
+		if p.applied.PinMemory && !opt.PinMemory {
+			c.SetCpusetMems(p.root.GetMemset().String())
+		}
+		if _, err := p.ReallocateResources(c); err != nil {
+			return policyError("failed to reallocate %s: %v", c.PrettyName(), err)
+		}
+	}
+
+	p.root.Dump("<post-reallocate>")
+
+	return nil
+}
+
+// grantSnapshot is a copy of all grants and the resulting container placement.
+type grantSnapshot struct {
+	cpu       map[string]CPUGrant
+	mem       map[string]MemoryGrant
+	placement map[string]placement
+}
+
+// placement is the resource assignment of a container, as set up by its grants.
+type placement struct {
+	cpus   string
+	mems   string
+	shares int64
+	pool   string
+}
+
+// placementOf returns the current placement of the given container.
+func placementOf(c cache.Container) placement {
+	pool, _ := c.GetTag(cache.TagPool)
+	return placement{
+		cpus:   c.GetCpusetCpus(),
+		mems:   c.GetCpusetMems(),
+		shares: c.GetCPUShares(),
+		pool:   pool,
+	}
+}
+
+// snapshotGrants takes a snapshot of the current grants of all containers.
+func (p *policy) snapshotGrants() *grantSnapshot {
+	s := &grantSnapshot{
+		cpu:       make(map[string]CPUGrant, len(p.allocations.CPU)),
+		mem:       make(map[string]MemoryGrant, len(p.allocations.Memory)),
+		placement: make(map[string]placement, len(p.allocations.CPU)),
+	}
+	for id, grant := range p.allocations.CPU {
+		s.cpu[id] = grant
+		s.placement[id] = placementOf(grant.GetContainer())
+	}
+	for id, grant := range p.allocations.Memory {
+		s.mem[id] = grant
+	}
+	return s
+}
+
+// restoreGrants puts back the grants and container placement of the given snapshot.
+func (p *policy) restoreGrants(s *grantSnapshot) {
+	for id, grant := range p.allocations.CPU {
+		if old, ok := s.cpu[id]; !ok || old != grant {
+			if _, _, err := p.releasePool(grant.GetContainer()); err != nil {
+				log.Error("failed to release %s: %v", grant, err)
+			}
+		}
+	}
+
+	for id, grant := range s.cpu {
+		if _, ok := p.allocations.CPU[id]; ok {
+			continue
+		}
+		grant.GetNode().FreeCPU().Reserve(grant)
+		p.allocations.CPU[id] = grant
+		if mem, ok := s.mem[id]; ok {
+			mem.GetNode().FreeMemory().Reserve(mem)
+			p.allocations.Memory[id] = mem
+		}
+	}
+
+	for id, old := range s.placement {
+		c := s.cpu[id].GetContainer()
+		if placementOf(c) == old {
+			continue
+		}
+		log.Debug("restoring placement of %s", c.PrettyName())
+		c.SetCpusetCpus(old.cpus)
+		c.SetCpusetMems(old.mems)
+		c.SetCPUShares(old.shares)
+		c.SetTag(cache.TagPool, old.pool)
+	}
+
+	p.saveAllocations()
+	p.root.Dump("<post-restore>")
+}
+
 // Check the constraints passed to us.
 func (p *policy) checkConstraints() error {
 	if c, ok := p.options.Available[policyapi.DomainCPU]; ok {
@@ -310,6 +550,9 @@ func (p *policy) restoreCache() error {
 		log.Warn("no allocations found in cache...")
 		p.saveAllocations()
 	} else {
+		for _, mem := range p.allocations.Memory {
+			mem.GetNode().FreeMemory().Reserve(mem)
+		}
 		p.allocations.Dump(log.Info, "restored ")
 	}
 
@@ -0,0 +1,199 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"fmt"
+	"testing"
+
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache/cachetest"
+)
+
+func TestReallocateContainers(t *testing.T) {
+	p, containers, cleanup := createRebalanceTest(t, 3)
+	defer cleanup()
+
+	c := createGuaranteedContainer(t, p.cache, "guaranteed", 1)
+	grant, err := p.allocateFromPool(p.root, newCPURequest(c))
+	if err != nil {
+		t.Fatalf("failed to allocate %s: %v", c.PrettyName(), err)
+	}
+	if err := p.applyGrant(grant); err != nil {
+		t.Fatalf("failed to apply grant %s: %v", grant, err)
+	}
+	containers = append(containers, c)
+
+	saved := *opt
+	defer func() { *opt = saved }()
+
+	for _, pin := range []bool{false, true} {
+		p.applied = *opt
+		opt.PinCPU = pin
+
+		if err := p.reallocateContainers(); err != nil {
+			t.Fatalf("failed to reallocate containers (PinCPU %v): %v", pin, err)
+		}
+
+		for _, c := range containers {
+			grant, ok := p.allocations.CPU[c.GetCacheID()]
+			if !ok {
+				t.Errorf("%s left without resources (PinCPU %v)", c.PrettyName(), pin)
+				continue
+			}
+			expected := p.allowed
+			if pin {
+				expected = grant.ExclusiveCPUs().Union(grant.SharedCPUs())
+			}
+			if cpus := cpuset.MustParse(c.GetCpusetCpus()); !cpus.Equals(expected) {
+				t.Errorf("expected %s with CPUs %s (PinCPU %v), got %s",
+					c.PrettyName(), expected, pin, cpus)
+			}
+		}
+	}
+
+	if exclusive := p.allocations.CPU[c.GetCacheID()].ExclusiveCPUs(); exclusive.Size() != 1 {
+		t.Errorf("expected %s to get back an exclusive CPU, got %s", c.PrettyName(), exclusive)
+	}
+}
+
+// policyState captures the grants, free supplies and container placement of a policy.
+type policyState struct {
+	grants    map[string]CPUGrant
+	free      map[string]string
+	placement map[string]placement
+}
+
+// getPolicyState returns the current state of the policy.
+func getPolicyState(p *policy, containers []cache.Container) policyState {
+	s := policyState{
+		grants:    map[string]CPUGrant{},
+		free:      map[string]string{},
+		placement: map[string]placement{},
+	}
+	for id, grant := range p.allocations.CPU {
+		s.grants[id] = grant
+	}
+	for name, n := range p.nodes {
+		s.free[name] = n.FreeCPU().String() + ", " + n.FreeMemory().String()
+	}
+	for _, c := range containers {
+		s.placement[c.GetCacheID()] = placementOf(c)
+	}
+	return s
+}
+
+// checkPolicyState checks that the policy is in the expected state.
+func checkPolicyState(t *testing.T, p *policy, containers []cache.Container, expected policyState) {
+	state := getPolicyState(p, containers)
+	for _, c := range containers {
+		id := c.GetCacheID()
+		if state.grants[id] != expected.grants[id] {
+			t.Errorf("expected %s to have grant %s, got %s",
+				c.PrettyName(), expected.grants[id], state.grants[id])
+		}
+		if state.placement[id] != expected.placement[id] {
+			t.Errorf("expected %s with placement %+v, got %+v",
+				c.PrettyName(), expected.placement[id], state.placement[id])
+		}
+	}
+	for name, free := range expected.free {
+		if state.free[name] != free {
+			t.Errorf("expected free resources %s in %s, got %s", free, name, state.free[name])
+		}
+	}
+}
+
+func TestRestoreGrants(t *testing.T) {
+	saved := *opt
+	defer func() { *opt = saved }()
+
+	tcs := []struct {
+		name   string
+		revert func(p *policy, snapshot *grantSnapshot)
+	}{
+		{
+			name: "reallocation failure",
+			revert: func(p *policy, snapshot *grantSnapshot) {
+				p.restoreGrants(snapshot)
+			},
+		},
+		{
+			name: "configuration rollback",
+			revert: func(p *policy, snapshot *grantSnapshot) {
+				opt.PinCPU = true
+				if err := p.configNotify(config.RevertEvent, config.ConfigBackup); err != nil {
+					t.Fatalf("failed to revert configuration: %v", err)
+				}
+			},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			*opt = saved
+			opt.PinCPU = true
+
+			cch, cleanup := cachetest.NewCache(t)
+			defer cleanup()
+			p, cleanupPolicy := createTestPolicy(t, cch)
+			defer cleanupPolicy()
+
+			// containers are pinned to the NUMA nodes they are allocated from
+			containers := []cache.Container{}
+			for i := 0; i < 4; i++ {
+				c := cachetest.CreateContainer(t, cch, cachetest.Pod{
+					Name:         fmt.Sprintf("pod%d", i),
+					Namespace:    "default",
+					CgroupParent: fmt.Sprintf("/kubepods/burstable/pod%d-uid", i),
+					Resources:    &cri.LinuxContainerResources{CpuShares: 204},
+				})
+				pool := p.nodes[fmt.Sprintf("numa node #%d", i)]
+				grant, err := p.allocateFromPool(pool, newCPURequest(c))
+				if err != nil {
+					t.Fatalf("failed to allocate %s from %s: %v", c.PrettyName(), pool.Name(), err)
+				}
+				if err := p.applyGrant(grant); err != nil {
+					t.Fatalf("failed to apply grant %s: %v", grant, err)
+				}
+				containers = append(containers, c)
+			}
+			p.applied = *opt
+
+			before := getPolicyState(p, containers)
+
+			// all containers get reallocated, and unpinned
+			opt.PinCPU = false
+			snapshot := p.snapshotGrants()
+			if err := p.configNotify(config.UpdateEvent, config.ConfigExternal); err != nil {
+				t.Fatalf("failed to update configuration: %v", err)
+			}
+			for _, c := range containers {
+				if p.allocations.CPU[c.GetCacheID()] == before.grants[c.GetCacheID()] {
+					t.Errorf("expected %s to be reallocated", c.PrettyName())
+				}
+				if cpus := c.GetCpusetCpus(); cpus != p.allowed.String() {
+					t.Errorf("expected %s to be unpinned, got CPUs %s", c.PrettyName(), cpus)
+				}
+			}
+
+			tc.revert(p, snapshot)
+			checkPolicyState(t, p, containers, before)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topologyaware
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+
+	policyapi "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+)
+
+// GetTopologyZones returns the capacity and free resources of our socket, NUMA node and
+// cache domain pools.
+func (p *policy) GetTopologyZones() []*policyapi.TopologyZone {
+	zones := []*policyapi.TopologyZone{}
+
+	for _, n := range p.pools {
+		if n.Kind() != SocketNode && n.Kind() != NumaNode && n.Kind() != CacheNode {
+			continue
+		}
+
+		zone := &policyapi.TopologyZone{
+			Name: n.Name(),
+			Type: string(n.Kind()),
+		}
+		if parent := n.Parent(); !parent.IsNil() && parent.Kind() != VirtualNode {
+			zone.Parent = parent.Name()
+		}
+
+		capacity, available := p.zoneCPU(n)
+		zone.Resources = append(zone.Resources, &policyapi.ZoneResource{
+			Name:      string(v1.ResourceCPU),
+			Capacity:  *resapi.NewMilliQuantity(int64(capacity), resapi.DecimalSI),
+			Available: *resapi.NewMilliQuantity(int64(available), resapi.DecimalSI),
+		})
+
+		mem, freemem := n.GetMemory(), n.FreeMemory()
+		if mem.Capacity() > 0 {
+			zone.Resources = append(zone.Resources, &policyapi.ZoneResource{
+				Name:      string(v1.ResourceMemory),
+				Capacity:  *resapi.NewQuantity(int64(mem.Capacity()), resapi.BinarySI),
+				Available: *resapi.NewQuantity(int64(freemem.Free()), resapi.BinarySI),
+			})
+		}
+		for _, size := range mem.HugePageSizes() {
+			name := v1.ResourceHugePagesPrefix + resapi.NewQuantity(int64(size), resapi.BinarySI).String()
+			zone.Resources = append(zone.Resources, &policyapi.ZoneResource{
+				Name:      name,
+				Capacity:  *resapi.NewQuantity(int64(mem.HugePageCapacity(size)), resapi.BinarySI),
+				Available: *resapi.NewQuantity(int64(freemem.FreeHugePages(size)), resapi.BinarySI),
+			})
+		}
+
+		zones = append(zones, zone)
+	}
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+
+	return zones
+}
+
+// zoneCPU returns the CPU capacity and the unallocated CPU (in milli-CPU) of a pool.
+//
+// The free CPU supply of a pool does not reflect allocations from its children,
+// so we account for the grants instead. Exclusive CPUs are accounted to every
+// pool they belong to, shared portions to the granting pool and its ancestors.
+func (p *policy) zoneCPU(n Node) (int, int) {
+	supply := n.GetCPU()
+	cpus := supply.IsolatedCPUs().Union(supply.SharableCPUs())
+	capacity := 1000 * cpus.Size()
+
+	allocated := 0
+	for _, g := range p.allocations.CPU {
+		allocated += 1000 * g.ExclusiveCPUs().Intersection(cpus).Size()
+		for gn := g.GetNode(); !gn.IsNil(); gn = gn.Parent() {
+			if gn.IsSameNode(n) {
+				allocated += g.SharedPortion()
+				break
+			}
+		}
+	}
+
+	if allocated > capacity {
+		return capacity, 0
+	}
+	return capacity, capacity - allocated
+}
@@ -17,6 +17,7 @@ package policy
 import (
 	"encoding/json"
 	"github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/rdt"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
 	"strconv"
@@ -26,6 +27,8 @@ import (
 const (
 	// NullPolicy is the reserved name for disabling policy altogether.
 	NullPolicy = "null"
+	// ConfigPath is the configuration module path for the generic policy layer.
+	ConfigPath = "policy"
 )
 
 // Options captures our configurable parameters.
@@ -36,11 +39,55 @@ type options struct {
 	Available ConstraintSet `json:"AvailableResources,omitempty"`
 	// Reserved hardware resources, for system and kube tasks.
 	Reserved ConstraintSet `json:"ReservedResources,omitempty"`
+	// DeviceHints is the default strictness for honoring device topology hints.
+	DeviceHints HintStrictness `json:"DeviceHints,omitempty"`
+	// NamespaceDeviceHints overrides the device topology hint strictness per namespace.
+	NamespaceDeviceHints map[string]HintStrictness `json:"NamespaceDeviceHints,omitempty"`
+	// Partitions splits resources between several active policies, overriding Policy.
+	Partitions []*Partition `json:"Partitions,omitempty"`
+	// RdtBudgets are the default L3 cache and memory bandwidth budgets per QoS class.
+	RdtBudgets map[string]ConstraintSet `json:"RdtBudgets,omitempty"`
 }
 
 // Our runtime configuration.
 var opt = defaultOptions().(*options)
 
+// UnmarshalJSON implements JSON unmarshalling for options, on top of the defaults.
+func (o *options) UnmarshalJSON(raw []byte) error {
+	type plainOptions options
+	cfg := plainOptions(*defaultOptions().(*options))
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return policyError("failed to unmarshal policy options: %v", err)
+	}
+	if err := cfg.Available.checkResources("available"); err != nil {
+		return err
+	}
+	if err := cfg.Reserved.checkResources("reserved"); err != nil {
+		return err
+	}
+	for _, part := range cfg.Partitions {
+		if err := part.Available.checkResources("partition " + part.Name + " available"); err != nil {
+			return err
+		}
+		if err := part.Reserved.checkResources("partition " + part.Name + " reserved"); err != nil {
+			return err
+		}
+	}
+	*o = options(cfg)
+	return nil
+}
+
+// checkResources checks that the set only constrains resources given to policies.
+func (cs ConstraintSet) checkResources(kind string) error {
+	for domain := range cs {
+		if domain == DomainCache || domain == DomainMemoryBW {
+			return policyError("invalid %s resources: %s is only valid in RDT budgets",
+				kind, domain)
+		}
+	}
+	return nil
+}
+
 // MarshalJSON implements JSON marshalling for ConstraintSets.
 func (cs ConstraintSet) MarshalJSON() ([]byte, error) {
 	obj := map[string]interface{}{}
@@ -54,6 +101,9 @@ func (cs ConstraintSet) MarshalJSON() ([]byte, error) {
 			obj[name] = qty.String()
 		case int:
 			obj[name] = strconv.Itoa(constraint.(int))
+			if domain == DomainCache || domain == DomainMemoryBW {
+				obj[name] = obj[name].(string) + "%"
+			}
 		default:
 			return nil, policyError("invalid %v constraint of type %T", domain, constraint)
 		}
@@ -61,6 +111,20 @@ func (cs ConstraintSet) MarshalJSON() ([]byte, error) {
 	return json.Marshal(obj)
 }
 
+// ConfigSchema describes ConstraintSets for config schema generation.
+func (cs *ConstraintSet) ConfigSchema() *config.Schema {
+	return &config.Schema{
+		Type:        "object",
+		Description: "Resource constraints per domain, for instance CPU: cpuset:0-3, CPU: 750m or CPU: 2.",
+		AdditionalProperties: &config.Schema{
+			OneOf: []*config.Schema{
+				{Type: "string"},
+				{Type: "number"},
+			},
+		},
+	}
+}
+
 // UnmarshalJSON implements JSON unmarshalling for ConstraintSets.
 func (cs *ConstraintSet) UnmarshalJSON(raw []byte) error {
 	set := make(ConstraintSet)
@@ -107,6 +171,28 @@ func (cs *ConstraintSet) UnmarshalJSON(raw []byte) error {
 				return policyError("invalid CPU constraint of type %T", value)
 			}
 
+		case DomainCache.isEqual(name), DomainMemoryBW.isEqual(name):
+			domain := DomainCache
+			if DomainMemoryBW.isEqual(name) {
+				domain = DomainMemoryBW
+			}
+			switch value.(type) {
+			case string:
+				pct, err := rdt.ParseBudgetPercentage(value.(string))
+				if err != nil {
+					return policyError("failed to unmarshal %s constraint: %v", domain, err)
+				}
+				set[domain] = int(pct)
+			case float64:
+				pct := value.(float64)
+				if pct < 0 || pct > 100 || pct != float64(int(pct)) {
+					return policyError("invalid %s constraint %v", domain, pct)
+				}
+				set[domain] = int(pct)
+			default:
+				return policyError("invalid %s constraint of type %T", domain, value)
+			}
+
 		default:
 			return policyError("internal error: unhandled ConstraintSet domain %s", name)
 		}
@@ -140,13 +226,16 @@ func (d Domain) isEqual(domain interface{}) bool {
 // defaultOptions returns a new options instance, all initialized to defaults.
 func defaultOptions() interface{} {
 	return &options{
-		Policy:    NullPolicy,
-		Available: ConstraintSet{},
-		Reserved:  ConstraintSet{},
+		Policy:               NullPolicy,
+		Available:            ConstraintSet{},
+		Reserved:             ConstraintSet{},
+		DeviceHints:          HintsPrefer,
+		NamespaceDeviceHints: map[string]HintStrictness{},
+		RdtBudgets:           map[string]ConstraintSet{},
 	}
 }
 
 // Register us for configuration handling.
 func init() {
-	config.Register("policy", "Generic policy layer.", opt, defaultOptions)
+	config.Register(ConfigPath, "Generic policy layer.", opt, defaultOptions)
 }
@@ -0,0 +1,169 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"encoding/json"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cpuallocator"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/topology"
+)
+
+// HintStrictness controls how strictly device topology hints are honored.
+type HintStrictness string
+
+const (
+	// HintsPrefer takes CPUs local to devices first, falling back to any other CPUs.
+	HintsPrefer HintStrictness = "prefer"
+	// HintsRequire takes CPUs only local to devices, failing if there are not enough.
+	HintsRequire HintStrictness = "require"
+	// keyDeviceHints is the annotation used to override hint strictness for a pod.
+	keyDeviceHints = "device-hints"
+)
+
+// ConfigSchema describes HintStrictness for config schema generation.
+func (s *HintStrictness) ConfigSchema() *config.Schema {
+	return config.EnumSchema("Strictness of honoring device topology hints.",
+		string(HintsPrefer), string(HintsRequire))
+}
+
+// UnmarshalJSON implements JSON unmarshalling for HintStrictness, rejecting invalid values.
+func (s *HintStrictness) UnmarshalJSON(raw []byte) error {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return policyError("failed to unmarshal device hint strictness: %v", err)
+	}
+	switch strictness := HintStrictness(value); strictness {
+	case HintsPrefer, HintsRequire:
+		*s = strictness
+		return nil
+	}
+	return policyError("invalid device hint strictness '%s', expecting %s or %s",
+		value, HintsPrefer, HintsRequire)
+}
+
+// DeviceHintStrictness returns the hint strictness to use for the given container.
+func DeviceHintStrictness(c cache.Container) HintStrictness {
+	if pod, ok := c.GetPod(); ok {
+		if value, ok := pod.GetResmgrAnnotation(keyDeviceHints); ok {
+			switch strictness := HintStrictness(value); strictness {
+			case HintsPrefer, HintsRequire:
+				return strictness
+			default:
+				log.Error("invalid annotation '%s' on container %s, expecting %s or %s",
+					keyDeviceHints, c.PrettyName(), HintsPrefer, HintsRequire)
+			}
+		}
+	}
+
+	if strictness, ok := opt.NamespaceDeviceHints[c.GetNamespace()]; ok {
+		return strictness
+	}
+
+	return opt.DeviceHints
+}
+
+// HintCPUs returns the set of CPUs local to the devices the given hints originate from.
+func HintCPUs(sys *sysfs.System, hints topology.Hints) cpuset.CPUSet {
+	cpus := cpuset.NewCPUSet()
+
+	for _, hint := range hints {
+		switch {
+		case hint.CPUs != "":
+			cset, err := cpuset.Parse(hint.CPUs)
+			if err != nil {
+				log.Warn("ignoring unparsable topology hint %s: %v", hint.String(), err)
+				continue
+			}
+			cpus = cpus.Union(cset)
+
+		case hint.NUMAs != "":
+			ids, err := cpuset.Parse(hint.NUMAs)
+			if err != nil {
+				log.Warn("ignoring unparsable topology hint %s: %v", hint.String(), err)
+				continue
+			}
+			for _, id := range ids.ToSlice() {
+				if node := sys.Node(sysfs.ID(id)); node != nil {
+					cpus = cpus.Union(node.CPUSet())
+				}
+			}
+
+		case hint.Sockets != "":
+			ids, err := cpuset.Parse(hint.Sockets)
+			if err != nil {
+				log.Warn("ignoring unparsable topology hint %s: %v", hint.String(), err)
+				continue
+			}
+			for _, id := range ids.ToSlice() {
+				if pkg := sys.Package(sysfs.ID(id)); pkg != nil {
+					cpus = cpus.Union(pkg.CPUSet())
+				}
+			}
+		}
+	}
+
+	return cpus
+}
+
+// AllocateCPUsByHints takes cnt CPUs from the given set, preferring CPUs local to the devices
+// of the container. Depending on the hint strictness for the container, it either falls back
+// to taking non-local CPUs or fails if there are not enough local ones available.
+func AllocateCPUsByHints(sys *sysfs.System, from *cpuset.CPUSet, cnt int, c cache.Container) (cpuset.CPUSet, error) {
+	var hints topology.Hints
+
+	if c != nil {
+		hints = c.GetTopologyHints()
+	}
+	if len(hints) == 0 || sys == nil {
+		return cpuallocator.AllocateCpus(from, cnt)
+	}
+
+	local := from.Intersection(HintCPUs(sys, hints))
+	strictness := DeviceHintStrictness(c)
+
+	log.Debug("%s: %d CPUs local to devices available in %s (%s)",
+		c.PrettyName(), local.Size(), from.String(), strictness)
+
+	switch {
+	case local.Size() >= cnt:
+		cset, err := cpuallocator.AllocateCpus(&local, cnt)
+		if err != nil {
+			return cset, err
+		}
+		*from = from.Difference(cset)
+		return cset, nil
+
+	case strictness == HintsRequire:
+		return cpuset.NewCPUSet(), policyError("%s: not enough CPUs local to devices (%d < %d)",
+			c.PrettyName(), local.Size(), cnt)
+	}
+
+	// take all the local CPUs and the rest by topology from the remaining ones
+	rest := from.Difference(local)
+	cset, err := cpuallocator.AllocateCpus(&rest, cnt-local.Size())
+	if err != nil {
+		return cset, err
+	}
+	cset = cset.Union(local)
+	*from = from.Difference(cset)
+
+	return cset, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cpuallocator"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache/cachetest"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
+	"github.com/intel/cri-resource-manager/pkg/sysfs"
+	"github.com/intel/cri-resource-manager/pkg/testutils"
+)
+
+// createContainer creates a container with the given kubelet-assigned cpuset
+// in a pod with the given namespace and device hint annotation.
+func createContainer(t *testing.T, cch cache.Cache, name, namespace, annotation, cpus string) cache.Container {
+	annotations := map[string]string{}
+	if annotation != "" {
+		annotations[kubernetes.ResmgrKey(keyDeviceHints)] = annotation
+	}
+	return cachetest.CreateContainer(t, cch, cachetest.Pod{
+		Name:        name,
+		Namespace:   namespace,
+		Annotations: annotations,
+		Resources:   &cri.LinuxContainerResources{CpusetCpus: cpus},
+	})
+}
+
+func TestHintStrictnessUnmarshal(t *testing.T) {
+	tcs := []struct {
+		raw      string
+		expected HintStrictness
+		invalid  bool
+	}{
+		{raw: `"prefer"`, expected: HintsPrefer},
+		{raw: `"require"`, expected: HintsRequire},
+		{raw: `"required"`, invalid: true},
+		{raw: `""`, invalid: true},
+		{raw: `1`, invalid: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.raw, func(t *testing.T) {
+			var strictness HintStrictness
+			err := json.Unmarshal([]byte(tc.raw), &strictness)
+			switch {
+			case tc.invalid && err == nil:
+				t.Errorf("expected an error, got strictness %q", strictness)
+			case !tc.invalid && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case !tc.invalid && strictness != tc.expected:
+				t.Errorf("expected strictness %q, got %q", tc.expected, strictness)
+			}
+		})
+	}
+
+	cfg := &options{}
+	raw := `{"NamespaceDeviceHints": {"default": "prefer", "kube-system": "always"}}`
+	if err := json.Unmarshal([]byte(raw), cfg); err == nil {
+		t.Errorf("expected an error for invalid namespace device hints")
+	}
+}
+
+func TestDeviceHintStrictness(t *testing.T) {
+	cch, cleanup := cachetest.NewCache(t)
+	defer cleanup()
+
+	saved := *opt
+	defer func() { *opt = saved }()
+	opt.DeviceHints = HintsPrefer
+	opt.NamespaceDeviceHints = map[string]HintStrictness{"strict": HintsRequire}
+
+	tcs := []struct {
+		name       string
+		namespace  string
+		annotation string
+		expected   HintStrictness
+	}{
+		{"default", "default", "", HintsPrefer},
+		{"namespace", "strict", "", HintsRequire},
+		{"annotation", "default", "require", HintsRequire},
+		{"annotation-override", "strict", "prefer", HintsPrefer},
+		{"invalid-annotation", "strict", "always", HintsRequire},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := createContainer(t, cch, tc.name, tc.namespace, tc.annotation, "")
+			if strictness := DeviceHintStrictness(c); strictness != tc.expected {
+				t.Errorf("expected strictness %q, got %q", tc.expected, strictness)
+			}
+		})
+	}
+}
+
+func TestAllocateCPUsByHints(t *testing.T) {
+	dir, cleanup := testutils.TempDir(t, "policy-test")
+	defer cleanup()
+
+	// a single socket and NUMA node with 8 single-threaded cores
+	files := map[string]string{
+		"devices/system/cpu/isolated":        "\n",
+		"devices/system/node/node0/cpulist":  "0-7\n",
+		"devices/system/node/node0/distance": "10\n",
+	}
+	for cpu := 0; cpu < 8; cpu++ {
+		path := fmt.Sprintf("devices/system/cpu/cpu%d/", cpu)
+		files[path+"online"] = "1\n"
+		files[path+"node0"] = ""
+		files[path+"topology/physical_package_id"] = "0\n"
+		files[path+"topology/thread_siblings_list"] = fmt.Sprintf("%d\n", cpu)
+	}
+	testutils.CreateFiles(t, dir, files)
+	sys, err := sysfs.DiscoverSystemAt(dir)
+	if err != nil {
+		t.Fatalf("failed to discover test system: %v", err)
+	}
+	cpuallocator.SetSystem(sys)
+
+	cch, cleanupCache := cachetest.NewCache(t)
+	defer cleanupCache()
+
+	tcs := []struct {
+		name       string
+		annotation string
+		hint       string
+		from       string
+		cnt        int
+		result     string
+		fail       bool
+	}{
+		{name: "no hints", from: "2-7", cnt: 2, result: "2-3"},
+		{name: "local", annotation: "require", hint: "4-5", from: "2-7", cnt: 2, result: "4-5"},
+		{name: "prefer falls back", annotation: "prefer", hint: "4-5", from: "2-7", cnt: 3, result: "2,4-5"},
+		{name: "require fails", annotation: "require", hint: "4-5", from: "2-7", cnt: 3, fail: true},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := createContainer(t, cch, tc.name, "default", tc.annotation, tc.hint)
+			from := cpuset.MustParse(tc.from)
+			cpus, err := AllocateCPUsByHints(sys, &from, tc.cnt, c)
+			if tc.fail {
+				if err == nil {
+					t.Errorf("expected an error, got CPUs %s", cpus)
+				}
+				if !from.Equals(cpuset.MustParse(tc.from)) {
+					t.Errorf("failed allocation modified free CPUs to %s", from)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !cpus.Equals(cpuset.MustParse(tc.result)) {
+				t.Errorf("expected CPUs %s, got %s", tc.result, cpus)
+			}
+			if !from.Intersection(cpus).IsEmpty() {
+				t.Errorf("allocated CPUs %s left in free set %s", cpus, from)
+			}
+		})
+	}
+}
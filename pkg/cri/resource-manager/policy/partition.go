@@ -0,0 +1,309 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"strings"
+
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/agent"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+)
+
+//
+// Partitioning splits the resources of the node between several concurrently
+// active policy backends. Each partition has a dedicated set of CPUs and its
+// own backend instance. Pods are assigned to partitions by
+//
+//   - the partition annotation of the pod, if present, otherwise
+//   - the first partition whose namespace and label selectors match the pod,
+//   - the first partition without any selectors, as a fallback.
+//
+// The partition of a container is recorded as a container tag, so that it
+// stays stable over configuration changes. Each backend sees only containers
+// of its own partition, and its cached policy data is kept separate from that
+// of other partitions.
+//
+
+// Partition is a subset of resources managed by a dedicated policy backend.
+type Partition struct {
+	// Name is the unique name of the partition.
+	Name string `json:"Name"`
+	// Policy is the name of the backend managing the partition.
+	Policy string `json:"Policy"`
+	// Available resources of the partition, CPUs must be given as a cpuset.
+	Available ConstraintSet `json:"AvailableResources"`
+	// Reserved resources of the partition, defaults to the global ones.
+	Reserved ConstraintSet `json:"ReservedResources,omitempty"`
+	// Namespaces selects pods in any of the given namespaces.
+	Namespaces []string `json:"Namespaces,omitempty"`
+	// Labels selects pods with all of the given labels.
+	Labels map[string]string `json:"Labels,omitempty"`
+}
+
+const (
+	// keyPartition is the pod annotation used to select a partition.
+	keyPartition = "partition"
+	// tagPartition is the container tag used to record the selected partition.
+	tagPartition = "partition"
+)
+
+// partition is an active partition with its backend.
+type partition struct {
+	*Partition
+	backend Backend         // backend managing this partition
+	cache   *partitionCache // partition-specific view of the cache
+}
+
+// partitionCache is the view of the cache exposed to the backend of a partition.
+type partitionCache struct {
+	cache.Cache
+	policy    *policy
+	partition *partition
+}
+
+// Partitioned returns true if resources are split between several backends.
+func Partitioned() bool {
+	return len(opt.Partitions) > 0
+}
+
+// activePolicy returns the name of the policy activated by the options.
+func (o *options) activePolicy() string {
+	if len(o.Partitions) == 0 {
+		return o.Policy
+	}
+	return partitionedName(o.Partitions)
+}
+
+// partitionedName returns the name describing the given partitions and backends.
+func partitionedName(partitions []*Partition) string {
+	names := []string{}
+	for _, part := range partitions {
+		names = append(names, part.Name+"="+part.Policy)
+	}
+	return "partitioned(" + strings.Join(names, ",") + ")"
+}
+
+// checkPartitions checks that the configured partitions are valid.
+func checkPartitions(partitions []*Partition) error {
+	names := map[string]struct{}{}
+	cpus := cpuset.NewCPUSet()
+
+	for _, part := range partitions {
+		if part.Name == "" {
+			return policyError("partition with no name")
+		}
+		if _, ok := names[part.Name]; ok {
+			return policyError("multiple partitions with name '%s'", part.Name)
+		}
+		names[part.Name] = struct{}{}
+
+		if _, ok := backends[part.Policy]; !ok {
+			return policyError("partition %s: unknown policy '%s'", part.Name, part.Policy)
+		}
+
+		cset, ok := part.Available[DomainCPU].(cpuset.CPUSet)
+		if !ok {
+			return policyError("partition %s: available CPUs must be given as a cpuset",
+				part.Name)
+		}
+		if !cset.Intersection(cpus).IsEmpty() {
+			return policyError("partition %s: CPUs #%s overlap with other partitions",
+				part.Name, cset.Intersection(cpus).String())
+		}
+		cpus = cpus.Union(cset)
+	}
+
+	return nil
+}
+
+// createPartitions creates the backends for the given partitions, with the given
+// default reservations.
+func (p *policy) createPartitions(partitions []*Partition, defaultReserved ConstraintSet, agentCli agent.Interface) error {
+	if err := checkPartitions(partitions); err != nil {
+		return err
+	}
+
+	for _, cfg := range partitions {
+		part := &partition{Partition: cfg}
+		part.cache = &partitionCache{Cache: p.cache, policy: p, partition: part}
+
+		reserved := cfg.Reserved
+		if len(reserved) == 0 {
+			reserved = defaultReserved
+		}
+
+		log.Info("creating partition '%s' with policy '%s'...", cfg.Name, cfg.Policy)
+		log.Info("  with resource availability constraints:")
+		for d := range cfg.Available {
+			log.Info("    - %s=%s", d, ConstraintToString(cfg.Available[d]))
+		}
+		if len(reserved) != 0 {
+			log.Info("  with resource reservation constraints:")
+			for d := range reserved {
+				log.Info("    - %s=%s", d, ConstraintToString(reserved[d]))
+			}
+		}
+
+		part.backend = backends[cfg.Policy].create(&BackendOptions{
+			Cache:     part.cache,
+			System:    p.system,
+			Available: cfg.Available,
+			Reserved:  reserved,
+			AgentCli:  agentCli,
+		})
+
+		p.partitions = append(p.partitions, part)
+	}
+
+	return nil
+}
+
+// lookupPartition looks up an active partition by name.
+func (p *policy) lookupPartition(name string) *partition {
+	for _, part := range p.partitions {
+		if part.Name == name {
+			return part
+		}
+	}
+	return nil
+}
+
+// partitionOf returns the partition of the given container.
+func (p *policy) partitionOf(c cache.Container) (*partition, error) {
+	if name, ok := c.GetTag(tagPartition); ok {
+		if part := p.lookupPartition(name); part != nil {
+			return part, nil
+		}
+		log.Warn("%s: partition '%s' no longer exists, reselecting partition",
+			c.PrettyName(), name)
+	}
+
+	part, err := p.selectPartition(c)
+	if err != nil {
+		return nil, err
+	}
+
+	c.SetTag(tagPartition, part.Name)
+
+	return part, nil
+}
+
+// selectPartition selects the partition for the given container.
+func (p *policy) selectPartition(c cache.Container) (*partition, error) {
+	pod, ok := c.GetPod()
+	if !ok {
+		return nil, policyError("%s: can't find pod to select partition", c.PrettyName())
+	}
+
+	if name, ok := pod.GetResmgrAnnotation(keyPartition); ok {
+		if part := p.lookupPartition(name); part != nil {
+			return part, nil
+		}
+		return nil, policyError("%s: annotated partition '%s' does not exist",
+			c.PrettyName(), name)
+	}
+
+	for _, part := range p.partitions {
+		if part.selects(pod) {
+			return part, nil
+		}
+	}
+
+	for _, part := range p.partitions {
+		if len(part.Namespaces) == 0 && len(part.Labels) == 0 {
+			return part, nil
+		}
+	}
+
+	return nil, policyError("%s: no partition selects the container", c.PrettyName())
+}
+
+// selects checks if the selectors of the partition match the given pod.
+func (part *partition) selects(pod cache.Pod) bool {
+	if len(part.Namespaces) == 0 && len(part.Labels) == 0 {
+		return false
+	}
+
+	if len(part.Namespaces) > 0 {
+		found := false
+		for _, ns := range part.Namespaces {
+			if ns == pod.GetNamespace() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for key, value := range part.Labels {
+		if v, ok := pod.GetLabel(key); !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filter returns the given containers which belong to the partition.
+func (part *partition) filter(containers []cache.Container) []cache.Container {
+	filtered := []cache.Container{}
+	for _, c := range containers {
+		if owner, err := part.cache.policy.partitionOf(c); err != nil {
+			log.Error("%v", err)
+		} else if owner == part {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered
+}
+
+// SetPolicyEntry sets the policy entry for a key, in the namespace of the partition.
+func (pc *partitionCache) SetPolicyEntry(key string, obj interface{}) {
+	pc.Cache.SetPolicyEntry(pc.partition.Name+"/"+key, obj)
+}
+
+// GetPolicyEntry gets the policy entry for a key, in the namespace of the partition.
+func (pc *partitionCache) GetPolicyEntry(key string, ptr interface{}) bool {
+	return pc.Cache.GetPolicyEntry(pc.partition.Name+"/"+key, ptr)
+}
+
+// GetContainers returns the containers in the partition.
+func (pc *partitionCache) GetContainers() []cache.Container {
+	return pc.partition.filter(pc.Cache.GetContainers())
+}
+
+// GetContainerCacheIds returns the cache ids of the containers in the partition.
+func (pc *partitionCache) GetContainerCacheIds() []string {
+	ids := []string{}
+	for _, c := range pc.GetContainers() {
+		ids = append(ids, c.GetCacheID())
+	}
+	return ids
+}
+
+// GetContainerIds returns the ids of the containers in the partition.
+func (pc *partitionCache) GetContainerIds() []string {
+	ids := []string{}
+	for _, c := range pc.GetContainers() {
+		if id := c.GetID(); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,299 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/kubelet/cm/cpuset"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache/cachetest"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
+)
+
+// testPartitionPolicy is the name of the backend used in partition tests.
+const testPartitionPolicy = "partition-test"
+
+// partitionBackend is a backend doing nothing.
+type partitionBackend struct {
+	cache cache.Cache
+}
+
+func init() {
+	Register(testPartitionPolicy, "Partition test policy.", func(opts *BackendOptions) Backend {
+		return &partitionBackend{cache: opts.Cache}
+	})
+}
+
+func (b *partitionBackend) Name() string                                     { return testPartitionPolicy }
+func (b *partitionBackend) Description() string                              { return "Partition test policy." }
+func (b *partitionBackend) Start([]cache.Container, []cache.Container) error { return nil }
+func (b *partitionBackend) Sync([]cache.Container, []cache.Container) error  { return nil }
+func (b *partitionBackend) AllocateResources(cache.Container) error          { return nil }
+func (b *partitionBackend) ReleaseResources(cache.Container) error           { return nil }
+func (b *partitionBackend) UpdateResources(cache.Container) error            { return nil }
+func (b *partitionBackend) Rebalance() (int, error)                          { return 0, nil }
+
+func (b *partitionBackend) ExportResourceData(cache.Container) map[string]string {
+	return nil
+}
+
+// testPartition creates a partition with the given CPUs.
+func testPartition(name, cpus string) *Partition {
+	return &Partition{
+		Name:      name,
+		Policy:    testPartitionPolicy,
+		Available: ConstraintSet{DomainCPU: cpuset.MustParse(cpus)},
+	}
+}
+
+func TestCheckPartitions(t *testing.T) {
+	tcs := []struct {
+		name       string
+		partitions []*Partition
+		invalid    bool
+	}{
+		{
+			name:       "valid",
+			partitions: []*Partition{testPartition("a", "0-1"), testPartition("b", "2-3")},
+		},
+		{
+			name:       "no name",
+			partitions: []*Partition{testPartition("", "0-1")},
+			invalid:    true,
+		},
+		{
+			name:       "duplicate names",
+			partitions: []*Partition{testPartition("a", "0-1"), testPartition("a", "2-3")},
+			invalid:    true,
+		},
+		{
+			name: "unknown policy",
+			partitions: []*Partition{
+				{
+					Name:      "a",
+					Policy:    "no-such-policy",
+					Available: ConstraintSet{DomainCPU: cpuset.MustParse("0-1")},
+				},
+			},
+			invalid: true,
+		},
+		{
+			name: "CPUs not a cpuset",
+			partitions: []*Partition{
+				{
+					Name:      "a",
+					Policy:    testPartitionPolicy,
+					Available: ConstraintSet{DomainCPU: resapi.MustParse("2")},
+				},
+			},
+			invalid: true,
+		},
+		{
+			name:       "overlapping CPUs",
+			partitions: []*Partition{testPartition("a", "0-2"), testPartition("b", "2-3")},
+			invalid:    true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkPartitions(tc.partitions)
+			if tc.invalid && err == nil {
+				t.Errorf("expected partitions to be rejected")
+			}
+			if !tc.invalid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+// createPartitionedPolicy creates a policy with partitions selecting pods by
+// namespace, by labels, by both, and a fallback partition.
+func createPartitionedPolicy(t *testing.T, cch cache.Cache) *policy {
+	byNamespace := testPartition("by-namespace", "0")
+	byNamespace.Namespaces = []string{"ns1", "ns2"}
+	byLabels := testPartition("by-labels", "1")
+	byLabels.Labels = map[string]string{"app": "db", "tier": "backend"}
+	byBoth := testPartition("by-both", "2")
+	byBoth.Namespaces = []string{"ns3"}
+	byBoth.Labels = map[string]string{"app": "web"}
+	fallback := testPartition("fallback", "3")
+
+	p := &policy{cache: cch}
+	partitions := []*Partition{byNamespace, byLabels, byBoth, fallback}
+	if err := p.createPartitions(partitions, nil, nil); err != nil {
+		t.Fatalf("failed to create partitions: %v", err)
+	}
+
+	return p
+}
+
+func TestSelectPartition(t *testing.T) {
+	cch, cleanup := cachetest.NewCache(t)
+	defer cleanup()
+
+	p := createPartitionedPolicy(t, cch)
+
+	tcs := []struct {
+		name       string
+		namespace  string
+		labels     map[string]string
+		annotation string
+		partitions []*partition
+		expected   string
+		invalid    bool
+	}{
+		{name: "namespace", namespace: "ns2", expected: "by-namespace"},
+		{
+			name:      "labels",
+			namespace: "default",
+			labels:    map[string]string{"app": "db", "tier": "backend", "extra": "x"},
+			expected:  "by-labels",
+		},
+		{
+			name:      "some labels",
+			namespace: "default",
+			labels:    map[string]string{"app": "db"},
+			expected:  "fallback",
+		},
+		{
+			name:      "namespace and labels",
+			namespace: "ns3",
+			labels:    map[string]string{"app": "web"},
+			expected:  "by-both",
+		},
+		{
+			name:      "namespace without labels",
+			namespace: "ns3",
+			expected:  "fallback",
+		},
+		{
+			name:      "first matching partition",
+			namespace: "ns1",
+			labels:    map[string]string{"app": "db", "tier": "backend"},
+			expected:  "by-namespace",
+		},
+		{
+			name:       "annotation over selectors",
+			namespace:  "ns1",
+			annotation: "by-labels",
+			expected:   "by-labels",
+		},
+		{
+			name:       "annotated partition missing",
+			namespace:  "default",
+			annotation: "no-such-partition",
+			invalid:    true,
+		},
+		{
+			name:       "no fallback",
+			namespace:  "default",
+			partitions: p.partitions[:3],
+			invalid:    true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			annotations := map[string]string{}
+			if tc.annotation != "" {
+				annotations[kubernetes.ResmgrKey(keyPartition)] = tc.annotation
+			}
+			c := cachetest.CreateContainer(t, cch, cachetest.Pod{
+				Name:        tc.name,
+				Namespace:   tc.namespace,
+				Labels:      tc.labels,
+				Annotations: annotations,
+			})
+
+			selector := p
+			if tc.partitions != nil {
+				selector = &policy{cache: cch, partitions: tc.partitions}
+			}
+			part, err := selector.selectPartition(c)
+			switch {
+			case tc.invalid && err == nil:
+				t.Errorf("expected no partition, got %s", part.Name)
+			case !tc.invalid && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case !tc.invalid && part.Name != tc.expected:
+				t.Errorf("expected partition %s, got %s", tc.expected, part.Name)
+			}
+		})
+	}
+}
+
+func TestPartitionCache(t *testing.T) {
+	cch, cleanup := cachetest.NewCache(t)
+	defer cleanup()
+
+	p := createPartitionedPolicy(t, cch)
+	byNamespace := p.lookupPartition("by-namespace")
+	fallback := p.lookupPartition("fallback")
+
+	inNamespace := cachetest.CreateContainer(t, cch, cachetest.Pod{Name: "pod0", Namespace: "ns1"})
+	other := cachetest.CreateContainer(t, cch, cachetest.Pod{Name: "pod1", Namespace: "default"})
+
+	// each partition sees only its own containers
+	for _, tc := range []struct {
+		part     *partition
+		expected cache.Container
+	}{
+		{byNamespace, inNamespace},
+		{fallback, other},
+	} {
+		containers := tc.part.cache.GetContainers()
+		if len(containers) != 1 || containers[0] != tc.expected {
+			t.Errorf("expected only %s in partition %s, got %v",
+				tc.expected.PrettyName(), tc.part.Name, containers)
+		}
+		ids := tc.part.cache.GetContainerCacheIds()
+		if len(ids) != 1 || ids[0] != tc.expected.GetCacheID() {
+			t.Errorf("expected only cache ID %s in partition %s, got %v",
+				tc.expected.GetCacheID(), tc.part.Name, ids)
+		}
+	}
+
+	// the selected partition is recorded, and kept even if selectors change
+	if name, ok := inNamespace.GetTag(tagPartition); !ok || name != "by-namespace" {
+		t.Errorf("expected partition tag by-namespace, got %q", name)
+	}
+	byNamespace.Namespaces = []string{"ns2"}
+	if part, err := p.partitionOf(inNamespace); err != nil || part != byNamespace {
+		t.Errorf("expected %s to stay in partition by-namespace, got %v (error %v)",
+			inNamespace.PrettyName(), part, err)
+	}
+
+	// policy entries of partitions are kept separate
+	byNamespace.cache.SetPolicyEntry("key", "by-namespace value")
+	fallback.cache.SetPolicyEntry("key", "fallback value")
+
+	for _, part := range []*partition{byNamespace, fallback} {
+		value := ""
+		if !part.cache.GetPolicyEntry("key", &value) || value != part.Name+" value" {
+			t.Errorf("expected policy entry %q in partition %s, got %q",
+				part.Name+" value", part.Name, value)
+		}
+	}
+	value := ""
+	if !cch.GetPolicyEntry("fallback/key", &value) || value != "fallback value" {
+		t.Errorf("expected policy entry of partition fallback in its namespace, got %q", value)
+	}
+	if cch.GetPolicyEntry("key", &value) {
+		t.Errorf("unexpected policy entry outside of partition namespaces")
+	}
+}
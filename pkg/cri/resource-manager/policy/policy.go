@@ -24,6 +24,7 @@ import (
 
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/agent"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/introspect"
 	logger "github.com/intel/cri-resource-manager/pkg/log"
 	system "github.com/intel/cri-resource-manager/pkg/sysfs"
 )
@@ -54,6 +55,8 @@ type ConstraintSet map[Domain]Constraint
 type Options struct {
 	// Client interface to cri-resmgr agent
 	AgentCli agent.Interface
+	// System overrides the discovered system/HW/topology information if set
+	System *system.System
 }
 
 // BackendOptions describes the options for a policy backend instance
@@ -104,16 +107,38 @@ type Backend interface {
 	ReleaseResources(cache.Container) error
 	// UpdateResources updates resource allocations of a container.
 	UpdateResources(cache.Container) error
-	// Rebalance tries an optimal allocation of resources for the current container.
-	Rebalance() (bool, error)
+	// Rebalance tries an optimal allocation of resources for the current containers,
+	// returning the number of containers moved.
+	Rebalance() (int, error)
 	// ExportResourceData provides resource data to export for the container.
 	ExportResourceData(cache.Container) map[string]string
 }
 
+// Reallocator is implemented by backends which can reallocate the resources
+// of a container on demand, for instance after a change in its AVX512 usage.
+type Reallocator interface {
+	// ReallocateResources reallocates resources of a container, returning true if they changed.
+	ReallocateResources(cache.Container) (bool, error)
+}
+
+// Introspector is implemented by backends which can describe their internal state.
+type Introspector interface {
+	// Introspect fills in the pools and resource grants of the backend.
+	Introspect(*introspect.Backend)
+}
+
+// Stopper is implemented by backends which need to clean up when they get deactivated.
+type Stopper interface {
+	// Stop stops the backend, which is not going to be used any more.
+	Stop()
+}
+
 // Policy is the exposed interface for container resource allocations decision making.
 type Policy interface {
 	// Start starts up policy, prepare for serving resource management requests.
 	Start([]cache.Container, []cache.Container) error
+	// Stop stops the policy, deactivating all of its backends.
+	Stop()
 	// Sync synchronizes the state of the active policy.
 	Sync([]cache.Container, []cache.Container) error
 	// AlocateResources allocates resources to a container.
@@ -122,17 +147,25 @@ type Policy interface {
 	ReleaseResources(cache.Container) error
 	// UpdateResources updates resource allocations of a container.
 	UpdateResources(cache.Container) error
-	// Rebalance tries to find an optimal allocation of resources for the current containers.
-	Rebalance() (bool, error)
+	// ReallocateResources reallocates resources of a container, if supported by the backend.
+	ReallocateResources(cache.Container) (bool, error)
+	// Rebalance tries to find an optimal allocation of resources for the current containers,
+	// returning the number of containers moved.
+	Rebalance() (int, error)
 	// ExportResourceData exports/updates resource data for the container.
 	ExportResourceData(cache.Container)
+	// Introspect fills in the state of the active backends.
+	Introspect(*introspect.State)
+	// GetTopologyZones returns the capacity and free resources per topology zone.
+	GetTopologyZones() []*TopologyZone
 }
 
 // Policy instance/state.
 type policy struct {
-	cache   cache.Cache    // system state cache
-	backend Backend        // our active backend
-	system  *system.System // system/HW/topology info
+	cache      cache.Cache    // system state cache
+	backend    Backend        // our active backend, if not partitioned
+	partitions []*partition   // our active partitions, if partitioned
+	system     *system.System // system/HW/topology info
 }
 
 // backend is a registered Backend.
@@ -150,23 +183,27 @@ var backends = make(map[string]*backend)
 
 // ActivePolicy returns the name of the policy to be activated.
 func ActivePolicy() string {
-	return opt.Policy
+	return opt.activePolicy()
+}
+
+// CheckedPolicy returns the name of the policy a candidate configuration, as passed
+// to configuration checks, would activate.
+func CheckedPolicy(candidate interface{}) string {
+	return candidate.(*options).activePolicy()
 }
 
 // NewPolicy creates a policy instance using the selected backend.
 func NewPolicy(cache cache.Cache, o *Options) (Policy, error) {
-	if opt.Policy == NullPolicy {
+	if ActivePolicy() == NullPolicy {
 		return nil, nil
 	}
 
-	backend, ok := backends[opt.Policy]
-	if !ok {
-		return nil, policyError("unknown policy '%s'", opt.Policy)
-	}
-
-	sys, err := system.DiscoverSystem()
-	if err != nil {
-		return nil, policyError("failed to discover system topology: %v", err)
+	sys := o.System
+	if sys == nil {
+		var err error
+		if sys, err = system.DiscoverSystem(system.DiscoverDefault | system.DiscoverCache); err != nil {
+			return nil, policyError("failed to discover system topology: %v", err)
+		}
 	}
 
 	p := &policy{
@@ -174,6 +211,26 @@ func NewPolicy(cache cache.Cache, o *Options) (Policy, error) {
 		system: sys,
 	}
 
+	if Partitioned() {
+		if opt.Policy != NullPolicy {
+			log.Warn("partitions configured, ignoring active policy '%s'", opt.Policy)
+		}
+		if log.DebugEnabled() {
+			for _, part := range opt.Partitions {
+				logger.Get(part.Policy).EnableDebug(true)
+			}
+		}
+		if err := p.createPartitions(opt.Partitions, opt.Reserved, o.AgentCli); err != nil {
+			return nil, err
+		}
+		return p, nil
+	}
+
+	backend, ok := backends[opt.Policy]
+	if !ok {
+		return nil, policyError("unknown policy '%s'", opt.Policy)
+	}
+
 	log.Info("creating new policy '%s'...", backend.name)
 	if len(opt.Available) != 0 {
 		log.Info("  with resource availability constraints:")
@@ -210,45 +267,144 @@ func NewPolicy(cache cache.Cache, o *Options) (Policy, error) {
 
 // Start starts up policy, preparing it for resving requests.
 func (p *policy) Start(add []cache.Container, del []cache.Container) error {
-	if opt.Policy == NullPolicy {
+	if ActivePolicy() == NullPolicy {
 		return nil
 	}
 
-	log.Info("starting policy '%s'...", p.backend.Name())
+	if p.partitions == nil {
+		log.Info("starting policy '%s'...", p.backend.Name())
+		return p.backend.Start(add, del)
+	}
+
+	for _, part := range p.partitions {
+		log.Info("starting partition '%s' with policy '%s'...", part.Name, part.backend.Name())
+		if err := part.backend.Start(part.filter(add), part.filter(del)); err != nil {
+			return policyError("failed to start partition %s: %v", part.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Stop stops the policy, deactivating all of its backends.
+func (p *policy) Stop() {
+	if p.partitions == nil {
+		log.Info("stopping policy '%s'...", p.backend.Name())
+		stopBackend(p.backend)
+		return
+	}
+
+	for _, part := range p.partitions {
+		log.Info("stopping partition '%s' with policy '%s'...", part.Name, part.backend.Name())
+		stopBackend(part.backend)
+	}
+}
 
-	return p.backend.Start(add, del)
+// stopBackend stops the given backend if it needs stopping.
+func stopBackend(backend Backend) {
+	if s, ok := backend.(Stopper); ok {
+		s.Stop()
+	}
 }
 
 // Sync synchronizes the active policy state.
 func (p *policy) Sync(add []cache.Container, del []cache.Container) error {
-	return p.backend.Sync(add, del)
+	if p.partitions == nil {
+		return p.backend.Sync(add, del)
+	}
+
+	for _, part := range p.partitions {
+		if err := part.backend.Sync(part.filter(add), part.filter(del)); err != nil {
+			return policyError("failed to sync partition %s: %v", part.Name, err)
+		}
+	}
+
+	return nil
 }
 
 // AllocateResources allocates resources for a container.
 func (p *policy) AllocateResources(c cache.Container) error {
-	return p.backend.AllocateResources(c)
+	backend, err := p.backendOf(c)
+	if err != nil {
+		return err
+	}
+	if err := backend.AllocateResources(c); err != nil {
+		return err
+	}
+	p.assignRdtBudget(c)
+	return nil
 }
 
 // ReleaseResources release resources of a container.
 func (p *policy) ReleaseResources(c cache.Container) error {
-	return p.backend.ReleaseResources(c)
+	backend, err := p.backendOf(c)
+	if err != nil {
+		return err
+	}
+	return backend.ReleaseResources(c)
 }
 
 // UpdateResources updates resource allocations of a container.
 func (p *policy) UpdateResources(c cache.Container) error {
-	return p.backend.UpdateResources(c)
+	backend, err := p.backendOf(c)
+	if err != nil {
+		return err
+	}
+	if err := backend.UpdateResources(c); err != nil {
+		return err
+	}
+	p.assignRdtBudget(c)
+	return nil
+}
+
+// ReallocateResources reallocates resources of a container, if supported by the backend.
+func (p *policy) ReallocateResources(c cache.Container) (bool, error) {
+	backend, err := p.backendOf(c)
+	if err != nil {
+		return false, err
+	}
+	r, ok := backend.(Reallocator)
+	if !ok {
+		return false, nil
+	}
+	changed, err := r.ReallocateResources(c)
+	if err != nil {
+		return changed, err
+	}
+	p.assignRdtBudget(c)
+	return changed, nil
 }
 
 // Rebalance tries to find a more optimal allocation of resources for the current containers.
-func (p *policy) Rebalance() (bool, error) {
-	return p.backend.Rebalance()
+func (p *policy) Rebalance() (int, error) {
+	if p.partitions == nil {
+		return p.backend.Rebalance()
+	}
+
+	moved := 0
+	for _, part := range p.partitions {
+		count, err := part.backend.Rebalance()
+		moved += count
+		if err != nil {
+			return moved, policyError("failed to rebalance partition %s: %v",
+				part.Name, err)
+		}
+	}
+
+	return moved, nil
 }
 
 // ExportResourceData exports/updates resource data for the container.
 func (p *policy) ExportResourceData(c cache.Container) {
 	var buf bytes.Buffer
 
-	for key, value := range p.backend.ExportResourceData(c) {
+	backend, err := p.backendOf(c)
+	if err != nil {
+		log.Error("container %s: failed to export resource data: %v", c.PrettyName(), err)
+		return
+	}
+
+	for key, value := range backend.ExportResourceData(c) {
 		if _, err := buf.WriteString(fmt.Sprintf("%s=%q\n", key, value)); err != nil {
 			log.Error("container %s: failed to export resource data (%s=%q)",
 				c.PrettyName(), key, value)
@@ -260,6 +416,46 @@ func (p *policy) ExportResourceData(c cache.Container) {
 	p.cache.WriteFile(c.GetCacheID(), ExportedResources, 0644, buf.Bytes())
 }
 
+// Introspect fills in the state of the active backends.
+func (p *policy) Introspect(state *introspect.State) {
+	state.Policy = ActivePolicy()
+
+	if p.partitions == nil {
+		state.Backends = []*introspect.Backend{introspectBackend(p.backend, "")}
+		return
+	}
+
+	for _, part := range p.partitions {
+		state.Backends = append(state.Backends, introspectBackend(part.backend, part.Name))
+	}
+}
+
+// introspectBackend returns the introspected state of a backend.
+func introspectBackend(backend Backend, partition string) *introspect.Backend {
+	b := &introspect.Backend{
+		Name:      backend.Name(),
+		Partition: partition,
+	}
+	if i, ok := backend.(Introspector); ok {
+		i.Introspect(b)
+	}
+	return b
+}
+
+// backendOf returns the backend responsible for the given container.
+func (p *policy) backendOf(c cache.Container) (Backend, error) {
+	if p.partitions == nil {
+		return p.backend, nil
+	}
+
+	part, err := p.partitionOf(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return part.backend, nil
+}
+
 // Register registers a policy backend.
 func Register(name, description string, create CreateFn) error {
 	log.Info("registering policy '%s'...", name)
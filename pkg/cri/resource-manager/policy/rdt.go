@@ -0,0 +1,132 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/rdt"
+)
+
+//
+// RDT budgets give containers a percentage of the L3 cache (DomainCache)
+// and of the memory bandwidth (DomainMemoryBW). The budget of a container
+// is taken from
+//
+//   - the rdt.cache and rdt.mb pod annotations, if present, otherwise
+//   - the configured default budget for the QoS class of the container.
+//
+// The annotations either give a single percentage for all containers of
+// the pod, or a map of percentages by container name. Budgets are enforced
+// by the RDT controller using dynamic classes. A class explicitly assigned
+// to the container by the backend takes precedence over its budget.
+//
+
+const (
+	// keyRdtCache is the pod annotation for L3 cache budgets.
+	keyRdtCache = "rdt.cache"
+	// keyRdtMemoryBW is the pod annotation for memory bandwidth budgets.
+	keyRdtMemoryBW = "rdt.mb"
+)
+
+// rdtBudget returns the RDT budget of the given container.
+func rdtBudget(c cache.Container) rdt.Budget {
+	budget := rdt.Budget{}
+	defaults := opt.RdtBudgets[string(c.GetQOSClass())]
+
+	if pct, ok := defaults[DomainCache].(int); ok {
+		budget.L3 = uint64(pct)
+	}
+	if pct, ok := defaults[DomainMemoryBW].(int); ok {
+		budget.MB = uint64(pct)
+	}
+
+	pod, ok := c.GetPod()
+	if !ok {
+		log.Warn("%s: can't find pod to check RDT budget annotations", c.PrettyName())
+		return budget
+	}
+	if pct, ok := budgetAnnotation(pod, c, keyRdtCache); ok {
+		budget.L3 = pct
+	}
+	if pct, ok := budgetAnnotation(pod, c, keyRdtMemoryBW); ok {
+		budget.MB = pct
+	}
+
+	return budget
+}
+
+// budgetAnnotation returns the budget percentage annotated for the container.
+func budgetAnnotation(pod cache.Pod, c cache.Container, key string) (uint64, bool) {
+	value, ok := pod.GetResmgrAnnotation(key)
+	if !ok {
+		return 0, false
+	}
+
+	if pct, err := rdt.ParseBudgetPercentage(value); err == nil {
+		return pct, true
+	}
+
+	budgets := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(value), &budgets); err != nil {
+		log.Error("%s: invalid annotation %s = '%s': %v", c.PrettyName(), key, value, err)
+		return 0, false
+	}
+	budget, ok := budgets[c.GetName()]
+	if !ok {
+		return 0, false
+	}
+	switch budget.(type) {
+	case string, float64:
+		value = fmt.Sprintf("%v", budget)
+	default:
+		log.Error("%s: invalid annotation %s: budget %v of type %T",
+			c.PrettyName(), key, budget, budget)
+		return 0, false
+	}
+	pct, err := rdt.ParseBudgetPercentage(value)
+	if err != nil {
+		log.Error("%s: invalid annotation %s: %v", c.PrettyName(), key, err)
+		return 0, false
+	}
+
+	return pct, true
+}
+
+// assignRdtBudget assigns the container to the dynamic RDT class of its budget.
+func (p *policy) assignRdtBudget(c cache.Container) {
+	class := c.GetRDTClass()
+	if class != "" && !rdt.IsDynamicClass(class) {
+		log.Debug("%s: keeping RDT class %s assigned by policy", c.PrettyName(), class)
+		return
+	}
+
+	budget := rdtBudget(c)
+	if budget.IsZero() {
+		if class != "" {
+			log.Info("%s: RDT budget removed", c.PrettyName())
+			c.SetRDTClass("")
+		}
+		return
+	}
+
+	if name := budget.ClassName(); name != class {
+		log.Info("%s: RDT budget %s, class %s", c.PrettyName(), budget, name)
+		c.SetRDTClass(name)
+	}
+}
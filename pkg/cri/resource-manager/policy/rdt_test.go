@@ -0,0 +1,107 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache/cachetest"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
+)
+
+func TestBudgetAnnotation(t *testing.T) {
+	cch, cleanup := cachetest.NewCache(t)
+	defer cleanup()
+
+	tcs := []struct {
+		name       string
+		annotation string
+		expected   uint64
+		invalid    bool
+	}{
+		{name: "pod percentage", annotation: "40%", expected: 40},
+		{name: "pod number", annotation: "40", expected: 40},
+		{name: "container percentage", annotation: "ctr: 40%\nother: 10%", expected: 40},
+		{name: "container number", annotation: "ctr: 40\nother: 10", expected: 40},
+		{name: "container quoted number", annotation: "ctr: \"40\"", expected: 40},
+		{name: "other container", annotation: "other: 10", invalid: true},
+		{name: "fraction", annotation: "ctr: 40.5", invalid: true},
+		{name: "out of range", annotation: "ctr: 140", invalid: true},
+		{name: "not a number", annotation: "ctr: [40]", invalid: true},
+	}
+	for i, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			c := cachetest.CreateContainer(t, cch, cachetest.Pod{
+				Name:      fmt.Sprintf("pod%d", i),
+				Namespace: "default",
+				Annotations: map[string]string{
+					kubernetes.ResmgrKey(keyRdtCache): tc.annotation,
+				},
+			})
+			pod, _ := c.GetPod()
+			pct, ok := budgetAnnotation(pod, c, keyRdtCache)
+			switch {
+			case tc.invalid && ok:
+				t.Errorf("expected no budget, got %d%%", pct)
+			case !tc.invalid && !ok:
+				t.Errorf("expected budget %d%%, got none", tc.expected)
+			case !tc.invalid && pct != tc.expected:
+				t.Errorf("expected budget %d%%, got %d%%", tc.expected, pct)
+			}
+		})
+	}
+}
+
+func TestBudgetResources(t *testing.T) {
+	tcs := []struct {
+		name    string
+		config  string
+		invalid bool
+	}{
+		{
+			name:   "CPU resources and budgets",
+			config: `{"AvailableResources": {"CPU": "cpuset:0-3"}, "RdtBudgets": {"Burstable": {"Cache": "40%", "MBW": 50}}}`,
+		},
+		{
+			name:    "available cache",
+			config:  `{"AvailableResources": {"CPU": "cpuset:0-3", "Cache": "40%"}}`,
+			invalid: true,
+		},
+		{
+			name:    "reserved memory bandwidth",
+			config:  `{"ReservedResources": {"CPU": 1, "MBW": 50}}`,
+			invalid: true,
+		},
+		{
+			name:    "partition cache",
+			config:  `{"Partitions": [{"Name": "a", "AvailableResources": {"CPU": "cpuset:0-3", "Cache": "40%"}}]}`,
+			invalid: true,
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			o := &options{}
+			err := json.Unmarshal([]byte(tc.config), o)
+			if tc.invalid && err == nil {
+				t.Errorf("expected configuration to be rejected")
+			}
+			if !tc.invalid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,414 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	cri "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+	kubetypes "k8s.io/kubernetes/pkg/kubelet/types"
+
+	"github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cpuallocator"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+	"github.com/intel/cri-resource-manager/pkg/sysfs"
+)
+
+//
+// The simulator runs a policy in isolation, against a fake system and a
+// scripted sequence of pod and container events. A script looks like this:
+//
+//   sysfs: testdata/2-socket/sys       # fake sysfs tree, relative to the script
+//   config:                            # configuration, as for --config-file
+//     policy:
+//       Active: static
+//       ReservedResources:
+//         CPU: 1
+//   events:
+//   - create:                          # create a pod and all its containers
+//       pod: web
+//       namespace: default
+//       containers:
+//       - name: nginx
+//         requests: { cpu: 2, memory: 1G }
+//         limits:   { cpu: 2, memory: 1G }
+//   - remove:                          # remove a container, or the whole pod
+//       pod: web
+//       container: nginx
+//   zones: true                        # record topology zones after each event
+//
+// After each event, the resulting decisions for all containers are recorded.
+// The collected decisions are meant to be compared against golden files.
+//
+
+// Script is a simulation script.
+type Script struct {
+	// Sysfs is the root of the fake sysfs tree to discover the system from.
+	Sysfs string `json:"sysfs"`
+	// Config is the configuration to use for the simulation.
+	Config map[string]interface{} `json:"config,omitempty"`
+	// Events is the sequence of events to simulate.
+	Events []*Event `json:"events"`
+	// Zones enables recording the topology zones reported by the policy.
+	Zones bool `json:"zones,omitempty"`
+}
+
+// Event is a single simulated event.
+type Event struct {
+	// Create creates a pod with its containers.
+	Create *Pod `json:"create,omitempty"`
+	// Remove removes a container or a whole pod.
+	Remove *Removal `json:"remove,omitempty"`
+}
+
+// Pod describes a pod to create.
+type Pod struct {
+	Name        string            `json:"pod"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Containers  []*Container      `json:"containers"`
+}
+
+// Container describes a container to create.
+type Container struct {
+	Name        string            `json:"name"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Requests    v1.ResourceList   `json:"requests,omitempty"`
+	Limits      v1.ResourceList   `json:"limits,omitempty"`
+}
+
+// Removal describes a container or pod to remove.
+type Removal struct {
+	Pod       string `json:"pod"`
+	Namespace string `json:"namespace,omitempty"`
+	Container string `json:"container,omitempty"`
+}
+
+// Step is the outcome of a single simulated event.
+type Step struct {
+	// Event is a short description of the event.
+	Event string `json:"event"`
+	// Error is the error, if any, returned by the policy for the event.
+	Error string `json:"error,omitempty"`
+	// Containers are the decisions for all existing containers after the event.
+	Containers []*Decision `json:"containers"`
+	// Zones are the topology zones reported by the policy after the event, if enabled.
+	Zones []*policy.TopologyZone `json:"zones,omitempty"`
+}
+
+// Decision is the resource assignment of a single container.
+type Decision struct {
+	Name         string `json:"name"`
+	Cpus         string `json:"cpus,omitempty"`
+	Mems         string `json:"mems,omitempty"`
+	RDTClass     string `json:"rdtClass,omitempty"`
+	BlockIOClass string `json:"blockioClass,omitempty"`
+}
+
+// simulator is the state of a single simulation run.
+type simulator struct {
+	cache  cache.Cache   // simulated cache
+	policy policy.Policy // policy being simulated
+	pods   map[string]*simPod
+}
+
+// simPod is a created pod with its containers.
+type simPod struct {
+	id         string
+	cfg        *cri.PodSandboxConfig
+	containers map[string]cache.Container
+}
+
+// Our logger instance.
+var log logger.Logger = logger.NewLogger("simulator")
+
+// LoadScript loads a simulation script from the given file.
+func LoadScript(path string) (*Script, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, simulatorError("failed to read script %q: %v", path, err)
+	}
+
+	script := &Script{}
+	if err := yaml.Unmarshal(raw, script); err != nil {
+		return nil, simulatorError("failed to parse script %q: %v", path, err)
+	}
+
+	if script.Sysfs != "" && !filepath.IsAbs(script.Sysfs) {
+		script.Sysfs = filepath.Join(filepath.Dir(path), script.Sysfs)
+	}
+
+	return script, nil
+}
+
+// Run runs the given script, returning the outcome of each event.
+func Run(script *Script) ([]*Step, error) {
+	if script.Sysfs == "" {
+		return nil, simulatorError("no sysfs tree given in script")
+	}
+
+	sys, err := sysfs.DiscoverSystemAt(script.Sysfs, sysfs.DiscoverDefault|sysfs.DiscoverCache)
+	if err != nil {
+		return nil, simulatorError("failed to discover system from %q: %v", script.Sysfs, err)
+	}
+	cpuallocator.SetSystem(sys)
+
+	if err := configure(script.Config); err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "cri-resmgr-simulator-")
+	if err != nil {
+		return nil, simulatorError("failed to create cache directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &simulator{pods: make(map[string]*simPod)}
+
+	if s.cache, err = cache.NewCache(cache.Options{CacheDir: dir}); err != nil {
+		return nil, simulatorError("failed to create cache: %v", err)
+	}
+	if s.policy, err = policy.NewPolicy(s.cache, &policy.Options{System: sys}); err != nil {
+		return nil, simulatorError("failed to create policy: %v", err)
+	}
+	if s.policy == nil {
+		return nil, simulatorError("no active policy configured")
+	}
+	defer s.policy.Stop()
+	if err := s.policy.Start(nil, nil); err != nil {
+		return nil, simulatorError("failed to start policy: %v", err)
+	}
+
+	steps := make([]*Step, 0, len(script.Events))
+	for idx, e := range script.Events {
+		step := &Step{}
+		switch {
+		case e.Create != nil:
+			step.Event = "create " + podName(e.Create.Namespace, e.Create.Name)
+			err = s.createPod(e.Create)
+		case e.Remove != nil:
+			step.Event = "remove " + podName(e.Remove.Namespace, e.Remove.Pod)
+			if e.Remove.Container != "" {
+				step.Event += "/" + e.Remove.Container
+			}
+			err = s.remove(e.Remove)
+		default:
+			return steps, simulatorError("event #%d: no create or remove given", idx)
+		}
+		if err != nil {
+			log.Warn("%s: %v", step.Event, err)
+			step.Error = err.Error()
+		}
+		step.Containers = s.decisions()
+		if script.Zones {
+			step.Zones = s.policy.GetTopologyZones()
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// configure applies the given configuration on top of the defaults.
+func configure(cfg map[string]interface{}) error {
+	// partial configuration is merged with the current one, so reset it first
+	if err := config.SetConfig(map[string]string{}); err != nil {
+		return simulatorError("failed to reset configuration: %v", err)
+	}
+
+	smap := make(map[string]string, len(cfg))
+	for key, val := range cfg {
+		raw, err := yaml.Marshal(val)
+		if err != nil {
+			return simulatorError("failed to marshal configuration %q: %v", key, err)
+		}
+		smap[key] = string(raw)
+	}
+
+	if err := config.SetConfig(smap); err != nil {
+		return simulatorError("failed to apply configuration: %v", err)
+	}
+
+	return nil
+}
+
+// createPod creates a pod and allocates resources for its containers.
+func (s *simulator) createPod(p *Pod) error {
+	name := podName(p.Namespace, p.Name)
+	if _, ok := s.pods[name]; ok {
+		return simulatorError("pod %s already exists", name)
+	}
+
+	resources := cache.PodResourceRequirements{
+		Containers: make(map[string]v1.ResourceRequirements),
+	}
+	for _, c := range p.Containers {
+		resources.Containers[c.Name] = v1.ResourceRequirements{
+			Requests: c.Requests,
+			Limits:   c.Limits,
+		}
+	}
+	raw, err := json.Marshal(resources)
+	if err != nil {
+		return simulatorError("failed to marshal resources of pod %s: %v", name, err)
+	}
+
+	annotations := map[string]string{cache.KeyResourceAnnotation: string(raw)}
+	for key, val := range p.Annotations {
+		annotations[key] = val
+	}
+	labels := map[string]string{kubetypes.KubernetesPodUIDLabel: name}
+	for key, val := range p.Labels {
+		labels[key] = val
+	}
+
+	sp := &simPod{
+		id: "pod:" + name,
+		cfg: &cri.PodSandboxConfig{
+			Metadata: &cri.PodSandboxMetadata{
+				Name:      p.Name,
+				Uid:       name,
+				Namespace: namespace(p.Namespace),
+			},
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		containers: make(map[string]cache.Container),
+	}
+
+	if s.cache.InsertPod(sp.id, &cri.RunPodSandboxRequest{Config: sp.cfg}) == nil {
+		return simulatorError("failed to create pod %s", name)
+	}
+	s.pods[name] = sp
+
+	for _, c := range p.Containers {
+		req := &cri.CreateContainerRequest{
+			PodSandboxId: sp.id,
+			Config: &cri.ContainerConfig{
+				Metadata:    &cri.ContainerMetadata{Name: c.Name},
+				Labels:      c.Labels,
+				Annotations: c.Annotations,
+				Linux: &cri.LinuxContainerConfig{
+					Resources: &cri.LinuxContainerResources{},
+				},
+			},
+			SandboxConfig: sp.cfg,
+		}
+		container, err := s.cache.InsertContainer(req)
+		if err != nil {
+			return simulatorError("failed to create container %s/%s: %v", name, c.Name, err)
+		}
+		sp.containers[c.Name] = container
+
+		if err := s.policy.AllocateResources(container); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// remove releases resources of a container or all containers of a pod.
+func (s *simulator) remove(r *Removal) error {
+	name := podName(r.Namespace, r.Pod)
+	sp, ok := s.pods[name]
+	if !ok {
+		return simulatorError("pod %s does not exist", name)
+	}
+
+	names := []string{r.Container}
+	if r.Container == "" {
+		names = sortedKeys(sp.containers)
+	}
+
+	for _, cname := range names {
+		c, ok := sp.containers[cname]
+		if !ok {
+			return simulatorError("container %s/%s does not exist", name, cname)
+		}
+		delete(sp.containers, cname)
+		err := s.policy.ReleaseResources(c)
+		s.cache.DeleteContainer(c.GetCacheID())
+		if err != nil {
+			return err
+		}
+	}
+
+	if r.Container == "" {
+		s.cache.DeletePod(sp.id)
+		delete(s.pods, name)
+	}
+
+	return nil
+}
+
+// decisions collects the current decisions for all containers.
+func (s *simulator) decisions() []*Decision {
+	decisions := []*Decision{}
+	for _, name := range sortedKeys(s.pods) {
+		sp := s.pods[name]
+		for _, cname := range sortedKeys(sp.containers) {
+			c := sp.containers[cname]
+			decisions = append(decisions, &Decision{
+				Name:         name + "/" + cname,
+				Cpus:         c.GetCpusetCpus(),
+				Mems:         c.GetCpusetMems(),
+				RDTClass:     c.GetRDTClass(),
+				BlockIOClass: c.GetBlockIOClass(),
+			})
+		}
+	}
+	return decisions
+}
+
+// namespace returns the given namespace, or the default one if it is empty.
+func namespace(ns string) string {
+	if ns == "" {
+		return "default"
+	}
+	return ns
+}
+
+// podName returns the namespaced name of a pod.
+func podName(ns, name string) string {
+	return namespace(ns) + "/" + name
+}
+
+// sortedKeys returns the keys of a pod or container map in sorted order.
+func sortedKeys(m interface{}) []string {
+	keys := []string{}
+	switch m.(type) {
+	case map[string]*simPod:
+		for key := range m.(map[string]*simPod) {
+			keys = append(keys, key)
+		}
+	case map[string]cache.Container:
+		for key := range m.(map[string]cache.Container) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulator
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/ghodss/yaml"
+
+	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/static"
+	_ "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy/builtin/topology-aware"
+)
+
+func TestScripts(t *testing.T) {
+	for _, name := range []string{"static", "partitions", "rdt", "zones", "caches"} {
+		t.Run(name, func(t *testing.T) {
+			script, err := LoadScript("testdata/" + name + ".yaml")
+			if err != nil {
+				t.Fatalf("failed to load script: %v", err)
+			}
+
+			steps, err := Run(script)
+			if err != nil {
+				t.Fatalf("simulation failed: %v", err)
+			}
+
+			out, err := yaml.Marshal(steps)
+			if err != nil {
+				t.Fatalf("failed to marshal results: %v", err)
+			}
+
+			golden, err := ioutil.ReadFile("testdata/" + name + ".golden")
+			if err != nil {
+				t.Fatalf("failed to read golden file: %v", err)
+			}
+
+			if string(out) != string(golden) {
+				t.Errorf("unexpected simulation results, expected\n%s\ngot\n%s", golden, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	resapi "k8s.io/apimachinery/pkg/api/resource"
+)
+
+// TopologyZone describes the resources of a topology zone (socket, NUMA node).
+type TopologyZone struct {
+	// Name is the name of the zone.
+	Name string `json:"name"`
+	// Type is the type of the zone, for instance socket or NUMA node.
+	Type string `json:"type"`
+	// Parent is the name of the zone containing this one, if any.
+	Parent string `json:"parent,omitempty"`
+	// Resources lists the capacity and free amount of resources in the zone.
+	Resources []*ZoneResource `json:"resources"`
+}
+
+// ZoneResource describes the capacity and free amount of a resource in a zone.
+type ZoneResource struct {
+	// Name is the name of the resource, for instance cpu, memory or hugepages-2Mi.
+	Name string `json:"name"`
+	// Capacity is the total amount of the resource in the zone.
+	Capacity resapi.Quantity `json:"capacity"`
+	// Available is the amount of the resource not allocated to any container.
+	Available resapi.Quantity `json:"available"`
+}
+
+// ZoneReporter is implemented by backends which can describe the resources
+// of the topology zones they manage.
+type ZoneReporter interface {
+	// GetTopologyZones returns the current capacity and free resources per zone.
+	GetTopologyZones() []*TopologyZone
+}
+
+// GetTopologyZones returns the topology zones of all backends that report them.
+func (p *policy) GetTopologyZones() []*TopologyZone {
+	if p.partitions == nil {
+		if r, ok := p.backend.(ZoneReporter); ok {
+			return r.GetTopologyZones()
+		}
+		return nil
+	}
+
+	var zones []*TopologyZone
+	for _, part := range p.partitions {
+		r, ok := part.backend.(ZoneReporter)
+		if !ok {
+			continue
+		}
+		for _, z := range r.GetTopologyZones() {
+			z.Name = part.Name + "/" + z.Name
+			if z.Parent != "" {
+				z.Parent = part.Name + "/" + z.Parent
+			}
+			zones = append(zones, z)
+		}
+	}
+	return zones
+}
@@ -63,6 +63,11 @@ func (m *resmgr) startRequestProcessing() error {
 		return nil
 	}
 
+	if m.switched {
+		add = m.activeContainers()
+		m.switched = false
+	}
+
 	if err := m.policy.Start(add, del); err != nil {
 		return resmgrError("failed to start policy %s: %v", policy.ActivePolicy(), err)
 	}
@@ -176,6 +181,8 @@ func (m *resmgr) RemovePod(ctx context.Context, method string, request interface
 		m.Info("%s: removing stale init-container %s...", method, c.PrettyName())
 		if err := m.policy.ReleaseResources(c); err != nil {
 			m.Warn("%s: failed to release init-container %s: %v", method, c.PrettyName(), err)
+		} else {
+			m.stats.released()
 		}
 		c.UpdateState(cache.ContainerStateStale)
 	}
@@ -183,6 +190,8 @@ func (m *resmgr) RemovePod(ctx context.Context, method string, request interface
 		m.Info("%s: removing stale container %s...", method, c.PrettyName())
 		if err := m.policy.ReleaseResources(c); err != nil {
 			m.Warn("%s: failed to release container %s: %v", method, c.PrettyName(), err)
+		} else {
+			m.stats.released()
 		}
 		c.UpdateState(cache.ContainerStateStale)
 	}
@@ -217,6 +226,7 @@ func (m *resmgr) CreateContainer(ctx context.Context, method string, request int
 	if err := m.policy.AllocateResources(container); err != nil {
 		m.Error("%s: failed to allocate resources for container %s: %v",
 			method, container.PrettyName(), err)
+		m.stats.failed(failedByPolicy)
 		m.cache.DeleteContainer(container.GetCacheID())
 		return nil, resmgrError("failed to allocate container resources: %v", err)
 	}
@@ -231,6 +241,7 @@ func (m *resmgr) CreateContainer(ctx context.Context, method string, request int
 	if err := m.runPostAllocateHooks(ctx, method); err != nil {
 		m.Error("%s: failed to run post-allocate hooks for %s: %v",
 			method, container.PrettyName(), err)
+		m.stats.failed(failedByHooks)
 		m.policy.ReleaseResources(container)
 		m.runPostReleaseHooks(ctx, method)
 		m.cache.DeleteContainer(container.GetCacheID())
@@ -242,12 +253,15 @@ func (m *resmgr) CreateContainer(ctx context.Context, method string, request int
 
 	if rqerr != nil {
 		m.Error("%s: failed to create container %s: %v", method, container.PrettyName(), rqerr)
+		m.stats.failed(failedByRuntime)
 		m.policy.ReleaseResources(container)
 		m.runPostReleaseHooks(ctx, method)
 		m.cache.DeleteContainer(container.GetCacheID())
 		return nil, resmgrError("failed to create container: %v", rqerr)
 	}
 
+	m.stats.allocated()
+
 	m.cache.UpdateContainerID(container.GetCacheID(), reply)
 	container.UpdateState(cache.ContainerStateCreated)
 
@@ -330,6 +344,8 @@ func (m *resmgr) StopContainer(ctx context.Context, method string, request inter
 	if err := m.policy.ReleaseResources(container); err != nil {
 		m.Error("%s: failed to release resources for container %s: %v",
 			method, container.PrettyName(), err)
+	} else {
+		m.stats.released()
 	}
 
 	if err := m.runPostReleaseHooks(ctx, method); err != nil {
@@ -372,6 +388,8 @@ func (m *resmgr) RemoveContainer(ctx context.Context, method string, request int
 	if err := m.policy.ReleaseResources(container); err != nil {
 		m.Error("%s: failed to release resources for container %s: %v",
 			method, container.PrettyName(), err)
+	} else {
+		m.stats.released()
 	}
 
 	if err := m.runPostReleaseHooks(ctx, method); err != nil {
@@ -391,20 +409,63 @@ func (m *resmgr) UpdateContainer(ctx context.Context, method string, request int
 	m.Lock()
 	defer m.Unlock()
 
-	containerID := request.(*criapi.UpdateContainerResourcesRequest).ContainerId
-	container, ok := m.cache.LookupContainer(containerID)
+	update := request.(*criapi.UpdateContainerResourcesRequest)
+	container, ok := m.cache.LookupContainer(update.ContainerId)
 
 	if !ok {
-		m.Warn("%s: silently dropping container update request for %s...",
-			method, containerID)
+		m.Warn("%s: failed to look up container %s, just passing request through",
+			method, update.ContainerId)
+		return handler(ctx, request)
+	}
+
+	m.Info("%s: updating container %s...", method, container.PrettyName())
+
+	changed, undo := container.SetResourceUpdates(update.GetLinux())
+	if changed {
+		m.Info("%s: resource requirements of %s changed", method, container.PrettyName())
+	}
+
+	if err := m.policy.UpdateResources(container); err != nil {
+		m.Error("%s: failed to update resources of container %s: %v",
+			method, container.PrettyName(), err)
+		undo()
+		return nil, resmgrError("failed to update container resources: %v", err)
+	}
+
+	// Notes:
+	//   We let the post-update hooks of the container update the original
+	//   request with any changes made by the policy. If the policy had no
+	//   opinion, the request is forwarded as such, except for the cpusets
+	//   which we always override with the ones assigned by the policy.
+	if linux := update.GetLinux(); linux != nil {
+		linux.CpusetCpus = container.GetCpusetCpus()
+		linux.CpusetMems = container.GetCpusetMems()
+	}
+	if err := container.SetCRIRequest(request); err != nil {
+		m.Warn("%s: failed to set pending request for %s: %v",
+			method, container.PrettyName(), err)
 	} else {
-		m.Warn("%s: silently dropping container update request for %s...",
-			method, container.PrettyName())
-		m.Warn("%s: XXX TODO: we probably should reallocate the container instead...",
-			method)
+		if err := m.control.RunPostUpdateHooks(container); err != nil {
+			m.Warn("%s: post-update hook failed for %s: %v",
+				method, container.PrettyName(), err)
+		}
+		container.ClearCRIRequest()
+	}
+
+	if err := m.runPostUpdateHooks(ctx, method); err != nil {
+		m.Error("%s: failed to run post-update hooks: %v", method, err)
 	}
 
-	return &criapi.UpdateContainerResourcesResponse{}, nil
+	reply, rqerr := handler(ctx, request)
+
+	if rqerr != nil {
+		m.Error("%s: failed to update container %s: %v", method, container.PrettyName(), rqerr)
+		return nil, rqerr
+	}
+
+	m.policy.ExportResourceData(container)
+
+	return reply, nil
 }
 
 // RebalanceContainers tries to find a more optimal container resource allocation if necessary.
@@ -415,13 +476,14 @@ func (m *resmgr) RebalanceContainers() error {
 	m.Info("rebalancing (reallocating) containers...")
 
 	method := "Rebalance"
-	changes, err := m.policy.Rebalance()
+	moved, err := m.policy.Rebalance()
 
 	if err != nil {
 		m.Error("%s: rebalancing of containers failed: %v", method, err)
 	}
 
-	if changes {
+	if moved > 0 {
+		m.stats.moved(moved)
 		if err := m.runPostUpdateHooks(context.Background(), method); err != nil {
 			m.Error("%s: failed to run post-update hooks: %v", method, err)
 			return resmgrError("%s: failed to run post-update hooks: %v", method, err)
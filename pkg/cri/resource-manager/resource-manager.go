@@ -15,9 +15,13 @@
 package resmgr
 
 import (
+	"context"
 	"sync"
 	"time"
 
+	criapi "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/intel/cri-resource-manager/pkg/cgroups"
 	pkgcfg "github.com/intel/cri-resource-manager/pkg/config"
 	"github.com/intel/cri-resource-manager/pkg/cri/relay"
 	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/agent"
@@ -37,6 +41,8 @@ type ResourceManager interface {
 	Stop()
 	// SetConfig dynamically updates the resource manager  configuration
 	SetConfig(*config.RawConfig) error
+	// CheckConfig validates the given configuration without taking it into use.
+	CheckConfig(*config.RawConfig) error
 	// SendEvent sends an event to be processed by the resource manager.
 	SendEvent(event interface{}) error
 }
@@ -55,12 +61,17 @@ type resmgr struct {
 	metrics      *metrics.Metrics  // metrics collector/pre-processor
 	events       chan interface{}  // channel for delivering events
 	stop         chan interface{}  // channel for signalling shutdown to goroutines
+	zones        string            // last published topology zones
+	stats        *policyMetrics    // policy decision metrics
+	switched     bool              // policy switched since last run, reallocate all containers
 }
 
 // NewResourceManager creates a new ResourceManager instance.
 func NewResourceManager() (ResourceManager, error) {
 	m := &resmgr{Logger: logger.NewLogger("resource-manager")}
 
+	m.Info("detected %s cgroup hierarchy", cgroups.GetMode())
+
 	if err := m.setupCache(); err != nil {
 		return nil, err
 	}
@@ -101,6 +112,12 @@ func NewResourceManager() (ResourceManager, error) {
 		return nil, err
 	}
 
+	m.setupIntrospection()
+
+	if err := m.setupPolicyMetrics(); err != nil {
+		return nil, err
+	}
+
 	return m, nil
 }
 
@@ -166,16 +183,24 @@ func (m *resmgr) SetConfig(conf *config.RawConfig) error {
 	m.Lock()
 	defer m.Unlock()
 
-	// TODO: save current configuration and roll back if some controllers fail to start
+	snapshot, err := pkgcfg.GetConfig()
+	if err != nil {
+		m.Error("failed to take snapshot of current configuration: %v", err)
+		return resmgrError("failed to take snapshot of current configuration: %v", err)
+	}
 
 	if err := pkgcfg.SetConfig(conf.Data); err != nil {
 		m.Error("new configuration was rejected: %v", err)
 		return resmgrError("configuration rejected: %v", err)
 	}
 
-	if err := m.control.StartStopControllers(m.cache, m.relay.Client()); err != nil {
-		m.Error("failed to activate new configuration: %v", err)
-		return resmgrError("failed to fully activate configuration: %v", err)
+	if err := m.activateConfig("SetConfig"); err != nil {
+		m.Error("failed to activate new configuration, reverting: %v", err)
+		pkgcfg.RevertConfig(snapshot)
+		if err := m.activateConfig("RevertConfig"); err != nil {
+			m.Error("failed to reactivate previous configuration: %v", err)
+		}
+		return resmgrError("failed to activate configuration: %v", err)
 	}
 
 	m.cache.SetConfig(conf)
@@ -184,6 +209,41 @@ func (m *resmgr) SetConfig(conf *config.RawConfig) error {
 	return nil
 }
 
+// activateConfig takes the current configuration into use by controllers and the policy.
+func (m *resmgr) activateConfig(method string) error {
+	if err := m.control.StartStopControllers(m.cache, m.relay.Client()); err != nil {
+		return err
+	}
+
+	if err := m.switchPolicy(); err != nil {
+		return err
+	}
+
+	// push any allocation changes made by the policy during reconfiguration
+	if err := m.runPostUpdateHooks(context.Background(), method); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CheckConfig validates the given configuration without taking it into use.
+func (m *resmgr) CheckConfig(conf *config.RawConfig) error {
+	m.Info("validating configuration...")
+
+	m.Lock()
+	defer m.Unlock()
+
+	if err := pkgcfg.CheckConfig(conf.Data); err != nil {
+		m.Error("configuration failed validation: %v", err)
+		return err
+	}
+
+	m.Info("configuration successfully validated")
+
+	return nil
+}
+
 // setupCache creates a cache and reloads its last saved state if found.
 func (m *resmgr) setupCache() error {
 	var err error
@@ -212,7 +272,7 @@ func (m *resmgr) setupConfigAgent() error {
 func (m *resmgr) setupConfigServer() error {
 	var err error
 
-	if m.configServer, err = config.NewConfigServer(m.SetConfig); err != nil {
+	if m.configServer, err = config.NewConfigServer(m.SetConfig, m.CheckConfig); err != nil {
 		return resmgrError("failed to create configuration notification server: %v", err)
 	}
 
@@ -296,22 +356,158 @@ func (m *resmgr) setupPolicy() error {
 	active := policy.ActivePolicy()
 	cached := m.cache.GetActivePolicy()
 
+	options := &policy.Options{AgentCli: m.agent}
+	if m.policy, err = policy.NewPolicy(m.cache, options); err != nil {
+		return resmgrError("failed to create policy %s: %v", active, err)
+	}
+
 	if active != cached {
 		if cached != "" {
-			return resmgrError("cannot load cache with policy %s for active policy %s",
+			m.Warn("switching from policy %s to %s, all containers will be reallocated",
 				cached, active)
+			if _, err := m.cache.ResetActivePolicy(); err != nil {
+				return resmgrError("failed to drop cached data of policy %s: %v", cached, err)
+			}
+			m.switched = true
 		}
 		m.cache.SetActivePolicy(active)
 	}
 
+	pkgcfg.GetModule(policy.ConfigPath).AddNotify(m.policyNotify)
+	pkgcfg.GetModule(policy.ConfigPath).AddCheck(m.policyCheck)
+
+	return nil
+}
+
+// policyNotify checks if a configuration update switches policies in a supported way.
+func (m *resmgr) policyNotify(event pkgcfg.Event, source pkgcfg.Source) error {
+	if event == pkgcfg.RevertEvent {
+		return nil
+	}
+	return m.checkPolicySwitch(policy.ActivePolicy())
+}
+
+// policyCheck checks if a candidate configuration switches policies in a supported way.
+func (m *resmgr) policyCheck(candidate interface{}, source pkgcfg.Source) error {
+	return m.checkPolicySwitch(policy.CheckedPolicy(candidate))
+}
+
+// checkPolicySwitch checks if switching to the given policy is possible without a restart.
+func (m *resmgr) checkPolicySwitch(active string) error {
+	current := m.cache.GetActivePolicy()
+
+	if active != current && (active == policy.NullPolicy || current == policy.NullPolicy) {
+		return resmgrError("switching from policy %s to %s requires a restart",
+			current, active)
+	}
+
+	return nil
+}
+
+// switchPolicy switches to the configured policy if it differs from the running one.
+func (m *resmgr) switchPolicy() error {
+	active := policy.ActivePolicy()
+	current := m.cache.GetActivePolicy()
+
+	if active == current {
+		return nil
+	}
+
+	m.Info("switching from policy %s to %s...", current, active)
+
 	options := &policy.Options{AgentCli: m.agent}
-	if m.policy, err = policy.NewPolicy(m.cache, options); err != nil {
+	p, err := policy.NewPolicy(m.cache, options)
+	if err != nil {
 		return resmgrError("failed to create policy %s: %v", active, err)
 	}
 
+	// keep the old policy running until the new one has successfully started
+	saved := m.saveContainerResources()
+	restore, err := m.cache.ResetActivePolicy()
+	if err != nil {
+		p.Stop()
+		if err := restore(); err != nil {
+			m.Error("failed to restore cached data of policy %s: %v", current, err)
+		}
+		return resmgrError("failed to drop cached data of policy %s: %v", current, err)
+	}
+	m.cache.SetActivePolicy(active)
+
+	if err := p.Start(m.activeContainers(), nil); err != nil {
+		p.Stop()
+		m.restoreContainerResources(saved)
+		if err := restore(); err != nil {
+			m.Error("failed to restore cached data of policy %s: %v", current, err)
+		}
+		return resmgrError("failed to start policy %s: %v", active, err)
+	}
+
+	m.policy.Stop()
+	m.policy = p
+
+	if err := m.runPostAllocateHooks(context.Background(), "SwitchPolicy"); err != nil {
+		m.Error("failed to run post-allocate hooks after switching policy: %v", err)
+	}
+
+	m.cache.Save()
+	m.Info("switched to policy %s", active)
+
 	return nil
 }
 
+// activeContainers returns all created and running containers, for reallocation.
+func (m *resmgr) activeContainers() []cache.Container {
+	containers := []cache.Container{}
+	for _, c := range m.cache.GetContainers() {
+		switch c.GetState() {
+		case cache.ContainerStateCreated, cache.ContainerStateRunning:
+			containers = append(containers, c)
+		}
+	}
+	return containers
+}
+
+// containerResources are the resources assigned to a container by the policy.
+type containerResources struct {
+	linux   *criapi.LinuxContainerResources // Linux resources
+	rdt     string                          // RDT class
+	blockio string                          // block I/O class
+}
+
+// saveContainerResources saves the resources of all containers, for restoring them later.
+func (m *resmgr) saveContainerResources() map[string]containerResources {
+	saved := map[string]containerResources{}
+	for _, c := range m.cache.GetContainers() {
+		r := containerResources{
+			rdt:     c.GetRDTClass(),
+			blockio: c.GetBlockIOClass(),
+		}
+		if linux := c.GetLinuxResources(); linux != nil {
+			resources := *linux
+			r.linux = &resources
+		}
+		saved[c.GetCacheID()] = r
+	}
+	return saved
+}
+
+// restoreContainerResources restores previously saved container resources.
+func (m *resmgr) restoreContainerResources(saved map[string]containerResources) {
+	for _, c := range m.cache.GetContainers() {
+		r, ok := saved[c.GetCacheID()]
+		if !ok {
+			continue
+		}
+		c.SetLinuxResources(r.linux)
+		if c.GetRDTClass() != r.rdt {
+			c.SetRDTClass(r.rdt)
+		}
+		if c.GetBlockIOClass() != r.blockio {
+			c.SetBlockIOClass(r.blockio)
+		}
+	}
+}
+
 // setupRelay sets up the CRI request relay.
 func (m *resmgr) setupRelay() error {
 	var err error
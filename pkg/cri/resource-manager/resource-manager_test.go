@@ -0,0 +1,281 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resmgr
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	pkgcfg "github.com/intel/cri-resource-manager/pkg/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/client"
+	"github.com/intel/cri-resource-manager/pkg/cri/relay"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/cache/cachetest"
+	config "github.com/intel/cri-resource-manager/pkg/cri/resource-manager/config"
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/policy"
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+)
+
+// testControl is a resource control whose controllers fail to start a given number of times.
+type testControl struct {
+	failures int
+}
+
+func (c *testControl) StartStopControllers(cache.Cache, client.Client) error {
+	if c.failures > 0 {
+		c.failures--
+		return fmt.Errorf("test controller failed to start")
+	}
+	return nil
+}
+
+func (c *testControl) RunPreCreateHooks(cache.Container) error  { return nil }
+func (c *testControl) RunPreStartHooks(cache.Container) error   { return nil }
+func (c *testControl) RunPostStartHooks(cache.Container) error  { return nil }
+func (c *testControl) RunPostUpdateHooks(cache.Container) error { return nil }
+func (c *testControl) RunPostStopHooks(cache.Container) error   { return nil }
+
+// testRelay is a relay without a CRI client.
+type testRelay struct {
+	relay.Relay
+}
+
+func (r *testRelay) Client() client.Client {
+	return nil
+}
+
+// testBackend is a policy backend pinning all containers to the same CPUs.
+type testBackend struct {
+	name   string
+	cpus   string
+	fail   bool
+	cache  cache.Cache
+	detach func()
+}
+
+// testOptions are the options of the configurable test backend.
+type testOptions struct {
+	Cpus string `json:"Cpus"`
+}
+
+// testOpt is the configuration of the configurable test backend.
+var testOpt = &testOptions{}
+
+// stopped counts the stopped test backends by name.
+var stopped = map[string]int{}
+
+// test backends by name, with the CPUs they use and whether they fail to start
+var testBackends = map[string]*testBackend{
+	"test-a":    {cpus: "0"},
+	"test-b":    {cpus: "1"},
+	"test-fail": {cpus: "2", fail: true},
+	// test-config uses the CPUs in its configuration
+	"test-config": {},
+}
+
+func init() {
+	pkgcfg.Register(policy.ConfigPath+".test-config", "Configurable test policy.", testOpt,
+		func() interface{} { return &testOptions{Cpus: "0"} })
+
+	for name, b := range testBackends {
+		name, b := name, b
+		policy.Register(name, "Test policy "+name+".", func(opts *policy.BackendOptions) policy.Backend {
+			backend := &testBackend{name: name, cpus: b.cpus, fail: b.fail, cache: opts.Cache}
+			if name == "test-config" {
+				backend.detach = pkgcfg.GetModule(policy.ConfigPath + ".test-config").AttachNotify(backend.configNotify)
+			}
+			return backend
+		})
+	}
+}
+
+func (b *testBackend) Name() string        { return b.name }
+func (b *testBackend) Description() string { return "Test policy " + b.name + "." }
+
+func (b *testBackend) Stop() {
+	stopped[b.name]++
+	if b.detach != nil {
+		b.detach()
+	}
+}
+
+// configNotify repins all containers to the configured CPUs.
+func (b *testBackend) configNotify(event pkgcfg.Event, source pkgcfg.Source) error {
+	b.pin(b.cache.GetContainers())
+	return nil
+}
+
+// pin pins the given containers to our CPUs.
+func (b *testBackend) pin(containers []cache.Container) {
+	cpus := b.cpus
+	if b.detach != nil {
+		cpus = testOpt.Cpus
+	}
+	for _, c := range containers {
+		c.SetCpusetCpus(cpus)
+	}
+}
+
+func (b *testBackend) Start(add []cache.Container, del []cache.Container) error {
+	b.cache.SetPolicyEntry("test-policy", b.name)
+	for _, c := range add {
+		b.pin([]cache.Container{c})
+		if b.fail {
+			return fmt.Errorf("test policy %s failed to start", b.name)
+		}
+	}
+	return nil
+}
+
+func (b *testBackend) Sync([]cache.Container, []cache.Container) error { return nil }
+func (b *testBackend) AllocateResources(cache.Container) error         { return nil }
+func (b *testBackend) ReleaseResources(cache.Container) error          { return nil }
+func (b *testBackend) UpdateResources(cache.Container) error           { return nil }
+func (b *testBackend) Rebalance() (int, error)                         { return 0, nil }
+
+func (b *testBackend) ExportResourceData(cache.Container) map[string]string {
+	return nil
+}
+
+// setPolicy sets the active policy in the configuration.
+func setPolicy(t *testing.T, name string) {
+	if err := pkgcfg.SetConfig(map[string]string{policy.ConfigPath: "Active: " + name}); err != nil {
+		t.Fatalf("failed to set active policy %s: %v", name, err)
+	}
+}
+
+// createTestResmgr creates a resource manager running the given test policy with a few containers.
+func createTestResmgr(t *testing.T, active string) (*resmgr, []cache.Container, func()) {
+	cch, cleanup := cachetest.NewCache(t)
+
+	m := &resmgr{
+		Logger:  logger.NewLogger("resource-manager"),
+		cache:   cch,
+		control: &testControl{},
+		relay:   &testRelay{},
+	}
+
+	containers := []cache.Container{}
+	for i := 0; i < 2; i++ {
+		c := cachetest.CreateContainer(t, m.cache, cachetest.Pod{Name: fmt.Sprintf("pod%d", i)})
+		c.UpdateState(cache.ContainerStateRunning)
+		containers = append(containers, c)
+	}
+
+	setPolicy(t, active)
+	if err := m.setupPolicy(); err != nil {
+		cleanup()
+		t.Fatalf("failed to set up policy %s: %v", active, err)
+	}
+	if err := m.policy.Start(m.activeContainers(), nil); err != nil {
+		cleanup()
+		t.Fatalf("failed to start policy %s: %v", active, err)
+	}
+
+	return m, containers, cleanup
+}
+
+// checkPolicy checks the active policy, its cached data and the CPUs of the containers.
+func checkPolicy(t *testing.T, m *resmgr, containers []cache.Container, active, cpus string) {
+	if cached := m.cache.GetActivePolicy(); cached != active {
+		t.Errorf("expected active policy %s in cache, got %s", active, cached)
+	}
+	if configured := policy.ActivePolicy(); configured != active {
+		t.Errorf("expected configured policy %s, got %s", active, configured)
+	}
+	entry := ""
+	if !m.cache.GetPolicyEntry("test-policy", &entry) || entry != active {
+		t.Errorf("expected cached data of policy %s, got %q", active, entry)
+	}
+	for _, c := range containers {
+		if c.GetCpusetCpus() != cpus {
+			t.Errorf("expected %s pinned to CPUs %s, got %s", c.PrettyName(), cpus, c.GetCpusetCpus())
+		}
+	}
+}
+
+func TestSwitchPolicy(t *testing.T) {
+	m, containers, cleanup := createTestResmgr(t, "test-a")
+	defer cleanup()
+	checkPolicy(t, m, containers, "test-a", "0")
+
+	// switching starts the new policy, then stops the old one
+	stopped["test-a"] = 0
+	if err := m.SetConfig(&config.RawConfig{Data: map[string]string{policy.ConfigPath: "Active: test-b"}}); err != nil {
+		t.Fatalf("failed to switch to policy test-b: %v", err)
+	}
+	checkPolicy(t, m, containers, "test-b", "1")
+	if stopped["test-a"] != 1 {
+		t.Errorf("expected policy test-a to be stopped once, got %d", stopped["test-a"])
+	}
+
+	// a failed switch keeps the old policy, its data and the container resources
+	stopped["test-b"], stopped["test-fail"] = 0, 0
+	active := m.policy
+	if err := m.SetConfig(&config.RawConfig{Data: map[string]string{policy.ConfigPath: "Active: test-fail"}}); err == nil {
+		t.Fatalf("expected switching to policy test-fail to fail")
+	}
+	checkPolicy(t, m, containers, "test-b", "1")
+	if stopped["test-b"] != 0 || stopped["test-fail"] != 1 {
+		t.Errorf("expected only policy test-fail to be stopped, got %v", stopped)
+	}
+	if m.policy != active {
+		t.Errorf("expected policy test-b to stay active")
+	}
+}
+
+func TestSetConfigRollback(t *testing.T) {
+	m, containers, cleanup := createTestResmgr(t, "test-config")
+	defer cleanup()
+	checkPolicy(t, m, containers, "test-config", "0")
+
+	snapshot, err := pkgcfg.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to take configuration snapshot: %v", err)
+	}
+
+	// a failing controller rolls back both the configuration and the allocations
+	m.control.(*testControl).failures = 1
+	conf := &config.RawConfig{Data: map[string]string{
+		policy.ConfigPath: "Active: test-config\ntest-config:\n  Cpus: \"1\"",
+	}}
+	if err := m.SetConfig(conf); err == nil {
+		t.Fatalf("expected configuration to be rejected")
+	}
+
+	current, err := pkgcfg.GetConfig()
+	if err != nil {
+		t.Fatalf("failed to get configuration: %v", err)
+	}
+	if !reflect.DeepEqual(current, snapshot) {
+		t.Errorf("expected configuration to be restored to %v, got %v", snapshot, current)
+	}
+	if testOpt.Cpus != "0" {
+		t.Errorf("expected configured CPUs to be restored to 0, got %s", testOpt.Cpus)
+	}
+	checkPolicy(t, m, containers, "test-config", "0")
+	for _, c := range containers {
+		if !c.HasPending(cache.CRI) {
+			t.Errorf("expected restored resources of %s to be pending for update", c.PrettyName())
+		}
+	}
+
+	// without failures the configuration is taken into use
+	if err := m.SetConfig(conf); err != nil {
+		t.Fatalf("failed to set configuration: %v", err)
+	}
+	checkPolicy(t, m, containers, "test-config", "1")
+}
@@ -23,4 +23,6 @@ const (
 	ResourceManagerAgent = "/var/run/cri-resmgr/cri-resmgr-agent.sock"
 	// ResourceManagerConfig for resource manager configuration notifications.
 	ResourceManagerConfig = "/var/run/cri-resmgr/cri-resmgr-config.sock"
+	// ExternalPolicy is the socket an external policy listens on.
+	ExternalPolicy = "/var/run/cri-resmgr/cri-resmgr-policy.sock"
 )
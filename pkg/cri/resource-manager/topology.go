@@ -0,0 +1,71 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resmgr
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/resource-manager/kubernetes"
+)
+
+const (
+	// topologyZonesTimeout is the timeout for publishing topology zones via the agent.
+	topologyZonesTimeout = 5 * time.Second
+)
+
+// TopologyZonesAnnotation is the node annotation we publish our topology zones in.
+var TopologyZonesAnnotation = kubernetes.ResmgrKey("topology-zones")
+
+// publishTopologyZones publishes the per-zone capacity and free resources, if changed.
+func (m *resmgr) publishTopologyZones() error {
+	zones, ok := m.topologyZones()
+	if !ok || zones == m.zones {
+		return nil
+	}
+
+	annotations := map[string]string{TopologyZonesAnnotation: zones}
+	if err := m.agent.SetAnnotations(annotations, topologyZonesTimeout); err != nil {
+		return resmgrError("failed to publish topology zones: %v", err)
+	}
+
+	m.Debug("published topology zones %s", zones)
+	m.zones = zones
+
+	return nil
+}
+
+// topologyZones returns the current topology zones of the active policy as JSON.
+func (m *resmgr) topologyZones() (string, bool) {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.policy == nil {
+		return "", false
+	}
+
+	zones := m.policy.GetTopologyZones()
+	if zones == nil {
+		return "", false
+	}
+
+	data, err := json.Marshal(zones)
+	if err != nil {
+		m.Error("failed to marshal topology zones: %v", err)
+		return "", false
+	}
+
+	return string(data), true
+}
@@ -0,0 +1,58 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/intel/cri-resource-manager/pkg/instrumentation"
+)
+
+const (
+	// phaseRelay is the latency label for request processing in the relay.
+	phaseRelay = "relay"
+	// phaseRuntime is the latency label for request processing in the CRI runtime.
+	phaseRuntime = "runtime"
+)
+
+// requestLatency is the histogram of intercepted CRI request latencies.
+var requestLatency = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name: "cri_request_duration_seconds",
+		Help: "Latency of intercepted CRI requests, by method and processing phase " +
+			"(relay for pre- and post-processing, runtime for the CRI runtime).",
+		Buckets: prometheus.ExponentialBuckets(0.0001, 4, 10),
+	},
+	[]string{"method", "phase"},
+)
+
+// observeRelayLatency records the time spent processing a CRI request in the relay.
+func observeRelayLatency(method string, latency time.Duration) {
+	requestLatency.WithLabelValues(method, phaseRelay).Observe(latency.Seconds())
+}
+
+// observeRuntimeLatency records the time spent processing a CRI request in the runtime.
+func observeRuntimeLatency(method string, latency time.Duration) {
+	requestLatency.WithLabelValues(method, phaseRuntime).Observe(latency.Seconds())
+}
+
+// Register our metrics for exporting.
+func init() {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(requestLatency)
+	instrumentation.RegisterGatherer(reg)
+}
@@ -87,7 +87,7 @@ func NewServer(options Options) (Server, error) {
 	return s, nil
 }
 
-// RegisterImageService registers an image service with the server.
+// RegisterImageService registers an image service with the server, for both API versions.
 func (s *server) RegisterImageService(service api.ImageServiceServer) error {
 	if s.image != nil {
 		return serverError("can't register image service, already registered")
@@ -100,11 +100,12 @@ func (s *server) RegisterImageService(service api.ImageServiceServer) error {
 	is := service
 	s.image = &is
 	api.RegisterImageServiceServer(s.server, s)
+	s.server.RegisterService(v1ServiceDesc(imageService, (*api.ImageServiceServer)(nil)), s)
 
 	return nil
 }
 
-// RegisterRuntimeService registers a runtime service with the server.
+// RegisterRuntimeService registers a runtime service with the server, for both API versions.
 func (s *server) RegisterRuntimeService(service api.RuntimeServiceServer) error {
 	if s.runtime != nil {
 		return serverError("can't register runtime server, already registered")
@@ -117,6 +118,7 @@ func (s *server) RegisterRuntimeService(service api.RuntimeServiceServer) error
 	rs := service
 	s.runtime = &rs
 	api.RegisterRuntimeServiceServer(s.server, s)
+	s.server.RegisterService(v1ServiceDesc(runtimeService, (*api.RuntimeServiceServer)(nil)), s)
 
 	return nil
 }
@@ -238,6 +240,7 @@ func (s *server) intercept(ctx context.Context, req interface{},
 	}
 
 	dump.RequestMessage(kind, info.FullMethod, req)
+	recorded := dump.RecordRequest(info.FullMethod, req)
 
 	if span := trace.FromContext(ctx); span != nil {
 		span.AddAttributes(trace.StringAttribute("kind", kind))
@@ -253,24 +256,34 @@ func (s *server) intercept(ctx context.Context, req interface{},
 	} else {
 		dump.ReplyMessage(kind, info.FullMethod, rpl, elapsed)
 	}
+	dump.RecordMessage(kind, info.FullMethod, recorded, rpl, err, send.IsZero(), start, elapsed)
 
 	s.collectStatistics(kind, name, start, send, recv, end)
 
 	return rpl, err
 }
 
-// collectStatistics collects (should collect) request processing statistics.
+// collectStatistics collects request processing statistics.
 func (s *server) collectStatistics(kind, name string, start, send, recv, end time.Time) {
 	if kind == "passthrough" {
 		return
 	}
 
+	// request rejected without ever reaching the CRI runtime
+	if send.IsZero() {
+		observeRelayLatency(name, end.Sub(start))
+		return
+	}
+
 	pre := send.Sub(start)
 	server := recv.Sub(send)
 	post := end.Sub(recv)
 
 	s.Debug(" * latency for %s: preprocess: %v, CRI server: %v, postprocess: %v, total: %v",
 		name, pre, server, post, pre+server+post)
+
+	observeRelayLatency(name, pre+post)
+	observeRuntimeLatency(name, server)
 }
 
 // Return a formatter server error.
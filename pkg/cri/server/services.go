@@ -24,7 +24,8 @@ import (
 )
 
 const (
-	apiVersion = "v1alpha2"
+	// apiVersion is the CRI API version we use internally.
+	apiVersion = apiVersionV1alpha2
 
 	imageService = "ImageService"
 	listImages   = "ListImages"
@@ -59,7 +60,7 @@ const (
 )
 
 func fqmn(service, method string) string {
-	return "/runtime." + apiVersion + "." + service + "/" + method
+	return "/" + serviceName(apiVersion, service) + "/" + method
 }
 
 func (s *server) interceptRequest(ctx context.Context, service, method string,
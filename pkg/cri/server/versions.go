@@ -0,0 +1,128 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"reflect"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/versions"
+	logger "github.com/intel/cri-resource-manager/pkg/log"
+)
+
+// Notes:
+//   We use the v1alpha2 CRI API as our internal representation of messages. The
+//   v1 API started its life as an identical copy of v1alpha2 with only the name
+//   of the protobuf package changed. The messages of the two versions are hence
+//   wire-compatible apart from fields added to v1 since, which we don't know
+//   about. This allows us to serve the v1 API by registering the v1 services
+//   with the very same handlers we use for v1alpha2, converting v1 requests to
+//   their v1alpha2 counterparts and replies back to v1. Any v1-only fields of a
+//   request are passed along in the request context, for our client to include
+//   them when relaying the request to a v1 runtime, and those of the reply are
+//   passed back the same way, to be included in our v1 reply.
+
+const (
+	// apiVersionV1 is the v1 CRI API version.
+	apiVersionV1 = "v1"
+	// apiVersionV1alpha2 is the v1alpha2 CRI API version.
+	apiVersionV1alpha2 = "v1alpha2"
+)
+
+// our logger instance, shared with the server
+var log = logger.NewLogger("cri/server")
+
+// serviceName returns the fully qualified gRPC name of a CRI service for the given API version.
+func serviceName(version, service string) string {
+	return "runtime." + version + "." + service
+}
+
+// v1ServiceDesc creates a v1 service description for a service with the given v1alpha2 interface.
+func v1ServiceDesc(service string, handlerType interface{}) *grpc.ServiceDesc {
+	name := serviceName(apiVersionV1, service)
+	desc := &grpc.ServiceDesc{
+		ServiceName: name,
+		HandlerType: handlerType,
+		Streams:     []grpc.StreamDesc{},
+		Metadata:    "api.proto",
+	}
+
+	itf := reflect.TypeOf(handlerType).Elem()
+	for i := 0; i < itf.NumMethod(); i++ {
+		method := itf.Method(i).Name
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: method,
+			Handler:    v1MethodHandler(name, method),
+		})
+	}
+
+	return desc
+}
+
+// v1MethodHandler creates a handler passing a v1 request to the corresponding v1alpha2 method.
+func v1MethodHandler(service, method string) func(interface{}, context.Context,
+	func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error,
+		interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		fn := reflect.ValueOf(srv).MethodByName(method)
+		raw := &versions.Raw{}
+		if err := dec(raw); err != nil {
+			return nil, err
+		}
+		req := reflect.New(fn.Type().In(1).Elem()).Interface()
+		ext, err := versions.FromV1(raw.Data, req.(versions.Message))
+		if err != nil {
+			return nil, grpcstatus.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		call := &versions.Call{Request: req, Extensions: ext}
+		ctx = versions.NewContext(ctx, call)
+
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			out := fn.Call([]reflect.Value{reflect.ValueOf(ctx), reflect.ValueOf(req)})
+			if err, ok := out[1].Interface().(error); ok && err != nil {
+				return nil, err
+			}
+			return out[0].Interface(), nil
+		}
+
+		var rpl interface{}
+		if interceptor == nil {
+			rpl, err = handler(ctx, req)
+		} else {
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + service + "/" + method,
+			}
+			rpl, err = interceptor(ctx, req, info, handler)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		data, complete, err := versions.ToV1(rpl.(versions.Message), call.Reply)
+		if err != nil {
+			return nil, grpcstatus.Errorf(codes.Internal, "%v", err)
+		}
+		if !complete {
+			log.Warn("%s: dropped v1-only fields of modified reply", method)
+		}
+		return &versions.Raw{Data: data}, nil
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	api "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+
+	"github.com/intel/cri-resource-manager/pkg/cri/client"
+	"github.com/intel/cri-resource-manager/pkg/cri/versions"
+)
+
+// fakeRuntime implements the few CRI methods we need for testing.
+type fakeRuntime struct {
+	api.RuntimeServiceServer
+	api.ImageServiceServer
+}
+
+func (f *fakeRuntime) Version(ctx context.Context, req *api.VersionRequest) (*api.VersionResponse, error) {
+	return &api.VersionResponse{RuntimeName: "fake", RuntimeApiVersion: req.Version}, nil
+}
+
+func (f *fakeRuntime) ImageFsInfo(ctx context.Context, req *api.ImageFsInfoRequest) (*api.ImageFsInfoResponse, error) {
+	return &api.ImageFsInfoResponse{}, nil
+}
+
+// startV1Runtime starts a fake runtime serving only the v1 CRI API.
+func startV1Runtime(t *testing.T, socket string) *grpc.Server {
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on socket %s: %v", socket, err)
+	}
+
+	srv := grpc.NewServer()
+	fake := &fakeRuntime{}
+	srv.RegisterService(v1ServiceDesc(runtimeService, (*api.RuntimeServiceServer)(nil)), fake)
+	srv.RegisterService(v1ServiceDesc(imageService, (*api.ImageServiceServer)(nil)), fake)
+	go srv.Serve(l)
+
+	return srv
+}
+
+// forwarder relays the Version requests it gets using a client.
+type forwarder struct {
+	api.RuntimeServiceServer
+	client client.Client
+}
+
+func (f *forwarder) Version(ctx context.Context, req *api.VersionRequest) (*api.VersionResponse, error) {
+	return f.client.Version(ctx, req)
+}
+
+// v1OnlyField is a v1-only field we add to messages, unknown to us.
+var v1OnlyField = append(proto.EncodeVarint(100<<3|proto.WireVarint), 1)
+
+// startRawV1Runtime starts a fake v1 runtime replying to Version requests with a v1-only field.
+func startRawV1Runtime(t *testing.T, socket string, requests chan<- []byte) *grpc.Server {
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to listen on socket %s: %v", socket, err)
+	}
+
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		req := &versions.Raw{}
+		if err := stream.RecvMsg(req); err != nil {
+			return err
+		}
+		requests <- req.Data
+		rpl, _ := (&api.VersionResponse{RuntimeName: "raw"}).Marshal()
+		return stream.SendMsg(&versions.Raw{Data: append(rpl, v1OnlyField...)})
+	}))
+	go srv.Serve(l)
+
+	return srv
+}
+
+// connect creates a client connected to the given socket.
+func connect(t *testing.T, socket string) client.Client {
+	c, err := client.NewClient(client.Options{ImageSocket: socket, RuntimeSocket: socket})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if err := c.Connect(client.ConnectOptions{Wait: true}); err != nil {
+		t.Fatalf("failed to connect client to %s: %v", socket, err)
+	}
+	return c
+}
+
+func TestAPIVersions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cri-versions-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	runtimeSocket := filepath.Join(dir, "runtime.sock")
+	relaySocket := filepath.Join(dir, "relay.sock")
+
+	runtime := startV1Runtime(t, runtimeSocket)
+	defer runtime.Stop()
+
+	// we should negotiate v1 with a v1-only runtime
+	rc := connect(t, runtimeSocket)
+	defer rc.Close()
+	if version := rc.APIVersion(); version != apiVersionV1 {
+		t.Errorf("expected negotiated API version %s, got %s", apiVersionV1, version)
+	}
+	rpl, err := rc.Version(context.Background(), &api.VersionRequest{Version: "test"})
+	if err != nil {
+		t.Fatalf("v1 Version request failed: %v", err)
+	}
+	if rpl.RuntimeName != "fake" || rpl.RuntimeApiVersion != "test" {
+		t.Errorf("unexpected Version reply %v", rpl)
+	}
+
+	// our server should serve, and intercept, both API versions
+	s, err := NewServer(Options{Socket: relaySocket})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := s.RegisterRuntimeService(&fakeRuntime{}); err != nil {
+		t.Fatalf("failed to register runtime service: %v", err)
+	}
+	intercepted := 0
+	s.RegisterInterceptors(map[string]Interceptor{
+		version: func(ctx context.Context, method string, req interface{}, h Handler) (interface{}, error) {
+			intercepted++
+			return h(ctx, req)
+		},
+	})
+	if err := s.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Stop()
+
+	cc, err := grpc.Dial(relaySocket, grpc.WithInsecure(),
+		grpc.WithDialer(func(socket string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", socket, timeout)
+		}))
+	if err != nil {
+		t.Fatalf("failed to connect to server: %v", err)
+	}
+	defer cc.Close()
+
+	for _, v := range []string{apiVersionV1alpha2, apiVersionV1} {
+		rpl := &api.VersionResponse{}
+		method := "/" + serviceName(v, runtimeService) + "/" + version
+		if err := cc.Invoke(context.Background(), method, &api.VersionRequest{}, rpl); err != nil {
+			t.Errorf("%s Version request failed: %v", v, err)
+			continue
+		}
+		if rpl.RuntimeName != "fake" {
+			t.Errorf("unexpected %s Version reply %v", v, rpl)
+		}
+	}
+	if intercepted != 2 {
+		t.Errorf("expected 2 intercepted requests, got %d", intercepted)
+	}
+}
+
+func TestV1OnlyFields(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cri-versions-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	runtimeSocket := filepath.Join(dir, "runtime.sock")
+	relaySocket := filepath.Join(dir, "relay.sock")
+
+	requests := make(chan []byte, 8)
+	runtime := startRawV1Runtime(t, runtimeSocket, requests)
+	defer runtime.Stop()
+
+	rc := connect(t, runtimeSocket)
+	defer rc.Close()
+	<-requests // API version negotiation
+
+	s, err := NewServer(Options{Socket: relaySocket})
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	if err := s.RegisterRuntimeService(&forwarder{client: rc}); err != nil {
+		t.Fatalf("failed to register runtime service: %v", err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	defer s.Stop()
+
+	cc, err := grpc.Dial(relaySocket, grpc.WithInsecure(),
+		grpc.WithDialer(func(socket string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", socket, timeout)
+		}))
+	if err != nil {
+		t.Fatalf("failed to connect to server: %v", err)
+	}
+	defer cc.Close()
+
+	// v1-only fields of both the request and the reply should be relayed
+	req, _ := (&api.VersionRequest{Version: "test"}).Marshal()
+	req = append(req, v1OnlyField...)
+	rpl := &versions.Raw{}
+	method := "/" + serviceName(apiVersionV1, runtimeService) + "/" + version
+	if err := cc.Invoke(context.Background(), method, &versions.Raw{Data: req}, rpl); err != nil {
+		t.Fatalf("v1 Version request failed: %v", err)
+	}
+
+	relayed := &api.VersionRequest{}
+	if ext, err := versions.FromV1(<-requests, relayed); err != nil || ext.IsEmpty() {
+		t.Errorf("expected relayed request with v1-only fields, got %v (error %v)", relayed, err)
+	}
+	reply := &api.VersionResponse{}
+	if ext, err := versions.FromV1(rpl.Data, reply); err != nil || ext.IsEmpty() || reply.RuntimeName != "raw" {
+		t.Errorf("expected reply with v1-only fields, got %v (error %v)", reply, err)
+	}
+}
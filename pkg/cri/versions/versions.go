@@ -0,0 +1,325 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versions
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Notes:
+//   We use the v1alpha2 CRI API as our internal representation of messages. The
+//   v1 API started its life as an identical copy of v1alpha2, so the messages of
+//   the two versions are wire-compatible apart from the fields added to v1 since.
+//   Decoding a v1 message into its v1alpha2 counterpart would silently drop any
+//   such v1-only fields. Instead, we convert messages explicitly, collecting the
+//   v1-only fields of a message as Extensions while converting it from v1 and
+//   putting them back in place when converting it (or its reply) back to v1.
+
+// Message is a CRI message with its own wire format encoder and decoder.
+type Message interface {
+	proto.Message
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+// Extensions are the v1-only fields of a message, unknown to our internal API.
+type Extensions struct {
+	fields []byte                  // unknown fields, in wire format
+	nested map[int32]*Extensions   // extensions of singular message fields
+	items  map[int32][]*Extensions // extensions of repeated message fields, by index
+}
+
+// FromV1 decodes a v1 message into msg, returning any v1-only fields of the message.
+func FromV1(data []byte, msg Message) (*Extensions, error) {
+	if err := msg.Unmarshal(data); err != nil {
+		return nil, versionsError("failed to decode v1 %T: %v", msg, err)
+	}
+	ext, err := extract(data, reflect.TypeOf(msg).Elem())
+	if err != nil {
+		return nil, versionsError("failed to decode v1 %T: %v", msg, err)
+	}
+	return ext, nil
+}
+
+// ToV1 encodes msg as a v1 message, putting back the given v1-only fields. If
+// some of the fields can't be put back, because their enclosing message is gone,
+// the encoding is returned and complete is false.
+func ToV1(msg Message, ext *Extensions) ([]byte, bool, error) {
+	data, err := msg.Marshal()
+	if err != nil {
+		return nil, false, versionsError("failed to encode v1 %T: %v", msg, err)
+	}
+	data, complete, err := attach(data, reflect.TypeOf(msg).Elem(), ext)
+	if err != nil {
+		return nil, false, versionsError("failed to encode v1 %T: %v", msg, err)
+	}
+	return data, complete, nil
+}
+
+// IsEmpty returns true if there are no v1-only fields.
+func (ext *Extensions) IsEmpty() bool {
+	return ext == nil
+}
+
+// Call tracks the v1-only fields of a request being relayed and those of its reply.
+type Call struct {
+	Request    interface{} // the request being relayed, as decoded by FromV1
+	Extensions *Extensions // the v1-only fields of the request
+	Reply      *Extensions // the v1-only fields of the reply
+}
+
+// callKey is the context key for a call.
+type callKey struct{}
+
+// NewContext returns a context carrying the given call.
+func NewContext(ctx context.Context, call *Call) context.Context {
+	return context.WithValue(ctx, callKey{}, call)
+}
+
+// FromContext returns the call for the given request carried by the context, if any.
+func FromContext(ctx context.Context, req interface{}) *Call {
+	call, ok := ctx.Value(callKey{}).(*Call)
+	if !ok || call.Request != req {
+		return nil
+	}
+	return call
+}
+
+// Raw is a message in wire format, passed through the gRPC codec as is.
+type Raw struct {
+	Data []byte
+}
+
+// Reset implements proto.Message.
+func (r *Raw) Reset() {
+	r.Data = nil
+}
+
+// String implements proto.Message.
+func (r *Raw) String() string {
+	return fmt.Sprintf("%x", r.Data)
+}
+
+// ProtoMessage implements proto.Message.
+func (r *Raw) ProtoMessage() {}
+
+// Marshal implements proto.Marshaler.
+func (r *Raw) Marshal() ([]byte, error) {
+	return r.Data, nil
+}
+
+// Unmarshal implements proto.Unmarshaler.
+func (r *Raw) Unmarshal(data []byte) error {
+	r.Data = append([]byte(nil), data...)
+	return nil
+}
+
+// field describes a field of a message.
+type field struct {
+	message  reflect.Type // message type for message fields, nil otherwise
+	repeated bool         // whether this is a repeated field
+}
+
+// messageFields returns the fields of a message type by field number.
+func messageFields(t reflect.Type) map[int32]field {
+	fields := map[int32]field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("protobuf"), ",")
+		if len(tag) < 2 {
+			continue
+		}
+		num, err := strconv.Atoi(tag[1])
+		if err != nil {
+			continue
+		}
+		info := field{}
+		ft := f.Type
+		if ft.Kind() == reflect.Slice && ft.Elem().Kind() != reflect.Uint8 {
+			info.repeated = true
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			info.message = ft
+		}
+		fields[int32(num)] = info
+	}
+	return fields
+}
+
+// nextField parses the next field in data, returning its number, wire type,
+// the length of its key, its total length and its payload if length-delimited.
+func nextField(data []byte) (int32, uint64, int, int, []byte, error) {
+	key, klen := proto.DecodeVarint(data)
+	if klen == 0 {
+		return 0, 0, 0, 0, nil, versionsError("invalid field key")
+	}
+	num, wire := int32(key>>3), key&0x7
+	switch wire {
+	case proto.WireVarint:
+		_, n := proto.DecodeVarint(data[klen:])
+		if n == 0 {
+			return 0, 0, 0, 0, nil, versionsError("invalid varint in field %d", num)
+		}
+		return num, wire, klen, klen + n, nil, nil
+	case proto.WireFixed64:
+		if len(data) < klen+8 {
+			return 0, 0, 0, 0, nil, versionsError("truncated field %d", num)
+		}
+		return num, wire, klen, klen + 8, nil, nil
+	case proto.WireFixed32:
+		if len(data) < klen+4 {
+			return 0, 0, 0, 0, nil, versionsError("truncated field %d", num)
+		}
+		return num, wire, klen, klen + 4, nil, nil
+	case proto.WireBytes:
+		size, n := proto.DecodeVarint(data[klen:])
+		if n == 0 || uint64(len(data)-klen-n) < size {
+			return 0, 0, 0, 0, nil, versionsError("truncated field %d", num)
+		}
+		end := klen + n + int(size)
+		return num, wire, klen, end, data[klen+n : end], nil
+	}
+	return 0, 0, 0, 0, nil, versionsError("unsupported wire type %d in field %d", wire, num)
+}
+
+// extract collects the fields of data unknown to the given message type.
+func extract(data []byte, t reflect.Type) (*Extensions, error) {
+	fields := messageFields(t)
+	ext := &Extensions{
+		nested: map[int32]*Extensions{},
+		items:  map[int32][]*Extensions{},
+	}
+	index := map[int32]int{}
+	empty := true
+
+	for len(data) > 0 {
+		num, wire, _, size, payload, err := nextField(data)
+		if err != nil {
+			return nil, err
+		}
+		f, known := fields[num]
+		switch {
+		case !known:
+			ext.fields = append(ext.fields, data[:size]...)
+			empty = false
+		case f.message != nil && wire == proto.WireBytes:
+			sub, err := extract(payload, f.message)
+			if err != nil {
+				return nil, err
+			}
+			if f.repeated {
+				idx := index[num]
+				index[num]++
+				if sub != nil {
+					for len(ext.items[num]) <= idx {
+						ext.items[num] = append(ext.items[num], nil)
+					}
+					ext.items[num][idx] = sub
+					empty = false
+				}
+			} else if sub != nil {
+				ext.nested[num] = sub
+				empty = false
+			}
+		}
+		data = data[size:]
+	}
+
+	// pad item extensions to the number of items, to detect added or removed ones
+	for num, items := range ext.items {
+		for len(items) < index[num] {
+			items = append(items, nil)
+		}
+		ext.items[num] = items
+	}
+
+	if empty {
+		return nil, nil
+	}
+	return ext, nil
+}
+
+// attach puts back the given unknown fields into data of the given message type.
+func attach(data []byte, t reflect.Type, ext *Extensions) ([]byte, bool, error) {
+	if ext == nil {
+		return data, true, nil
+	}
+
+	fields := messageFields(t)
+	out := make([]byte, 0, len(data)+len(ext.fields))
+	index := map[int32]int{}
+	seen := map[int32]bool{}
+	complete := true
+
+	for len(data) > 0 {
+		num, wire, klen, size, payload, err := nextField(data)
+		if err != nil {
+			return nil, false, err
+		}
+		var sub *Extensions
+		if f, ok := fields[num]; ok && f.message != nil && wire == proto.WireBytes {
+			if f.repeated {
+				if idx := index[num]; idx < len(ext.items[num]) {
+					sub = ext.items[num][idx]
+				}
+				index[num]++
+			} else {
+				sub = ext.nested[num]
+				seen[num] = true
+			}
+		}
+		if sub == nil {
+			out = append(out, data[:size]...)
+		} else {
+			payload, ok, err := attach(payload, fields[num].message, sub)
+			if err != nil {
+				return nil, false, err
+			}
+			complete = complete && ok
+			out = append(out, data[:klen]...)
+			out = append(out, proto.EncodeVarint(uint64(len(payload)))...)
+			out = append(out, payload...)
+		}
+		data = data[size:]
+	}
+
+	for num := range ext.nested {
+		if !seen[num] {
+			complete = false
+		}
+	}
+	for num, items := range ext.items {
+		if index[num] != len(items) {
+			complete = false
+		}
+	}
+
+	return append(out, ext.fields...), complete, nil
+}
+
+// versionsError returns a formatted package-specific error.
+func versionsError(format string, args ...interface{}) error {
+	return fmt.Errorf("cri/versions: "+format, args...)
+}
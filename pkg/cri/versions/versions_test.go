@@ -0,0 +1,139 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versions
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	api "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+// v1Only is the number of the v1-only fields we add to messages.
+const v1Only = 100
+
+// encode encodes a message, appending the given fields.
+func encode(t *testing.T, msg Message, fields ...[]byte) []byte {
+	data, err := msg.Marshal()
+	if err != nil {
+		t.Fatalf("failed to encode %T: %v", msg, err)
+	}
+	for _, f := range fields {
+		data = append(data, f...)
+	}
+	return data
+}
+
+// varintField encodes a varint field.
+func varintField(num int, value uint64) []byte {
+	return append(proto.EncodeVarint(uint64(num)<<3|proto.WireVarint), proto.EncodeVarint(value)...)
+}
+
+// bytesField encodes a length-delimited field.
+func bytesField(num int, data []byte) []byte {
+	f := proto.EncodeVarint(uint64(num)<<3 | proto.WireBytes)
+	f = append(f, proto.EncodeVarint(uint64(len(data)))...)
+	return append(f, data...)
+}
+
+// createV1Request creates a v1 container creation request with v1-only fields
+// at the top level, in a nested message and in an item of a repeated field.
+func createV1Request(t *testing.T) []byte {
+	mount := encode(t, &api.Mount{ContainerPath: "/data"}, varintField(v1Only, 1))
+	resources := encode(t, &api.LinuxContainerResources{CpuShares: 2}, varintField(v1Only, 2))
+	config := encode(t, &api.ContainerConfig{Metadata: &api.ContainerMetadata{Name: "ctr"}},
+		bytesField(7, mount), bytesField(15, bytesField(1, resources)))
+	return encode(t, &api.CreateContainerRequest{PodSandboxId: "pod"},
+		bytesField(2, config), varintField(v1Only, 3))
+}
+
+func TestConversion(t *testing.T) {
+	data := createV1Request(t)
+
+	req := &api.CreateContainerRequest{}
+	ext, err := FromV1(data, req)
+	if err != nil {
+		t.Fatalf("failed to convert v1 request: %v", err)
+	}
+	if ext.IsEmpty() {
+		t.Fatalf("expected v1-only fields in request")
+	}
+	if req.PodSandboxId != "pod" || len(req.Config.Mounts) != 1 ||
+		req.Config.Linux.Resources.CpuShares != 2 {
+		t.Fatalf("unexpected converted request %v", req)
+	}
+
+	// v1-only fields survive modifications of the request
+	req.Config.Linux.Resources.CpuShares = 4
+	req.Config.Mounts[0].Readonly = true
+	converted, complete, err := ToV1(req, ext)
+	if err != nil || !complete {
+		t.Fatalf("failed to convert request back to v1 (complete: %v): %v", complete, err)
+	}
+	chk := &api.CreateContainerRequest{}
+	chkExt, err := FromV1(converted, chk)
+	if err != nil {
+		t.Fatalf("failed to convert v1 request: %v", err)
+	}
+	if !reflect.DeepEqual(ext, chkExt) {
+		t.Errorf("v1-only fields changed in conversion, expected %v, got %v", ext, chkExt)
+	}
+	if chk.Config.Linux.Resources.CpuShares != 4 || !chk.Config.Mounts[0].Readonly {
+		t.Errorf("modifications lost in conversion, got %v", chk)
+	}
+
+	// a plain message converts without v1-only fields
+	plain := &api.CreateContainerRequest{PodSandboxId: "pod"}
+	if ext, err := FromV1(encode(t, plain), &api.CreateContainerRequest{}); err != nil || !ext.IsEmpty() {
+		t.Errorf("expected no v1-only fields in plain request, got %v (error %v)", ext, err)
+	}
+
+	// v1-only fields of removed messages can't be kept
+	tcs := map[string]func(*api.CreateContainerRequest){
+		"removed mount":     func(r *api.CreateContainerRequest) { r.Config.Mounts = nil },
+		"added mount":       func(r *api.CreateContainerRequest) { r.Config.Mounts = append(r.Config.Mounts, &api.Mount{}) },
+		"removed resources": func(r *api.CreateContainerRequest) { r.Config.Linux.Resources = nil },
+	}
+	for name, modify := range tcs {
+		req := &api.CreateContainerRequest{}
+		ext, err := FromV1(data, req)
+		if err != nil {
+			t.Fatalf("%s: failed to convert v1 request: %v", name, err)
+		}
+		modify(req)
+		if _, complete, err := ToV1(req, ext); err != nil || complete {
+			t.Errorf("%s: expected incomplete conversion, got complete %v, error %v",
+				name, complete, err)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	req, other := &api.VersionRequest{}, &api.VersionRequest{}
+	call := &Call{Request: req}
+	ctx := NewContext(context.Background(), call)
+
+	if FromContext(ctx, req) != call {
+		t.Errorf("expected call for request in context")
+	}
+	if FromContext(ctx, other) != nil {
+		t.Errorf("expected no call for other request in context")
+	}
+	if FromContext(context.Background(), req) != nil {
+		t.Errorf("expected no call in empty context")
+	}
+}
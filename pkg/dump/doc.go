@@ -62,6 +62,11 @@ explicitly enabled, otherwise messages are suppressed.
 If a dump file is specified messages will be dumped additionally
 to the dump file as well.
 
+If a record file is specified all requests and replies are also
+recorded to the record file, one JSON object per line, in a format
+suitable for replaying with cri-resmgr-replay. Recording ignores the
+level of detail configured for dumping.
+
 Here is a sample configuration fragment to suppress all .*List.*
 calls, produce short dumps of all .*Stop.* calls, and full dumps
 of everything else, dumps also going to the file '/tmp/cri-dump.log'
@@ -59,6 +59,7 @@ func checkAndScheduleDumpFileSwitch() {
 			log.Info("old message dump file '%s' closed", fileName)
 		}
 	}
+	rec.checkFileSwitch(string(opt.Record))
 }
 
 func checkDumpFile() bool {
@@ -47,6 +47,8 @@ const (
 	optDump = "dump"
 	// optDumpFile is the command line option to specify an additional file to dump to.
 	optDumpFile = "dump-file"
+	// optDumpRecord is the command line option to specify a file to record messages to.
+	optDumpRecord = "dump-record"
 )
 
 // verbosity defines the level of detail for a message dump.
@@ -75,6 +77,7 @@ type options struct {
 	sync.Mutex
 	Config   string               // last value Set()
 	File     dumpFile             // file to also dump to, if set
+	Record   dumpFile             // file to record messages to, if set
 	Debug    bool                 // log messages as debug messages
 	Disabled bool                 // whether dumping is globally disabled
 	methods  map[string]verbosity // method to verbosity map
@@ -212,6 +215,9 @@ func (f *dumpFile) Set(value string) error {
 	if defaults != nil && f == &defaults.File {
 		opt.File = defaults.File
 	}
+	if defaults != nil && f == &defaults.Record {
+		opt.Record = defaults.Record
+	}
 
 	return nil
 }
@@ -257,10 +263,24 @@ func (o *options) MarshalJSON() ([]byte, error) {
 	cfg := map[string]string{
 		"Config": o.Config,
 		"File":   string(o.File),
+		"Record": string(o.Record),
 	}
 	return json.Marshal(cfg)
 }
 
+// ConfigSchema describes our configuration data for config schema generation.
+func (o *options) ConfigSchema() *config.Schema {
+	return &config.Schema{
+		Type: "object",
+		Properties: map[string]*config.Schema{
+			"Config": {Type: "string",
+				Description: "Dump specification of the format [target:]message[,...], target being off, short, or full."},
+			"File":   {Type: "string", Description: "Additional file to dump messages to."},
+			"Record": {Type: "string", Description: "File to record all messages to in a replayable format."},
+		},
+	}
+}
+
 func (o *options) UnmarshalJSON(raw []byte) error {
 	cfg := map[string]string{}
 	if err := json.Unmarshal(raw, &cfg); err != nil {
@@ -277,6 +297,10 @@ func (o *options) UnmarshalJSON(raw []byte) error {
 			if err := o.File.Set(value); err != nil {
 				return err
 			}
+		case "Record":
+			if err := o.Record.Set(value); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -288,6 +312,7 @@ func defaultOptions() interface{} {
 	o := &options{}
 	o.Set(defaults.String())
 	o.File.Set(defaults.File.String())
+	o.Record.Set(defaults.Record.String())
 	return o
 }
 
@@ -299,6 +324,7 @@ func (o *options) configNotify(event config.Event, source config.Source) error {
 
 	log.Info(" * dumping: %s", opt.String())
 	log.Info(" * dump file: %v", opt.File)
+	log.Info(" * record file: %v", opt.Record)
 	log.Info(" * log with debug: %v", opt.Debug)
 
 	return nil
@@ -313,6 +339,8 @@ func init() {
 			"The possible targets are:\n    off, short, and full")
 	flag.Var(&defaults.File, optDumpFile,
 		"additional file to dump messages to")
+	flag.Var(&defaults.Record, optDumpRecord,
+		"file to record all messages to in a replayable format")
 
 	config.Register("dump", configHelp, opt, defaultOptions,
 		config.WithNotify(opt.configNotify))
@@ -0,0 +1,254 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	api "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+//
+// Recording captures CRI requests with their replies in a machine-readable
+// format, one JSON-encoded Record per line. Unlike dumping, recording is not
+// subject to per-method verbosity: every request is recorded, so that the
+// capture can be replayed later against a fake runtime.
+//
+
+// Record is a single recorded CRI request with its reply.
+type Record struct {
+	// Time is the time the request was received.
+	Time time.Time `json:"time"`
+	// Method is the full gRPC method name of the request.
+	Method string `json:"method"`
+	// Kind tells whether the request was intercepted or passed through.
+	Kind string `json:"kind"`
+	// Latency is the total latency of processing the request, in seconds.
+	Latency float64 `json:"latency"`
+	// Request is the request message.
+	Request json.RawMessage `json:"request"`
+	// Reply is the reply message, if the request succeeded.
+	Reply json.RawMessage `json:"reply,omitempty"`
+	// Error is the returned error, if the request failed.
+	Error string `json:"error,omitempty"`
+	// Rejected tells whether the request failed without ever reaching the runtime.
+	Rejected bool `json:"rejected,omitempty"`
+}
+
+// recorder writes records to the configured record file.
+type recorder struct {
+	sync.Mutex
+	file *os.File // currently open record file
+	name string   // name of the record file
+}
+
+var rec = &recorder{}
+
+// messageTypes are sample request and reply messages for CRI methods.
+var messageTypes = map[string][2]interface{}{
+	"ListImages":               {&api.ListImagesRequest{}, &api.ListImagesResponse{}},
+	"ImageStatus":              {&api.ImageStatusRequest{}, &api.ImageStatusResponse{}},
+	"PullImage":                {&api.PullImageRequest{}, &api.PullImageResponse{}},
+	"RemoveImage":              {&api.RemoveImageRequest{}, &api.RemoveImageResponse{}},
+	"ImageFsInfo":              {&api.ImageFsInfoRequest{}, &api.ImageFsInfoResponse{}},
+	"Version":                  {&api.VersionRequest{}, &api.VersionResponse{}},
+	"RunPodSandbox":            {&api.RunPodSandboxRequest{}, &api.RunPodSandboxResponse{}},
+	"StopPodSandbox":           {&api.StopPodSandboxRequest{}, &api.StopPodSandboxResponse{}},
+	"RemovePodSandbox":         {&api.RemovePodSandboxRequest{}, &api.RemovePodSandboxResponse{}},
+	"PodSandboxStatus":         {&api.PodSandboxStatusRequest{}, &api.PodSandboxStatusResponse{}},
+	"ListPodSandbox":           {&api.ListPodSandboxRequest{}, &api.ListPodSandboxResponse{}},
+	"CreateContainer":          {&api.CreateContainerRequest{}, &api.CreateContainerResponse{}},
+	"StartContainer":           {&api.StartContainerRequest{}, &api.StartContainerResponse{}},
+	"StopContainer":            {&api.StopContainerRequest{}, &api.StopContainerResponse{}},
+	"RemoveContainer":          {&api.RemoveContainerRequest{}, &api.RemoveContainerResponse{}},
+	"ListContainers":           {&api.ListContainersRequest{}, &api.ListContainersResponse{}},
+	"ContainerStatus":          {&api.ContainerStatusRequest{}, &api.ContainerStatusResponse{}},
+	"UpdateContainerResources": {&api.UpdateContainerResourcesRequest{}, &api.UpdateContainerResourcesResponse{}},
+	"ReopenContainerLog":       {&api.ReopenContainerLogRequest{}, &api.ReopenContainerLogResponse{}},
+	"ExecSync":                 {&api.ExecSyncRequest{}, &api.ExecSyncResponse{}},
+	"Exec":                     {&api.ExecRequest{}, &api.ExecResponse{}},
+	"Attach":                   {&api.AttachRequest{}, &api.AttachResponse{}},
+	"PortForward":              {&api.PortForwardRequest{}, &api.PortForwardResponse{}},
+	"ContainerStats":           {&api.ContainerStatsRequest{}, &api.ContainerStatsResponse{}},
+	"ListContainerStats":       {&api.ListContainerStatsRequest{}, &api.ListContainerStatsResponse{}},
+	"UpdateRuntimeConfig":      {&api.UpdateRuntimeConfigRequest{}, &api.UpdateRuntimeConfigResponse{}},
+	"Status":                   {&api.StatusRequest{}, &api.StatusResponse{}},
+}
+
+// NewMessages creates empty request and reply messages for the given CRI method.
+func NewMessages(name string) (interface{}, interface{}, error) {
+	method := name[strings.LastIndex(name, "/")+1:]
+	types, ok := messageTypes[method]
+	if !ok {
+		return nil, nil, dumpError("unknown CRI method '%s'", name)
+	}
+	req := reflect.New(reflect.TypeOf(types[0]).Elem()).Interface()
+	rpl := reflect.New(reflect.TypeOf(types[1]).Elem()).Interface()
+	return req, rpl, nil
+}
+
+// RecordRequest captures a request for recording, if recording is enabled. It
+// needs to be called before the request is processed, since processing might
+// alter the request.
+func RecordRequest(name string, request interface{}) json.RawMessage {
+	opt.Lock()
+	file := string(opt.Record)
+	opt.Unlock()
+
+	if file == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(request)
+	if err != nil {
+		log.Error("failed to record %s request: %v", name, err)
+		return nil
+	}
+
+	return raw
+}
+
+// RecordMessage records a captured request with its reply or error, if recording is enabled.
+// Rejected tells if the request failed without ever being passed on to the runtime.
+func RecordMessage(kind, name string, request json.RawMessage, reply interface{}, err error, rejected bool, start time.Time, latency time.Duration) {
+	opt.Lock()
+	file := string(opt.Record)
+	opt.Unlock()
+
+	if file == "" || request == nil {
+		return
+	}
+
+	r := &Record{
+		Time:    start,
+		Method:  name,
+		Kind:    kind,
+		Latency: latency.Seconds(),
+		Request: request,
+	}
+
+	var merr error
+	if err != nil {
+		r.Error = err.Error()
+		r.Rejected = rejected
+	} else if r.Reply, merr = json.Marshal(reply); merr != nil {
+		log.Error("failed to record %s reply: %v", name, merr)
+		return
+	}
+
+	rec.write(file, r)
+}
+
+// checkFileSwitch closes the record file if it differs from the given one.
+func (r *recorder) checkFileSwitch(name string) {
+	r.Lock()
+	defer r.Unlock()
+	r.closeIfSwitched(name)
+}
+
+// closeIfSwitched closes the record file if it differs from the given one, with r locked.
+func (r *recorder) closeIfSwitched(name string) {
+	if r.file != nil && r.name != name {
+		r.file.Close()
+		r.file = nil
+		log.Info("old message record file '%s' closed", r.name)
+	}
+}
+
+// write writes a record to the given file, (re)opening the file if necessary.
+func (r *recorder) write(name string, record *Record) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.closeIfSwitched(name)
+
+	if r.file == nil {
+		f, err := os.Create(name)
+		if err != nil {
+			log.Error("failed to open message record file '%s': %v", name, err)
+			return
+		}
+		log.Info("opened new message record file '%s'", name)
+		r.file = f
+		r.name = name
+	}
+
+	raw, err := json.Marshal(record)
+	if err != nil {
+		log.Error("failed to marshal record of %s: %v", record.Method, err)
+		return
+	}
+
+	if _, err := r.file.Write(append(raw, '\n')); err != nil {
+		log.Error("failed to write message record file '%s': %v", name, err)
+	}
+}
+
+// ReadRecords reads a capture from the given file, sorting records by request time.
+func ReadRecords(path string) ([]*Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, dumpError("failed to open record file '%s': %v", path, err)
+	}
+	defer f.Close()
+
+	records := []*Record{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		r := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), r); err != nil {
+			return nil, dumpError("%s:%d: invalid record: %v", path, line, err)
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, dumpError("failed to read record file '%s': %v", path, err)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+
+	return records, nil
+}
+
+// Decode decodes the request and reply messages of a record.
+func (r *Record) Decode() (interface{}, interface{}, error) {
+	req, rpl, err := NewMessages(r.Method)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(r.Request, req); err != nil {
+		return nil, nil, dumpError("failed to decode %s request: %v", r.Method, err)
+	}
+	if len(r.Reply) != 0 {
+		if err := json.Unmarshal(r.Reply, rpl); err != nil {
+			return nil, nil, dumpError("failed to decode %s reply: %v", r.Method, err)
+		}
+	}
+	return req, rpl, nil
+}
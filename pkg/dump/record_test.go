@@ -0,0 +1,101 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dump
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	api "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dump-record-test-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt.Record = dumpFile(filepath.Join(dir, "record"))
+	defer func() {
+		opt.Record = ""
+		rec.checkFileSwitch("")
+	}()
+
+	now := time.Now()
+	create := &api.CreateContainerRequest{
+		PodSandboxId: "pod0",
+		Config: &api.ContainerConfig{
+			Metadata: &api.ContainerMetadata{Name: "ctr0"},
+			Linux: &api.LinuxContainerConfig{
+				Resources: &api.LinuxContainerResources{CpusetCpus: "0-3"},
+			},
+		},
+	}
+	created := &api.CreateContainerResponse{ContainerId: "ctr0-id"}
+	start := &api.StartContainerRequest{ContainerId: "ctr0-id"}
+	failure := fmt.Errorf("container ctr0-id not found")
+
+	// requests are captured before processing, which might alter them
+	createMethod := "/runtime.v1alpha2.RuntimeService/CreateContainer"
+	startMethod := "/runtime.v1alpha2.RuntimeService/StartContainer"
+	recordedCreate := RecordRequest(createMethod, create)
+	recordedStart := RecordRequest(startMethod, start)
+	create.Config.Linux.Resources.CpusetCpus = "4-7"
+
+	// record out of order, reading should sort by request time
+	RecordMessage("passthrough", startMethod,
+		recordedStart, nil, failure, false, now.Add(time.Second), time.Millisecond)
+	RecordMessage("intercepted", createMethod,
+		recordedCreate, created, nil, false, now, time.Millisecond)
+	RecordMessage("intercepted", startMethod,
+		recordedStart, nil, failure, true, now.Add(2*time.Second), time.Millisecond)
+
+	records, err := ReadRecords(string(opt.Record))
+	if err != nil {
+		t.Fatalf("failed to read records: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+
+	req, rpl, err := records[0].Decode()
+	if err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+	if req.(*api.CreateContainerRequest).GetConfig().GetLinux().GetResources().GetCpusetCpus() != "0-3" {
+		t.Errorf("unexpected decoded request %v", req)
+	}
+	if rpl.(*api.CreateContainerResponse).ContainerId != "ctr0-id" {
+		t.Errorf("unexpected decoded reply %v", rpl)
+	}
+
+	if records[1].Error != failure.Error() || len(records[1].Reply) != 0 || records[1].Rejected {
+		t.Errorf("unexpected failed record %+v", records[1])
+	}
+	if records[2].Error != failure.Error() || !records[2].Rejected {
+		t.Errorf("unexpected rejected record %+v", records[2])
+	}
+	if req, _, err = records[1].Decode(); err != nil {
+		t.Fatalf("failed to decode record: %v", err)
+	}
+	if req.(*api.StartContainerRequest).ContainerId != "ctr0-id" {
+		t.Errorf("unexpected decoded request %v", req)
+	}
+}
@@ -74,6 +74,12 @@ func (tc TraceConfig) MarshalJSON() ([]byte, error) {
 	return json.Marshal(tc)
 }
 
+// ConfigSchema describes TraceConfig for config schema generation.
+func (tc *TraceConfig) ConfigSchema() *config.Schema {
+	return config.EnumSchema("Pre-defined tracing configuration.",
+		"disabled", "production", "testing", "full")
+}
+
 // UnmarshalJSON is the JSON unmarshaller for TraceConfig values.
 func (tc *TraceConfig) UnmarshalJSON(raw []byte) error {
 	var obj interface{}
@@ -19,6 +19,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/intel/cri-resource-manager/pkg/config"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -259,6 +260,33 @@ func (o *options) MarshalJSON() ([]byte, error) {
 	return json.Marshal(cfg)
 }
 
+// ConfigSchema describes our configuration data for config schema generation.
+func (o *options) ConfigSchema() *config.Schema {
+	levels := []interface{}{}
+	for _, name := range []string{"debug", "info", "warn", "error"} {
+		levels = append(levels, name)
+	}
+	backends := []interface{}{}
+	for name := range logging.backends {
+		backends = append(backends, name)
+	}
+	sort.Slice(backends, func(i, j int) bool {
+		return backends[i].(string) < backends[j].(string)
+	})
+
+	return &config.Schema{
+		Type: "object",
+		Properties: map[string]*config.Schema{
+			"Level":  config.EnumSchema("Least severity of log messages to pass through.", levels...),
+			"Logger": config.EnumSchema("Logging backend to use.", backends...),
+			"Enable": {Type: "string",
+				Description: "Comma-separated list of logger sources to enable, '*' for all."},
+			"Debug": {Type: "string",
+				Description: "Comma-separated list of logger sources to enable debugging for, '*' for all."},
+		},
+	}
+}
+
 func (o *options) UnmarshalJSON(raw []byte) error {
 	cfg := map[string]string{}
 
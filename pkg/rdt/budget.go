@@ -0,0 +1,152 @@
+/*
+Copyright 2020 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rdt
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Budget describes the L3 cache and memory bandwidth budget of a dynamic class
+type Budget struct {
+	// L3 is the percentage of L3 cache granted, 0 for no limit
+	L3 uint64
+	// MB is the percentage of memory bandwidth granted, 0 for no limit
+	MB uint64
+}
+
+const (
+	// dynamicClassPrefix is the common prefix of all dynamic class names
+	dynamicClassPrefix = "dynamic"
+)
+
+// ClassName returns the name of the dynamic class for the budget. Containers
+// with identical budgets share the same class (i.e. resctrl group).
+func (b Budget) ClassName() string {
+	name := dynamicClassPrefix
+	if b.L3 > 0 {
+		name += fmt.Sprintf("-l3-%d", b.L3)
+	}
+	if b.MB > 0 {
+		name += fmt.Sprintf("-mb-%d", b.MB)
+	}
+	return name
+}
+
+// IsZero returns true if the budget does not limit any resources
+func (b Budget) IsZero() bool {
+	return b.L3 == 0 && b.MB == 0
+}
+
+// String returns the budget as a human-readable string
+func (b Budget) String() string {
+	return fmt.Sprintf("<L3 %d%%, MB %d%%>", b.L3, b.MB)
+}
+
+// check checks that the budget percentages are in range
+func (b Budget) check() error {
+	if b.L3 > 100 || b.MB > 100 {
+		return rdtError("invalid budget %s, percentages must be within 0-100", b)
+	}
+	return nil
+}
+
+// IsDynamicClass returns true if the given class name is that of a dynamic class
+func IsDynamicClass(name string) bool {
+	_, ok := ParseDynamicClass(name)
+	return ok
+}
+
+// ParseDynamicClass parses the budget from the name of a dynamic class
+func ParseDynamicClass(name string) (Budget, bool) {
+	b := Budget{}
+
+	if !strings.HasPrefix(name, dynamicClassPrefix) {
+		return b, false
+	}
+	fields := strings.Split(strings.TrimPrefix(name, dynamicClassPrefix), "-")
+	if len(fields) < 3 || fields[0] != "" || len(fields)%2 != 1 {
+		return b, false
+	}
+
+	for i := 1; i < len(fields); i += 2 {
+		val, err := strconv.ParseUint(fields[i+1], 10, 7)
+		if err != nil || val == 0 {
+			return b, false
+		}
+		switch {
+		case fields[i] == "l3" && b.L3 == 0 && b.MB == 0:
+			b.L3 = val
+		case fields[i] == "mb" && b.MB == 0:
+			b.MB = val
+		default:
+			return b, false
+		}
+	}
+
+	return b, b.check() == nil
+}
+
+// ParseBudgetPercentage parses a budget percentage, given with or without a
+// trailing percent sign
+func ParseBudgetPercentage(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	pct, err := strconv.ParseUint(strings.TrimSuffix(value, "%"), 10, 7)
+	if err != nil || pct > 100 {
+		return 0, rdtError("invalid budget percentage %q", value)
+	}
+	return pct, nil
+}
+
+// budgetClassConfig returns the class configuration for a budget
+func budgetClassConfig(b Budget, partition string) classConfig {
+	class := classConfig{Partition: partition}
+
+	if b.L3 > 0 {
+		class.L3Schema = make(l3Schema, len(rdtInfo.cacheIds))
+		for _, id := range rdtInfo.cacheIds {
+			class.L3Schema[id] = l3Allocation{Unified: l3PctAllocation(b.L3)}
+		}
+	}
+	if b.MB > 0 {
+		class.MBSchema = make(mbSchema, len(rdtInfo.cacheIds))
+		for _, id := range rdtInfo.cacheIds {
+			class.MBSchema[id] = b.MB
+		}
+	}
+
+	return class
+}
+
+// wholePartition returns a partition spanning all of the RDT resources
+func wholePartition() partitionConfig {
+	partition := partitionConfig{
+		L3: make(l3Schema, len(rdtInfo.cacheIds)),
+		MB: make(mbSchema, len(rdtInfo.cacheIds)),
+	}
+	mb := uint64(100)
+	if rdtInfo.mb.mbpsEnabled {
+		mb = math.MaxUint32
+	}
+	for _, id := range rdtInfo.cacheIds {
+		partition.L3[id] = l3Allocation{Unified: l3AbsoluteAllocation(rdtInfo.l3CbmMask())}
+		partition.MB[id] = mb
+	}
+	return partition
+}
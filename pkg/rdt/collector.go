@@ -0,0 +1,103 @@
+/*
+Copyright 2020 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rdt
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// monFeatureLLCOccupancy is the L3 cache occupancy monitoring feature
+	monFeatureLLCOccupancy = "llc_occupancy"
+	// monFeatureMBMTotal is the total memory bandwidth monitoring feature
+	monFeatureMBMTotal = "mbm_total_bytes"
+	// monFeatureMBMLocal is the local memory bandwidth monitoring feature
+	monFeatureMBMLocal = "mbm_local_bytes"
+)
+
+// monLabels are the labels of all RDT monitoring metrics
+var monLabels = []string{
+	"namespace",
+	"pod",
+	"container",
+	"rdt_class",
+	"mon_group",
+	"cache_id",
+}
+
+var monDescs = map[string]*prometheus.Desc{
+	monFeatureLLCOccupancy: prometheus.NewDesc(
+		"rdt_llc_occupancy_bytes",
+		"L3 cache occupancy of a container, per cache id.",
+		monLabels, nil,
+	),
+	monFeatureMBMTotal: prometheus.NewDesc(
+		"rdt_mbm_total_bytes",
+		"Total memory bandwidth used by a container, per cache id.",
+		monLabels, nil,
+	),
+	monFeatureMBMLocal: prometheus.NewDesc(
+		"rdt_mbm_local_bytes",
+		"Local memory bandwidth used by a container, per cache id.",
+		monLabels, nil,
+	),
+}
+
+var monValueTypes = map[string]prometheus.ValueType{
+	monFeatureLLCOccupancy: prometheus.GaugeValue,
+	monFeatureMBMTotal:     prometheus.CounterValue,
+	monFeatureMBMLocal:     prometheus.CounterValue,
+}
+
+type collector struct {
+}
+
+// NewCollector creates a new Prometheus collector for RDT monitoring data
+func NewCollector() (prometheus.Collector, error) {
+	return &collector{}, nil
+}
+
+// Describe implements prometheus.Collector interface
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range monDescs {
+		ch <- desc
+	}
+}
+
+// Collect implements prometheus.Collector interface
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, mg := range getMonData() {
+		group := mg.group
+		for id, values := range mg.data {
+			for feature, value := range values {
+				desc, ok := monDescs[feature]
+				if !ok {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(
+					desc,
+					monValueTypes[feature],
+					float64(value),
+					group.labels["namespace"], group.labels["pod"], group.labels["container"],
+					group.class, group.name, strconv.FormatUint(id, 10),
+				)
+			}
+		}
+	}
+}
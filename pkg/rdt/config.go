@@ -77,6 +77,9 @@ type classConfig struct {
 type schemaOptions struct {
 	L3 l3Options `json:"l3"`
 	MB mbOptions `json:"mb"`
+	// DynamicPartition is the partition dynamic classes are carved out of,
+	// all of the RDT resources if empty
+	DynamicPartition string `json:"dynamicPartition,omitempty"`
 }
 
 // l3Options contains the common settings for L3 cache allocation
@@ -402,6 +405,12 @@ func (raw options) resolve() (config, error) {
 		return conf, err
 	}
 
+	if name := conf.Options.DynamicPartition; name != "" {
+		if _, ok := conf.Partitions[name]; !ok {
+			return conf, fmt.Errorf("unknown partition %q for dynamic classes", name)
+		}
+	}
+
 	return conf, nil
 }
 
@@ -659,6 +668,9 @@ func (raw options) resolveClasses() (classSet, error) {
 			if _, ok := classes[gname]; ok {
 				return classes, fmt.Errorf("class names must be unique, %q defined multiple times", gname)
 			}
+			if IsDynamicClass(gname) {
+				return classes, fmt.Errorf("class name %q is reserved for dynamic classes", gname)
+			}
 
 			var err error
 			gc := classConfig{Partition: bname}
@@ -0,0 +1,244 @@
+/*
+Copyright 2020 Intel Corporation
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rdt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// monGroupsDir is the directory of monitoring groups within a class
+	monGroupsDir = "mon_groups"
+	// monDataDir is the directory of monitoring data within a group
+	monDataDir = "mon_data"
+	// monL3Prefix is the prefix of per-cache-id L3 monitoring data directories
+	monL3Prefix = "mon_L3_"
+)
+
+// monGroup is a monitoring group within a class
+type monGroup struct {
+	class  string
+	name   string
+	path   string
+	labels map[string]string
+}
+
+// monGroupSet is the set of known monitoring groups
+type monGroupSet struct {
+	sync.RWMutex
+	groups map[string]*monGroup
+}
+
+// our known monitoring groups, by name
+var monGroups = &monGroupSet{groups: make(map[string]*monGroup)}
+
+// MonSupported returns true if RDT monitoring is supported in the system
+func MonSupported() bool {
+	return rdtInfo.l3mon.Supported()
+}
+
+func (r *control) SetMonGroup(class, group string, labels map[string]string, pids ...string) error {
+	if !MonSupported() {
+		return rdtError("RDT monitoring not supported by the system")
+	}
+	if !r.knownClass(class) && class != rootClassName {
+		return rdtError("unknown RDT class %q", class)
+	}
+
+	monGroups.Lock()
+	defer monGroups.Unlock()
+
+	if old, ok := monGroups.groups[group]; ok && old.class != class {
+		if err := r.removeMonGroup(old); err != nil {
+			return err
+		}
+	}
+
+	path := r.monGroupPath(class, group)
+	if err := os.Mkdir(path, 0755); err != nil && !os.IsExist(err) {
+		return rdtError("failed to create monitoring group %q: %v", path, r.cmdError(err))
+	}
+	monGroups.groups[group] = &monGroup{class: class, name: group, path: path, labels: labels}
+
+	if err := r.writeTasks(filepath.Join(path, "tasks"), pids); err != nil {
+		return rdtError("failed to assign processes %v to monitoring group %q: %v",
+			pids, group, err)
+	}
+
+	return nil
+}
+
+func (r *control) DeleteMonGroup(group string) error {
+	monGroups.Lock()
+	defer monGroups.Unlock()
+
+	mg, ok := monGroups.groups[group]
+	if !ok {
+		return nil
+	}
+	return r.removeMonGroup(mg)
+}
+
+func (r *control) PruneMonGroups(active ...string) error {
+	keep := make(map[string]struct{}, len(active))
+	for _, group := range active {
+		keep[group] = struct{}{}
+	}
+
+	monGroups.Lock()
+	defer monGroups.Unlock()
+
+	for name, mg := range monGroups.groups {
+		if _, ok := keep[name]; ok {
+			continue
+		}
+		if err := r.removeMonGroup(mg); err != nil {
+			return err
+		}
+		r.Info("removed stale monitoring group %q", name)
+	}
+	return nil
+}
+
+// removeMonGroup removes a monitoring group, with monGroups locked
+func (r *control) removeMonGroup(mg *monGroup) error {
+	if err := os.Remove(mg.path); err != nil && !os.IsNotExist(err) {
+		return rdtError("failed to remove monitoring group %q: %v", mg.path, err)
+	}
+	delete(monGroups.groups, mg.name)
+	return nil
+}
+
+// forgetMonGroups forgets the monitoring groups of a removed class
+func (r *control) forgetMonGroups(class string) {
+	monGroups.Lock()
+	defer monGroups.Unlock()
+
+	for name, mg := range monGroups.groups {
+		if mg.class == class {
+			delete(monGroups.groups, name)
+		}
+	}
+}
+
+// adoptMonGroups discovers existing monitoring groups, possibly left behind
+// by an earlier instance. Adopted groups have no labels until they are set
+// up again for a container, and are removed by PruneMonGroups otherwise
+func (r *control) adoptMonGroups() error {
+	if !MonSupported() {
+		return nil
+	}
+
+	classes, err := r.getClasses()
+	if err != nil {
+		return err
+	}
+	classes = append(classes, rootClassName)
+
+	monGroups.Lock()
+	defer monGroups.Unlock()
+
+	for _, class := range classes {
+		dir := filepath.Join(r.resctrlGroupPath(class), monGroupsDir)
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				monGroups.groups[e.Name()] = &monGroup{
+					class: class,
+					name:  e.Name(),
+					path:  filepath.Join(dir, e.Name()),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *control) monGroupPath(class, group string) string {
+	return filepath.Join(r.resctrlGroupPath(class), monGroupsDir, group)
+}
+
+// monGroupData is the monitoring data of a group
+type monGroupData struct {
+	group *monGroup
+	// data holds feature values by cache id
+	data map[uint64]map[string]uint64
+}
+
+// getMonData reads the monitoring data of all known monitoring groups
+func getMonData() []monGroupData {
+	monGroups.RLock()
+	defer monGroups.RUnlock()
+
+	result := make([]monGroupData, 0, len(monGroups.groups))
+	for _, mg := range monGroups.groups {
+		data, err := mg.read()
+		if err != nil {
+			// the group might have been removed while we were reading it
+			log.Debug("failed to read monitoring data of group %q: %v", mg.name, err)
+			continue
+		}
+		result = append(result, monGroupData{group: mg, data: data})
+	}
+
+	return result
+}
+
+// read reads the monitoring data of a group, per cache id
+func (mg *monGroup) read() (map[uint64]map[string]uint64, error) {
+	dir := filepath.Join(mg.path, monDataDir)
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[uint64]map[string]uint64, len(entries))
+	for _, e := range entries {
+		if !strings.HasPrefix(e.Name(), monL3Prefix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimPrefix(e.Name(), monL3Prefix), 10, 64)
+		if err != nil {
+			return nil, rdtError("invalid monitoring data directory %q", e.Name())
+		}
+		values := make(map[string]uint64, len(rdtInfo.l3mon.monFeatures))
+		for feature := range rdtInfo.l3mon.monFeatures {
+			value, err := readFileUint64(filepath.Join(dir, e.Name(), feature))
+			if err != nil {
+				// values are "Unavailable" until the hardware can provide them
+				continue
+			}
+			values[feature] = value
+		}
+		data[id] = values
+	}
+
+	return data, nil
+}
@@ -45,6 +45,24 @@ type Control interface {
 
 	// SetProcessClass assigns a set of processes to a RDT class
 	SetProcessClass(string, ...string) error
+
+	// SetBudgetClass creates or updates the dynamic class for the given
+	// budget, returning the name of the class
+	SetBudgetClass(Budget) (string, error)
+
+	// PruneBudgetClasses removes dynamic classes with no processes left
+	PruneBudgetClasses() error
+
+	// SetMonGroup assigns a set of processes to a monitoring group of a RDT
+	// class, creating the group if necessary. The namespace, pod and
+	// container labels of the group are exported with its metrics
+	SetMonGroup(class, group string, labels map[string]string, pids ...string) error
+
+	// DeleteMonGroup removes a monitoring group
+	DeleteMonGroup(group string) error
+
+	// PruneMonGroups removes all monitoring groups except the given active ones
+	PruneMonGroups(active ...string) error
 }
 
 var rdtInfo Info
@@ -54,13 +72,14 @@ var log logger.Logger = logger.NewLogger("rdt")
 type control struct {
 	logger.Logger
 
-	conf config
+	conf    config
+	dynamic map[string]Budget
 }
 
 // NewControl returns new instance of the RDT Control interface
 func NewControl(resctrlpath string) (Control, error) {
 	var err error
-	r := &control{Logger: log}
+	r := &control{Logger: log, dynamic: make(map[string]Budget)}
 
 	// Get info from the resctrl filesystem
 	rdtInfo, err = getRdtInfo(resctrlpath)
@@ -78,7 +97,12 @@ func NewControl(resctrlpath string) (Control, error) {
 		return nil, rdtError("configuration failed: %v", err)
 	}
 
+	if err := r.adoptMonGroups(); err != nil {
+		return nil, rdtError("failed to discover monitoring groups: %v", err)
+	}
+
 	pkgcfg.GetModule("rdt").AddNotify(r.configNotify)
+	pkgcfg.GetModule("rdt").AddCheck(r.configCheck)
 
 	return r, nil
 }
@@ -96,29 +120,71 @@ func (r *control) GetClasses() []string {
 }
 
 func (r *control) SetProcessClass(class string, pids ...string) error {
-	if _, ok := r.conf.Classes[class]; !ok {
+	if !r.knownClass(class) {
 		return rdtError("unknown RDT class %q", class)
 	}
 
 	path := filepath.Join(r.resctrlGroupPath(class), "tasks")
-	f, err := os.OpenFile(path, os.O_WRONLY, 0644)
-	if err != nil {
-		return err
+	if err := r.writeTasks(path, pids); err != nil {
+		return rdtError("failed to assign processes %v to class %q: %v", pids, class, err)
 	}
-	defer f.Close()
+	return nil
+}
 
-	for _, pid := range pids {
-		if _, err := f.WriteString(pid + "\n"); err != nil {
-			unwrapped := err
-			if pathError, ok := err.(*os.PathError); ok {
-				unwrapped = pathError.Unwrap()
-			}
-			if unwrapped == syscall.ESRCH {
-				r.Debug("no task %s", pid)
-			} else {
-				return rdtError("failed to assign processes %v to class %q: %v", pids, class, r.cmdError(err))
-			}
+func (r *control) SetBudgetClass(b Budget) (string, error) {
+	if b.IsZero() {
+		return "", rdtError("refusing to create dynamic class for empty budget")
+	}
+	if err := b.check(); err != nil {
+		return "", err
+	}
+	if b.MB > 0 && rdtInfo.mb.mbpsEnabled {
+		return "", rdtError("memory bandwidth budgets not supported with 'mba_MBps'")
+	}
+
+	name := b.ClassName()
+	if _, ok := r.dynamic[name]; ok {
+		return name, nil
+	}
+
+	if uint64(len(r.conf.Classes)+len(r.dynamic)+1) >= rdtInfo.numClosids {
+		return "", rdtError("can't create class %q, out of CLOSIDs (%d in use)",
+			name, len(r.conf.Classes)+len(r.dynamic)+1)
+	}
+
+	if err := r.configureBudgetGroup(name, b, r.conf); err != nil {
+		return "", rdtError("failed to create dynamic class %q: %v", name, err)
+	}
+	r.dynamic[name] = b
+	r.Info("created dynamic class %q for budget %s", name, b)
+
+	return name, nil
+}
+
+func (r *control) PruneBudgetClasses() error {
+	for name := range r.dynamic {
+		tasks, err := r.getClassTasks(name)
+		if err != nil {
+			return rdtError("failed to get resctrl group tasks: %v", err)
+		}
+		if len(tasks) > 0 {
+			continue
 		}
+		path := r.resctrlGroupPath(name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return rdtError("failed to remove resctrl group %q: %v", path, err)
+		}
+		delete(r.dynamic, name)
+		r.forgetMonGroups(name)
+		r.Info("removed unused dynamic class %q", name)
+	}
+	return nil
+}
+
+// configCheck checks a candidate configuration without taking it into use.
+func (r *control) configCheck(candidate interface{}, source pkgcfg.Source) error {
+	if _, err := candidate.(*options).resolve(); err != nil {
+		return rdtError("invalid configuration: %v", err)
 	}
 	return nil
 }
@@ -152,6 +218,11 @@ func (r *control) configureResctrl(conf config) error {
 	}
 
 	for _, name := range existingClasses {
+		// Adopt dynamic classes, possibly left behind by an earlier instance
+		if b, ok := ParseDynamicClass(name); ok {
+			r.dynamic[name] = b
+			continue
+		}
 		if _, ok := conf.Classes[name]; !ok {
 			tasks, err := r.getClassTasks(name)
 			if err != nil {
@@ -165,6 +236,7 @@ func (r *control) configureResctrl(conf config) error {
 			if err != nil {
 				return rdtError("failed to remove resctrl group %q: %v", path, err)
 			}
+			r.forgetMonGroups(name)
 		}
 	}
 
@@ -177,9 +249,26 @@ func (r *control) configureResctrl(conf config) error {
 		}
 	}
 
+	// Resize dynamic classes according to the given configuration
+	for name, budget := range r.dynamic {
+		if err := r.configureBudgetGroup(name, budget, conf); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+func (r *control) configureBudgetGroup(name string, b Budget, conf config) error {
+	partition := wholePartition()
+	if conf.Options.DynamicPartition != "" {
+		partition = conf.Partitions[conf.Options.DynamicPartition]
+	}
+	class := budgetClassConfig(b, conf.Options.DynamicPartition)
+
+	return r.configureResctrlGroup(name, class, partition, conf.Options)
+}
+
 func (r *control) configureResctrlGroup(name string, class classConfig,
 	partition partitionConfig, options schemaOptions) error {
 	if err :=
@@ -237,6 +326,41 @@ func (r *control) configureResctrlGroup(name string, class classConfig,
 	return nil
 }
 
+func (r *control) knownClass(class string) bool {
+	if _, ok := r.conf.Classes[class]; ok {
+		return true
+	}
+	_, ok := r.dynamic[class]
+	return ok
+}
+
+func (r *control) writeTasks(path string, pids []string) error {
+	if len(pids) == 0 {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	for _, pid := range pids {
+		if _, err := f.WriteString(pid + "\n"); err != nil {
+			unwrapped := err
+			if pathError, ok := err.(*os.PathError); ok {
+				unwrapped = pathError.Unwrap()
+			}
+			if unwrapped == syscall.ESRCH {
+				r.Debug("no task %s", pid)
+			} else {
+				return r.cmdError(err)
+			}
+		}
+	}
+	return nil
+}
+
 func (r *control) resctrlGroupDirName(name string) string {
 	if name == rootClassName {
 		return ""
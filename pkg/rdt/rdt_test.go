@@ -17,9 +17,15 @@ limitations under the License.
 package rdt
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/intel/cri-resource-manager/pkg/testutils"
 )
 
 func TestBitMap(t *testing.T) {
@@ -120,3 +126,142 @@ func TestListStrToArray(t *testing.T) {
 		}
 	}
 }
+
+func TestDynamicClass(t *testing.T) {
+	testSet := map[Budget]string{
+		{L3: 50}:         "dynamic-l3-50",
+		{MB: 70}:         "dynamic-mb-70",
+		{L3: 1, MB: 100}: "dynamic-l3-1-mb-100",
+	}
+	for b, s := range testSet {
+		// Test conversion to class name
+		name := b.ClassName()
+		if name != s {
+			t.Errorf("from %s expected %q, got %q", b, s, name)
+		}
+
+		// Test conversion from class name
+		parsed, ok := ParseDynamicClass(s)
+		if !ok {
+			t.Errorf("failed to parse dynamic class %q", s)
+		}
+		if parsed != b {
+			t.Errorf("from %q expected %s, got %s", s, b, parsed)
+		}
+	}
+
+	// Negative test cases
+	negTestSet := []string{
+		"",
+		"dynamic",
+		"dynamic-",
+		"dynamic-l3",
+		"dynamic-l3-0",
+		"dynamic-l3-101",
+		"dynamic-l3-50-",
+		"dynamic-mb-50-l3-50",
+		"dynamic-l3-50-l3-50",
+		"dynamic-cpu-50",
+		"dynamicl3-50",
+		"Guaranteed",
+	}
+	for _, s := range negTestSet {
+		if b, ok := ParseDynamicClass(s); ok {
+			t.Errorf("expected failure but got %s when parsing %q", b, s)
+		}
+	}
+}
+
+func TestMonitoring(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rdt-test-")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	testutils.CreateFiles(t, dir, map[string]string{
+		"schemata":                  "L3:0=fff;1=fff\n",
+		"info/L3/cbm_mask":          "fff\n",
+		"info/L3/min_cbm_bits":      "1\n",
+		"info/L3/shareable_bits":    "0\n",
+		"info/L3/num_closids":       "16\n",
+		"info/L3_MON/num_rmids":     "224\n",
+		"info/L3_MON/mon_features":  "llc_occupancy\nmbm_total_bytes\nmbm_local_bytes\n",
+		"mon_groups/stale/tasks":    "",
+		"mon_groups/stale/mon_data": "",
+	})
+
+	ctl, err := NewControl(dir)
+	if err != nil {
+		t.Fatalf("failed to create RDT control: %v", err)
+	}
+	if !MonSupported() {
+		t.Fatalf("RDT monitoring not detected")
+	}
+	if _, ok := monGroups.groups["stale"]; !ok {
+		t.Errorf("existing monitoring group not adopted")
+	}
+
+	labels := map[string]string{"namespace": "default", "pod": "pod0", "container": "ctr0"}
+	if err := ctl.SetMonGroup(rootClassName, "ctr0", labels); err != nil {
+		t.Fatalf("failed to set up monitoring group: %v", err)
+	}
+	testutils.CreateFiles(t, filepath.Join(dir, "mon_groups", "ctr0", "mon_data"), map[string]string{
+		"mon_L3_00/llc_occupancy":   "1024\n",
+		"mon_L3_00/mbm_total_bytes": "2048\n",
+		"mon_L3_00/mbm_local_bytes": "Unavailable\n",
+		"mon_L3_01/llc_occupancy":   "4096\n",
+	})
+
+	for _, mg := range getMonData() {
+		if mg.group.name != "ctr0" {
+			continue
+		}
+		expected := map[uint64]map[string]uint64{
+			0: {"llc_occupancy": 1024, "mbm_total_bytes": 2048},
+			1: {"llc_occupancy": 4096},
+		}
+		if !cmp.Equal(mg.data, expected) {
+			t.Errorf("expected monitoring data %v, got %v", expected, mg.data)
+		}
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	collector, _ := NewCollector()
+	reg.MustRegister(collector)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	count := 0
+	for _, f := range families {
+		count += len(f.GetMetric())
+	}
+	if count != 3 {
+		t.Errorf("expected 3 metrics, got %d", count)
+	}
+
+	os.RemoveAll(filepath.Join(dir, "mon_groups", "stale", "mon_data"))
+	os.Remove(filepath.Join(dir, "mon_groups", "stale", "tasks"))
+	if err := ctl.PruneMonGroups("ctr0"); err != nil {
+		t.Errorf("failed to prune monitoring groups: %v", err)
+	}
+	if _, ok := monGroups.groups["stale"]; ok {
+		t.Errorf("stale monitoring group not pruned")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mon_groups", "stale")); !os.IsNotExist(err) {
+		t.Errorf("stale monitoring group not removed")
+	}
+	if _, ok := monGroups.groups["ctr0"]; !ok {
+		t.Errorf("active monitoring group pruned")
+	}
+
+	os.RemoveAll(filepath.Join(dir, "mon_groups", "ctr0", "mon_data"))
+	os.Remove(filepath.Join(dir, "mon_groups", "ctr0", "tasks"))
+	if err := ctl.DeleteMonGroup("ctr0"); err != nil {
+		t.Errorf("failed to delete monitoring group: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mon_groups", "ctr0")); !os.IsNotExist(err) {
+		t.Errorf("monitoring group not removed")
+	}
+}
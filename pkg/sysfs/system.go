@@ -55,16 +55,16 @@ const (
 
 // System devices
 type System struct {
-	logger.Logger                 // our logger instance
-	flags         DiscoveryFlag   // system discovery flags
-	path          string          // sysfs mount point
-	packages      map[ID]*Package // physical packages
-	nodes         map[ID]*Node    // NUMA nodes
-	cpus          map[ID]*CPU     // CPUs
-	cache         map[ID]*Cache   // Cache
-	offline       IDSet           // offlined CPUs
-	isolated      IDSet           // isolated CPUs
-	threads       int             // hyperthreads per core
+	logger.Logger                     // our logger instance
+	flags         DiscoveryFlag       // system discovery flags
+	path          string              // sysfs mount point
+	packages      map[ID]*Package     // physical packages
+	nodes         map[ID]*Node        // NUMA nodes
+	cpus          map[ID]*CPU         // CPUs
+	cache         map[cacheKey]*Cache // CPU caches
+	offline       IDSet               // offlined CPUs
+	isolated      IDSet               // isolated CPUs
+	threads       int                 // hyperthreads per core
 }
 
 // Package is a physical package (a collection of CPUs).
@@ -109,10 +109,17 @@ type MemInfo struct {
 	MemUsed  uint64
 }
 
+// HugePages contains data read from a NUMA node huge page pool directory.
+type HugePages struct {
+	Size  uint64 // huge page size in bytes
+	Total uint64 // number of huge pages
+	Free  uint64 // number of free huge pages
+}
+
 // CPU cache.
-//   Notes: cache-discovery is forced off now (by forcibly clearing the related discovery bit)
-//      Can't seem to make sense of the cache information exposed under sysfs. The cache ids
-//      do not seem to be unique, which IIUC is contrary to the documentation.
+//   Notes: cache ids under sysfs are only unique among caches of the same level and type
+//      (and are missing altogether on older kernels), so we identify caches by their level,
+//      type and the set of CPUs sharing them instead.
 
 // CacheType specifies a cache type.
 type CacheType string
@@ -135,8 +142,20 @@ type Cache struct {
 	cpus  IDSet     // CPUs sharing this cache
 }
 
+// cacheKey identifies a cache.
+type cacheKey struct {
+	level uint8     // cache level
+	kind  CacheType // cache type
+	cpus  string    // CPUs sharing the cache
+}
+
 // DiscoverSystem performs discovery of the running systems details.
 func DiscoverSystem(args ...DiscoveryFlag) (*System, error) {
+	return DiscoverSystemAt(SysfsRootPath, args...)
+}
+
+// DiscoverSystemAt performs discovery of the system details under the given sysfs root.
+func DiscoverSystemAt(path string, args ...DiscoveryFlag) (*System, error) {
 	var flags DiscoveryFlag
 
 	if len(args) < 1 {
@@ -150,7 +169,7 @@ func DiscoverSystem(args ...DiscoveryFlag) (*System, error) {
 
 	sys := &System{
 		Logger:  logger.NewLogger("sysfs"),
-		path:    SysfsRootPath,
+		path:    path,
 		offline: NewIDSet(),
 	}
 
@@ -163,7 +182,7 @@ func DiscoverSystem(args ...DiscoveryFlag) (*System, error) {
 
 // Discover performs system/hardware discovery.
 func (sys *System) Discover(flags DiscoveryFlag) error {
-	sys.flags |= (flags &^ DiscoverCache)
+	sys.flags |= flags
 
 	if (sys.flags & (DiscoverCPUTopology | DiscoverCache)) != 0 {
 		if err := sys.discoverCPUs(); err != nil {
@@ -217,8 +236,8 @@ func (sys *System) Discover(flags DiscoveryFlag) error {
 		sys.Debug("offline CPUs: %s", sys.offline)
 		sys.Debug("isolated CPUs: %s", sys.isolated)
 
-		for id, cch := range sys.cache {
-			sys.Debug("cache #%d:", id)
+		for _, cch := range sys.cache {
+			sys.Debug("L%d %s cache #%d:", cch.level, cch.kind, cch.id)
 			sys.Debug("   type: %v", cch.kind)
 			sys.Debug("   size: %d", cch.size)
 			sys.Debug("  level: %d", cch.level)
@@ -462,7 +481,7 @@ func (sys *System) discoverCPU(path string) error {
 		entries, _ := filepath.Glob(filepath.Join(path, "cache/index[0-9]*"))
 		for _, entry := range entries {
 			if err := sys.discoverCache(entry); err != nil {
-				return err
+				sys.Warn("%v", err)
 			}
 		}
 	}
@@ -634,6 +653,33 @@ func (n *Node) MemoryInfo() (*MemInfo, error) {
 	return buf, nil
 }
 
+// HugePages returns the huge page pools of the node.
+func (n *Node) HugePages() ([]HugePages, error) {
+	dirs, err := filepath.Glob(filepath.Join(n.path, "hugepages", "hugepages-*kB"))
+	if err != nil {
+		return nil, sysfsError(n.path, "failed to look for huge pages: %v", err)
+	}
+
+	pools := []HugePages{}
+	for _, dir := range dirs {
+		kB := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(dir), "hugepages-"), "kB")
+		size, err := strconv.ParseUint(kB, 10, 64)
+		if err != nil {
+			return nil, sysfsError(dir, "failed to parse huge page size: %v", err)
+		}
+		pool := HugePages{Size: size * 1024}
+		if _, err := readSysfsEntry(dir, "nr_hugepages", &pool.Total); err != nil {
+			return nil, err
+		}
+		if _, err := readSysfsEntry(dir, "free_hugepages", &pool.Free); err != nil {
+			return nil, err
+		}
+		pools = append(pools, pool)
+	}
+
+	return pools, nil
+}
+
 // Discover physical packages (CPU sockets) present in the system.
 func (sys *System) discoverPackages() error {
 	if sys.packages != nil {
@@ -675,25 +721,8 @@ func (p *Package) NodeIDs() []ID {
 }
 
 // Discover cache associated with the given CPU.
-// Notes:
-//     I'm not sure how to interpret the cache information under sysfs. This code is now effectively
-//     disabled by forcing the associated discovery bit off in the discovery flags.
 func (sys *System) discoverCache(path string) error {
-	var id ID
-
-	if _, err := readSysfsEntry(path, "id", &id); err != nil {
-		return sysfsError(path, "can't read cache id: %v", err)
-	}
-
-	if sys.cache == nil {
-		sys.cache = make(map[ID]*Cache)
-	}
-
-	if _, found := sys.cache[id]; found {
-		return nil
-	}
-
-	c := &Cache{id: id}
+	c := &Cache{id: -1}
 
 	if _, err := readSysfsEntry(path, "level", &c.level); err != nil {
 		return sysfsError(path, "can't read cache level: %v", err)
@@ -716,27 +745,80 @@ func (sys *System) discoverCache(path string) error {
 		return sysfsError(path, "unknown cache type: %s", kind)
 	}
 
+	key := cacheKey{level: c.level, kind: c.kind, cpus: c.cpus.String()}
+	if sys.cache == nil {
+		sys.cache = make(map[cacheKey]*Cache)
+	}
+	if _, found := sys.cache[key]; found {
+		return nil
+	}
+
+	// cache ids are not available on older kernels
+	readSysfsEntry(path, "id", &c.id)
+
 	size := ""
 	if _, err := readSysfsEntry(path, "size", &size); err != nil {
 		return sysfsError(path, "can't read cache size: %v", err)
 	}
 
-	base := size[0 : len(size)-1]
-	suff := size[len(size)-1]
-	unit := map[byte]uint64{'K': 1 << 10, 'M': 1 << 20, 'G': 1 << 30}
+	if size != "" {
+		base, unit := size, uint64(1)
+		switch size[len(size)-1] {
+		case 'K':
+			base, unit = size[0:len(size)-1], 1<<10
+		case 'M':
+			base, unit = size[0:len(size)-1], 1<<20
+		case 'G':
+			base, unit = size[0:len(size)-1], 1<<30
+		}
 
-	val, err := strconv.ParseUint(base, 10, 0)
-	if err != nil {
-		return sysfsError(path, "can't parse cache size '%s': %v", size, err)
+		val, err := strconv.ParseUint(base, 10, 0)
+		if err != nil {
+			return sysfsError(path, "can't parse cache size '%s': %v", size, err)
+		}
+		c.size = val * unit
 	}
 
-	if u, ok := unit[suff]; ok {
-		c.size = val * u
-	} else {
-		c.size = val*1000 + u - '0'
+	sys.cache[key] = c
+
+	return nil
+}
+
+// CacheDomains returns the data and unified caches of the given level, sorted by their first CPU.
+func (sys *System) CacheDomains(level uint8) []*Cache {
+	caches := []*Cache{}
+	for _, c := range sys.cache {
+		if c.level == level && c.kind != InstructionCache && c.cpus.Size() > 0 {
+			caches = append(caches, c)
+		}
 	}
+	sort.Slice(caches, func(i, j int) bool {
+		return caches[i].cpus.SortedMembers()[0] < caches[j].cpus.SortedMembers()[0]
+	})
+	return caches
+}
 
-	sys.cache[c.id] = c
+// ID returns the id of this cache, unique only among caches of the same level and type.
+func (c *Cache) ID() ID {
+	return c.id
+}
 
-	return nil
+// Level returns the level of this cache.
+func (c *Cache) Level() uint8 {
+	return c.level
+}
+
+// Type returns the type of this cache.
+func (c *Cache) Type() CacheType {
+	return c.kind
+}
+
+// Size returns the size of this cache in bytes.
+func (c *Cache) Size() uint64 {
+	return c.size
+}
+
+// CPUSet returns the CPUSet of all CPUs sharing this cache.
+func (c *Cache) CPUSet() cpuset.CPUSet {
+	return c.cpus.CPUSet()
 }
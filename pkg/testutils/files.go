@@ -0,0 +1,45 @@
+// Copyright 2020 Intel Corporation. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutils provides helpers shared by the unit tests of other packages.
+package testutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// CreateFiles creates the given files, with their parent directories, under root.
+func CreateFiles(t *testing.T, root string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory for %s: %v", path, err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+}
+
+// TempDir creates a temporary directory and returns it with a function to remove it.
+func TempDir(t *testing.T, prefix string) (string, func()) {
+	dir, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	return dir, func() { os.RemoveAll(dir) }
+}
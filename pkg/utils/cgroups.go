@@ -15,19 +15,11 @@
 package utils
 
 import (
-	"bufio"
-	"fmt"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 )
 
-const (
-	cgroupTasks     = "tasks"
-	cpusetCgroupDir = "/sys/fs/cgroup/cpuset/"
-)
-
 // GetContainerCgroupDir finds container path in one specified subsystem directory
 func GetContainerCgroupDir(subsystemDir, containerID string) string {
 	var containerDir string
@@ -49,50 +41,24 @@ func GetContainerCgroupDir(subsystemDir, containerID string) string {
 	return containerDir
 }
 
-// GetProcessInContainer gets the IDs of all processes in the container.
-func GetProcessInContainer(cgroupParentDir, containerID string) ([]string, error) {
-	var entries []string
-
-	// Find Cpuset sub-cgroup directory of this container
-	containerDir := ""
+// FindContainerCgroupDir finds the cgroup directory of a container in a subsystem directory.
+func FindContainerCgroupDir(subsystemDir, cgroupParentDir, containerID string) string {
 	// Probe known per-container directories
 	if cgroupParentDir != "" {
 		dirs := []string{
-			filepath.Join(cpusetCgroupDir, cgroupParentDir, "cri-containerd-"+containerID+".scope"),
-			filepath.Join(cpusetCgroupDir, cgroupParentDir, "crio-"+containerID+".scope"),
-			filepath.Join(cpusetCgroupDir, cgroupParentDir, "docker-"+containerID+".scope"),
-			filepath.Join(cpusetCgroupDir, cgroupParentDir, containerID),
+			filepath.Join(subsystemDir, cgroupParentDir, "cri-containerd-"+containerID+".scope"),
+			filepath.Join(subsystemDir, cgroupParentDir, "crio-"+containerID+".scope"),
+			filepath.Join(subsystemDir, cgroupParentDir, "docker-"+containerID+".scope"),
+			filepath.Join(subsystemDir, cgroupParentDir, containerID),
 		}
 		for _, d := range dirs {
 			info, err := os.Stat(d)
 			if err == nil && info.IsDir() {
-				containerDir = d
-				break
+				return d
 			}
 		}
 	}
 
 	// Try generic way to search container directory under one cgroups subsytem directory
-	if containerDir == "" {
-		containerDir = GetContainerCgroupDir(cpusetCgroupDir, containerID)
-		if containerDir == "" {
-			return nil, fmt.Errorf("failed to find corresponding cgroups directory for container %s", containerID)
-		}
-	}
-
-	// Find all processes listed in cgroup tasks file and apply to RDT CLOS
-	cgroupTasksFileName := path.Join(containerDir, cgroupTasks)
-
-	file, err := os.Open(cgroupTasksFileName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file %s", cgroupTasksFileName)
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		entry := scanner.Text()
-		entries = append(entries, entry)
-	}
-	return entries, nil
+	return GetContainerCgroupDir(subsystemDir, containerID)
 }